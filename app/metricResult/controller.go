@@ -26,10 +26,12 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/github.com/gorilla/mux"
 	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2/bson"
+	"github.com/ARGOeu/argo-web-api/respond"
 	"github.com/ARGOeu/argo-web-api/utils/authentication"
 	"github.com/ARGOeu/argo-web-api/utils/config"
 	"github.com/ARGOeu/argo-web-api/utils/mongo"
@@ -66,6 +68,8 @@ func GetMetricResult(r *http.Request, cfg config.Config) (int, http.Header, []by
 		MetricName:   vars["metric_name"],
 		Format:       r.Header.Get("Accept"),
 		ExecTime:     urlValues.Get("exec_time"),
+		StartTime:    urlValues.Get("start_time"),
+		EndTime:      urlValues.Get("end_time"),
 	}
 
 	// TODO: Decide which format (xml or json) should be the default
@@ -73,10 +77,18 @@ func GetMetricResult(r *http.Request, cfg config.Config) (int, http.Header, []by
 		contentType = "application/xml"
 	} else if input.Format == "application/json" {
 		contentType = "application/json"
+	} else if input.Format == "text/csv" {
+		contentType = "text/csv"
 	}
 
 	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
 
+	if errs := validateTimes(input); len(errs) > 0 {
+		code = http.StatusBadRequest
+		output = respond.CreateFailureResponseMessage("Bad Request", "400", errs).MarshalTo(contentType)
+		return code, h, output, nil
+	}
+
 	session, err := mongo.OpenSession(tenantDbConfig)
 	defer mongo.CloseSession(session)
 
@@ -85,14 +97,27 @@ func GetMetricResult(r *http.Request, cfg config.Config) (int, http.Header, []by
 		return code, h, output, err
 	}
 
-	result := metricResultOutput{}
+	results := []metricResultOutput{}
 
 	metricCol := session.DB(tenantDbConfig.Db).C("status_metrics")
 
-	// Query the detailed metric results
-	err = metricCol.Find(prepQuery(input)).One(&result)
+	// Query the detailed metric results: the execution at exec_time, all executions between
+	// start_time and end_time or the latest execution when no time is given
+	query := metricCol.Find(prepQuery(input))
+	if input.ExecTime != "" {
+		err = query.Limit(1).All(&results)
+	} else if input.StartTime != "" {
+		err = query.Sort("timestamp").All(&results)
+	} else {
+		err = query.Sort("-timestamp").Limit(1).All(&results)
+	}
 
-	output, err = createMetricResultView(result, input.Format)
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	output, err = createMetricResultView(results, input.Format)
 
 	if err != nil {
 		code = http.StatusInternalServerError
@@ -102,25 +127,239 @@ func GetMetricResult(r *http.Request, cfg config.Config) (int, http.Header, []by
 	return code, h, output, err
 }
 
-func prepQuery(input metricResultQuery) bson.M {
+// SearchMetricResults looks up the executions whose summary or message contain the searched terms over a
+// time span, optionally narrowed down to a host and a metric
+func SearchMetricResults(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
+
+	//STANDARD DECLARATIONS START
+	code := http.StatusOK
+	h := http.Header{}
+	output := []byte("")
+	err := error(nil)
+	contentType := "application/xml"
+	charset := "utf-8"
+	//STANDARD DECLARATIONS END
+
+	tenantDbConfig, err := authentication.AuthenticateTenant(r.Header, cfg)
+	if err != nil {
+		if err.Error() == "Unauthorized" {
+			code = http.StatusUnauthorized
+			return code, h, output, err
+		}
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	urlValues := r.URL.Query()
+
+	input := metricResultSearch{
+		Terms:        strings.TrimSpace(urlValues.Get("q")),
+		EndpointName: urlValues.Get("host"),
+		MetricName:   urlValues.Get("metric"),
+		Format:       r.Header.Get("Accept"),
+		StartTime:    urlValues.Get("start_time"),
+		EndTime:      urlValues.Get("end_time"),
+		Limit:        urlValues.Get("limit"),
+	}
+
+	if input.Format == "application/xml" {
+		contentType = "application/xml"
+	} else if input.Format == "application/json" {
+		contentType = "application/json"
+	} else if input.Format == "text/csv" {
+		contentType = "text/csv"
+	}
+
+	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
+
+	terms := searchTerms(input.Terms)
+	errs := validateSpan(input.StartTime, input.EndTime)
+	errs = append(errs, input.validateLimit()...)
+	if len(terms) == 0 {
+		errs = append(errs, respond.ErrorResponse{
+			Message: "No search terms",
+			Code:    "400",
+			Details: "Please use the q url parameter to set the terms to search for",
+		})
+	}
+	if len(errs) > 0 {
+		code = http.StatusBadRequest
+		output = respond.CreateFailureResponseMessage("Bad Request", "400", errs).MarshalTo(contentType)
+		return code, h, output, nil
+	}
+
+	session, err := mongo.OpenSession(tenantDbConfig)
+	defer mongo.CloseSession(session)
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	// The text index is created along with the tenant database, not on every search
+	results := []metricResultOutput{}
+	err = mongo.Pipe(session, tenantDbConfig.Db, "status_metrics", prepSearchQuery(input), &results)
+
+	if err != nil && strings.Contains(err.Error(), "text index required") {
+		code = http.StatusInternalServerError
+		errs = []respond.ErrorResponse{{
+			Message: "Missing text index",
+			Code:    "500",
+			Details: "The metric results of the tenant have no text index to search, please create it as documented",
+		}}
+		output = respond.CreateFailureResponseMessage("Internal Server Error", "500", errs).MarshalTo(contentType)
+		return code, h, output, err
+	}
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	output, err = createSearchView(results, terms, input.Format)
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	return code, h, output, err
+}
+
+// validateTimes checks that an execution is looked up either at an exact exec_time or over a time span
+// with both start_time and end_time
+func validateTimes(input metricResultQuery) []respond.ErrorResponse {
+	errs := []respond.ErrorResponse{}
+	if input.StartTime == "" && input.EndTime == "" {
+		return errs
+	}
+
+	if input.ExecTime != "" {
+		errs = append(errs, respond.ErrorResponse{
+			Message: "Conflicting time parameters",
+			Code:    "400",
+			Details: "Please use either the exec_time url parameter or the start_time and end_time url parameters",
+		})
+		return errs
+	}
 
-	//Time Related
-	const zuluForm = "2006-01-02T15:04:05Z"
-	const ymdForm = "20060102"
+	return validateSpan(input.StartTime, input.EndTime)
+}
 
-	ts, _ := time.Parse(zuluForm, input.ExecTime)
-	tsYMD, _ := strconv.Atoi(ts.Format(ymdForm))
+// validateLimit checks that the limit of a search is a positive integer up to maxSearchLimit and
+// defaults it to defaultSearchLimit
+func (input *metricResultSearch) validateLimit() []respond.ErrorResponse {
+	errs := []respond.ErrorResponse{}
+	input.limit = defaultSearchLimit
+	if input.Limit == "" {
+		return errs
+	}
 
-	// parse time as integer
-	tsInt := (ts.Hour() * 10000) + (ts.Minute() * 100) + ts.Second()
+	limit, err := strconv.Atoi(input.Limit)
+	if err != nil || limit < 1 || limit > maxSearchLimit {
+		errs = append(errs, respond.ErrorResponse{
+			Message: "Wrong Limit",
+			Code:    "400",
+			Details: fmt.Sprintf("%s is not accepted as limit parameter, please provide a positive integer up to %d", input.Limit, maxSearchLimit),
+		})
+	}
+	input.limit = limit
+	return errs
+}
+
+// validateSpan checks that both start_time and end_time are set and that the end_time is not before the start_time
+func validateSpan(startTime string, endTime string) []respond.ErrorResponse {
+	_, _, errs := respond.ValidateDateRange(startTime, endTime)
+	start, errStart := time.Parse(zuluForm, startTime)
+	end, errEnd := time.Parse(zuluForm, endTime)
+	if len(errs) == 0 && (errStart != nil || errEnd != nil || end.Before(start)) {
+		errs = append(errs, respond.ErrorResponse{
+			Message: "Wrong time span",
+			Code:    "400",
+			Details: "Please use both start_time and end_time url parameters and an end_time after the start_time",
+		})
+	}
+	return errs
+}
+
+func prepQuery(input metricResultQuery) bson.M {
 
 	query := bson.M{
-		"date_integer": tsYMD,
-		"host":         input.EndpointName,
-		"metric":       input.MetricName,
-		"time_integer": tsInt,
+		"host":   input.EndpointName,
+		"metric": input.MetricName,
+	}
+
+	if input.ExecTime != "" {
+		ts, _ := time.Parse(zuluForm, input.ExecTime)
+		tsYMD, _ := strconv.Atoi(ts.Format(ymdForm))
+
+		// parse time as integer
+		tsInt := (ts.Hour() * 10000) + (ts.Minute() * 100) + ts.Second()
+
+		query["date_integer"] = tsYMD
+		query["time_integer"] = tsInt
+	} else if input.StartTime != "" {
+		start, _ := time.Parse(zuluForm, input.StartTime)
+		end, _ := time.Parse(zuluForm, input.EndTime)
+		startYMD, _ := strconv.Atoi(start.Format(ymdForm))
+		endYMD, _ := strconv.Atoi(end.Format(ymdForm))
+
+		// the date range narrows the search down to the daily documents of the span
+		query["date_integer"] = bson.M{"$gte": startYMD, "$lte": endYMD}
+		query["timestamp"] = bson.M{"$gte": input.StartTime, "$lte": input.EndTime}
 	}
 
 	return query
 
 }
+
+// prepSearchQuery builds the aggregation that finds the executions matching the search, keeps a single
+// copy of the executions stored once for every report and returns the first input.limit of them
+// sorted by host, metric and timestamp
+func prepSearchQuery(input metricResultSearch) []bson.M {
+
+	start, _ := time.Parse(zuluForm, input.StartTime)
+	end, _ := time.Parse(zuluForm, input.EndTime)
+	startYMD, _ := strconv.Atoi(start.Format(ymdForm))
+	endYMD, _ := strconv.Atoi(end.Format(ymdForm))
+
+	query := bson.M{
+		"$text":        bson.M{"$search": input.Terms},
+		"date_integer": bson.M{"$gte": startYMD, "$lte": endYMD},
+		"timestamp":    bson.M{"$gte": input.StartTime, "$lte": input.EndTime},
+	}
+
+	if input.EndpointName != "" {
+		query["host"] = input.EndpointName
+	}
+	if input.MetricName != "" {
+		query["metric"] = input.MetricName
+	}
+
+	return []bson.M{
+		{"$match": query},
+		{"$group": bson.M{
+			"_id": bson.M{
+				"host":      "$host",
+				"metric":    "$metric",
+				"timestamp": "$timestamp"},
+			"status":  bson.M{"$first": "$status"},
+			"summary": bson.M{"$first": "$summary"},
+			"message": bson.M{"$first": "$message"}},
+		},
+		{"$project": bson.M{
+			"host":      "$_id.host",
+			"metric":    "$_id.metric",
+			"timestamp": "$_id.timestamp",
+			"status":    1,
+			"summary":   1,
+			"message":   1},
+		},
+		{"$sort": bson.D{
+			{"host", 1},
+			{"metric", 1},
+			{"timestamp", 1}},
+		},
+		{"$limit": input.limit},
+	}
+}
@@ -134,8 +134,12 @@ func (suite *metricResultTestSuite) SetupTest() {
 	// authenticate user's api key and find corresponding tenant
 	suite.tenantDbConf, err = authentication.AuthenticateTenant(request.Header, suite.cfg)
 
-	// seed the status detailed metric data
+	// seed the status detailed metric data along with the text index that is created at deploy time
 	c = session.DB(suite.tenantDbConf.Db).C("status_metrics")
+	c.EnsureIndex(mgo.Index{
+		Key:  []string{"$text:summary", "$text:message"},
+		Name: "status_metrics_text",
+	})
 	c.Insert(bson.M{
 		"monitoring_box":     "nagios3.hellasgrid.gr",
 		"date_integer":       20150501,
@@ -235,6 +239,22 @@ func (suite *metricResultTestSuite) TestReadStatusDetail() {
 	// Compare the expected and actual xml response
 	suite.Equal(respJSON, response.Body.String(), "Response body mismatch")
 
+	respCSV := `host,metric,timestamp,status,summary,message
+cream01.afroditi.gr,emi.cream.CREAMCE-JobSubmit,2015-05-01T01:00:00Z,CRITICAL,Cream status is CRITICAL,Cream job submission test failed
+`
+
+	request, _ = http.NewRequest("GET", "/api/v2/metric_result/cream01.afroditi.gr/emi.cream.CREAMCE-JobSubmit?exec_time=2015-05-01T01:00:00Z", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "text/csv")
+	response = httptest.NewRecorder()
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	// Check the negotiated content type
+	suite.Equal("text/csv; charset=utf-8", response.Header().Get("Content-Type"), "Content type mismatch")
+	// Compare the expected and actual csv response
+	suite.Equal(respCSV, response.Body.String(), "Response body mismatch")
+
 	// Check returned xml when no results are available for a given timestamp
 	request, _ = http.NewRequest("GET", "/api/v2/metric_result/cream01.afroditi.gr/emi.cream.CREAMCE-JobSubmit?exec_time=2015-05-01T01:01:00Z", strings.NewReader(""))
 	request.Header.Set("x-api-key", suite.clientkey)
@@ -248,6 +268,160 @@ func (suite *metricResultTestSuite) TestReadStatusDetail() {
 
 }
 
+// TestReadStatusDetailRange tests that all executions of a metric in a time span are returned
+func (suite *metricResultTestSuite) TestReadStatusDetailRange() {
+
+	respCSV := `host,metric,timestamp,status,summary,message
+cream01.afroditi.gr,emi.cream.CREAMCE-JobSubmit,2015-05-01T00:00:00Z,OK,Cream status is ok,Cream job submission test return value of ok
+cream01.afroditi.gr,emi.cream.CREAMCE-JobSubmit,2015-05-01T01:00:00Z,CRITICAL,Cream status is CRITICAL,Cream job submission test failed
+`
+
+	request, _ := http.NewRequest("GET", "/api/v2/metric_result/cream01.afroditi.gr/emi.cream.CREAMCE-JobSubmit?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T02:00:00Z", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "text/csv")
+	response := httptest.NewRecorder()
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	// Compare the expected and actual csv response
+	suite.Equal(respCSV, response.Body.String(), "Response body mismatch")
+
+	respXML := ` <root>
+   <host name="cream01.afroditi.gr">
+     <metric name="emi.cream.CREAMCE-JobSubmit">
+       <status timestamp="2015-05-01T00:00:00Z" value="OK">
+         <summary>Cream status is ok</summary>
+         <message>Cream job submission test return value of ok</message>
+         <match field="summary" start="0" end="5"></match>
+         <match field="message" start="0" end="5"></match>
+       </status>
+       <status timestamp="2015-05-01T01:00:00Z" value="CRITICAL">
+         <summary>Cream status is CRITICAL</summary>
+         <message>Cream job submission test failed</message>
+         <match field="summary" start="0" end="5"></match>
+         <match field="message" start="0" end="5"></match>
+       </status>
+     </metric>
+   </host>
+ </root>`
+
+	// The limit keeps the first executions by host, metric and timestamp
+	request, _ = http.NewRequest("GET", "/api/v2/metric_result/search?q=cream&start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:59:59Z&limit=2", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/xml")
+	response = httptest.NewRecorder()
+	suite.router.ServeHTTP(response, request)
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	suite.Equal(respXML, response.Body.String(), "Response body mismatch")
+
+	for query, expected := range map[string]string{
+		"?exec_time=2015-05-01T01:00:00Z&start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T02:00:00Z": "Please use either the exec_time url parameter or the start_time and end_time url parameters",
+		"?start_time=2015-05-01T00:00:00Z":                     "Please use both start_time and end_time url parameters and an end_time after the start_time",
+		"?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01": "Error parsing date string 2015-05-01 please use zulu format like 2006-01-02T15:04:05Z",
+	} {
+		request, _ = http.NewRequest("GET", "/api/v2/metric_result/cream01.afroditi.gr/emi.cream.CREAMCE-JobSubmit"+query, strings.NewReader(""))
+		request.Header.Set("x-api-key", suite.clientkey)
+		request.Header.Set("Accept", "application/json")
+		response = httptest.NewRecorder()
+		suite.router.ServeHTTP(response, request)
+		// Check that we must have a 400 bad request code
+		suite.Equal(400, response.Code, "Incorrect HTTP response code")
+		suite.Contains(response.Body.String(), expected, "Response body mismatch")
+	}
+
+}
+
+// TestReadStatusDetailLatest tests that the latest execution of a metric is returned without a time
+func (suite *metricResultTestSuite) TestReadStatusDetailLatest() {
+
+	respXML := ` <root>
+   <host name="cream01.afroditi.gr">
+     <metric name="emi.cream.CREAMCE-JobSubmit">
+       <status timestamp="2015-05-01T05:00:00Z" value="OK">
+         <summary>Cream status is ok</summary>
+         <message>Cream job submission test return value of ok</message>
+       </status>
+     </metric>
+   </host>
+ </root>`
+
+	request, _ := http.NewRequest("GET", "/api/v2/metric_result/cream01.afroditi.gr/emi.cream.CREAMCE-JobSubmit", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/xml")
+	response := httptest.NewRecorder()
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	// Compare the expected and actual xml response
+	suite.Equal(respXML, response.Body.String(), "Response body mismatch")
+
+}
+
+// TestSearchStatusDetail tests that executions are found by the terms of their summary and message
+func (suite *metricResultTestSuite) TestSearchStatusDetail() {
+
+	respCSV := `host,metric,timestamp,status,summary,message,matches
+cream01.afroditi.gr,emi.cream.CREAMCE-JobSubmit,2015-05-01T01:00:00Z,CRITICAL,Cream status is CRITICAL,Cream job submission test failed,message:26-32
+`
+
+	request, _ := http.NewRequest("GET", "/api/v2/metric_result/search?q=failed&start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:59:59Z&host=cream01.afroditi.gr", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "text/csv")
+	response := httptest.NewRecorder()
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	// Compare the expected and actual csv response
+	suite.Equal(respCSV, response.Body.String(), "Response body mismatch")
+
+	respXML := ` <root>
+   <host name="cream01.afroditi.gr">
+     <metric name="emi.cream.CREAMCE-JobSubmit">
+       <status timestamp="2015-05-01T00:00:00Z" value="OK">
+         <summary>Cream status is ok</summary>
+         <message>Cream job submission test return value of ok</message>
+         <match field="summary" start="0" end="5"></match>
+         <match field="message" start="0" end="5"></match>
+       </status>
+       <status timestamp="2015-05-01T01:00:00Z" value="CRITICAL">
+         <summary>Cream status is CRITICAL</summary>
+         <message>Cream job submission test failed</message>
+         <match field="summary" start="0" end="5"></match>
+         <match field="message" start="0" end="5"></match>
+       </status>
+     </metric>
+   </host>
+ </root>`
+
+	// The limit keeps the first executions by host, metric and timestamp
+	request, _ = http.NewRequest("GET", "/api/v2/metric_result/search?q=cream&start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:59:59Z&limit=2", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/xml")
+	response = httptest.NewRecorder()
+	suite.router.ServeHTTP(response, request)
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	suite.Equal(respXML, response.Body.String(), "Response body mismatch")
+
+	for query, expected := range map[string]string{
+		"?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:59:59Z":                     "Please use the q url parameter to set the terms to search for",
+		"?q=-failed&start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:59:59Z":           "Please use the q url parameter to set the terms to search for",
+		"?q=failed&start_time=2015-05-01T00:00:00Z":                                          "Please use both start_time and end_time url parameters and an end_time after the start_time",
+		"?q=failed&start_time=2015-05-02T00:00:00Z&end_time=2015-05-01T23:59:59Z":            "Please use both start_time and end_time url parameters and an end_time after the start_time",
+		"?q=failed&start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:59:59Z&limit=0":    "0 is not accepted as limit parameter, please provide a positive integer up to 1000",
+		"?q=failed&start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:59:59Z&limit=5000": "5000 is not accepted as limit parameter, please provide a positive integer up to 1000",
+	} {
+		request, _ = http.NewRequest("GET", "/api/v2/metric_result/search"+query, strings.NewReader(""))
+		request.Header.Set("x-api-key", suite.clientkey)
+		request.Header.Set("Accept", "application/json")
+		response = httptest.NewRecorder()
+		suite.router.ServeHTTP(response, request)
+		// Check that we must have a 400 bad request code
+		suite.Equal(400, response.Code, "Incorrect HTTP response code")
+		suite.Contains(response.Body.String(), expected, "Response body mismatch")
+	}
+
+}
+
 // This function is actually called in the end of all tests
 // and clears the test environment.
 // Mainly it's purpose is to drop the testdb
@@ -24,11 +24,34 @@ package metricResult
 
 import "encoding/xml"
 
+const zuluForm = "2006-01-02T15:04:05Z"
+const ymdForm = "20060102"
+
+// defaultSearchLimit is the number of executions a search returns unless the limit url param is set
+const defaultSearchLimit = 100
+
+// maxSearchLimit is the largest limit a search accepts
+const maxSearchLimit = 1000
+
 type metricResultQuery struct {
 	EndpointName string `bson:"hostname"`
 	MetricName   string `bson:"metric_name"`
 	Format       string `bson:"-"`
 	ExecTime     string `bson:"exec_time"` // UTC time in W3C format
+	StartTime    string `bson:"start_time"`
+	EndTime      string `bson:"end_time"`
+}
+
+// metricResultSearch holds the url params of a full text search over the metric results
+type metricResultSearch struct {
+	Terms        string
+	EndpointName string
+	MetricName   string
+	Format       string
+	StartTime    string
+	EndTime      string
+	Limit        string
+	limit        int
 }
 
 // metricResultOutput structure holds mongo results
@@ -57,14 +80,25 @@ type MetricXML struct {
 
 // StatusXML struct used as xml block
 type StatusXML struct {
-	XMLName   xml.Name `xml:"status" json:"-"`
-	Timestamp string   `xml:"timestamp,attr"`
-	Value     string   `xml:"value,attr"`
-	Summary   string   `xml:"summary"`
-	Message   string   `xml:"message"`
+	XMLName   xml.Name    `xml:"status" json:"-"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Value     string      `xml:"value,attr"`
+	Summary   string      `xml:"summary"`
+	Message   string      `xml:"message"`
+	Matches   []*MatchXML `json:",omitempty"`
+}
+
+// MatchXML struct used as xml block of a searched term found in the summary or message of an execution,
+// located by the character offsets of its start and end
+type MatchXML struct {
+	XMLName xml.Name `xml:"match" json:"-"`
+	Field   string   `xml:"field,attr"`
+	Start   int      `xml:"start,attr"`
+	End     int      `xml:"end,attr"`
 }
 
 type root struct {
 	XMLName xml.Name      `xml:"root" json:"-"`
 	Result  []interface{} `json:"root"`
+	matches bool
 }
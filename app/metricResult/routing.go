@@ -31,6 +31,11 @@ import (
 // handling each route with a different subrouter
 func HandleSubrouter(s *mux.Router, confhandler *respond.ConfHandler) {
 
+	s.Path("/search").
+		Methods("GET").
+		Name("Metric Result Search").
+		Handler(confhandler.Respond(SearchMetricResults))
+
 	s.Path("/{endpoint_name}/{metric_name}").
 		Methods("GET").
 		Name("Metric Result").
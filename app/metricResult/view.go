@@ -26,42 +26,173 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"regexp"
 	"strings"
+	"unicode/utf8"
+
+	"github.com/ARGOeu/argo-web-api/respond"
 )
 
-func createMetricResultView(result metricResultOutput, format string) ([]byte, error) {
+// createMetricResultView renders the executions of a metric on a host sorted by timestamp. Executions
+// stored once for every report show up once
+func createMetricResultView(results []metricResultOutput, format string) ([]byte, error) {
 
 	docRoot := &root{}
 
 	// Exit here in case result is empty
-	if result.Hostname == "" {
+	if len(results) == 0 && strings.ToLower(format) == "text/csv" {
+		return respond.MarshalCSV(docRoot)
+	} else if len(results) == 0 {
 		output, err := xml.MarshalIndent(docRoot, "", "")
 		return output, err
 	}
 
 	hostname := &HostXML{
-		Name: result.Hostname,
+		Name: results[0].Hostname,
 	}
 	docRoot.Result = append(docRoot.Result, hostname)
 
 	metric := &MetricXML{
-		Name: result.Metric,
+		Name: results[0].Metric,
 	}
 	hostname.Metrics = append(hostname.Metrics, metric)
 
 	// we append the detailed results
-	metric.Details = append(metric.Details,
-		&StatusXML{
-			Timestamp: fmt.Sprintf("%s", result.Timestamp),
-			Value:     fmt.Sprintf("%s", result.Status),
-			Summary:   fmt.Sprintf("%s", result.Summary),
-			Message:   fmt.Sprintf("%s", result.Message),
-		})
+	for i, result := range results {
+		if i > 0 && result.Timestamp == results[i-1].Timestamp {
+			continue
+		}
+		metric.Details = append(metric.Details,
+			&StatusXML{
+				Timestamp: fmt.Sprintf("%s", result.Timestamp),
+				Value:     fmt.Sprintf("%s", result.Status),
+				Summary:   fmt.Sprintf("%s", result.Summary),
+				Message:   fmt.Sprintf("%s", result.Message),
+			})
+	}
 
 	if strings.ToLower(format) == "application/json" {
 		return json.MarshalIndent(docRoot, " ", "  ")
+	} else if strings.ToLower(format) == "text/csv" {
+		return respond.MarshalCSV(docRoot)
 	} else {
 		return xml.MarshalIndent(docRoot, " ", "  ")
 	}
 
 }
+
+// createSearchView renders the executions found by a search grouped by host and metric, each one with the
+// places of its summary and message where the searched terms appear
+func createSearchView(results []metricResultOutput, terms []string, format string) ([]byte, error) {
+
+	docRoot := &root{matches: true}
+	pattern := matchPattern(terms)
+
+	var hostname *HostXML
+	var metric *MetricXML
+	for _, result := range results {
+		if hostname == nil || hostname.Name != result.Hostname {
+			hostname = &HostXML{Name: result.Hostname}
+			docRoot.Result = append(docRoot.Result, hostname)
+			metric = nil
+		}
+		if metric == nil || metric.Name != result.Metric {
+			metric = &MetricXML{Name: result.Metric}
+			hostname.Metrics = append(hostname.Metrics, metric)
+		}
+
+		status := &StatusXML{
+			Timestamp: result.Timestamp,
+			Value:     result.Status,
+			Summary:   result.Summary,
+			Message:   result.Message,
+		}
+		for _, field := range []struct{ name, text string }{{"summary", result.Summary}, {"message", result.Message}} {
+			status.Matches = append(status.Matches, findMatches(field.name, field.text, pattern)...)
+		}
+		metric.Details = append(metric.Details, status)
+	}
+
+	if strings.ToLower(format) == "application/json" {
+		return json.MarshalIndent(docRoot, " ", "  ")
+	} else if strings.ToLower(format) == "text/csv" {
+		return respond.MarshalCSV(docRoot)
+	}
+	return xml.MarshalIndent(docRoot, " ", "  ")
+}
+
+// searchTerms splits a text search into the words and quoted phrases it looks for. Negated words and
+// phrases are left out since they never appear in the results
+func searchTerms(search string) []string {
+	terms := []string{}
+	parts := strings.Split(search, "\"")
+	for i, part := range parts {
+		if i%2 == 1 {
+			// quoted phrases sit at odd positions and are negated by a dash right before the quote
+			negated := strings.HasSuffix(strings.TrimSpace(parts[i-1]), "-")
+			if phrase := strings.TrimSpace(part); phrase != "" && !negated {
+				terms = append(terms, phrase)
+			}
+			continue
+		}
+		for _, word := range strings.Fields(part) {
+			if !strings.HasPrefix(word, "-") {
+				terms = append(terms, word)
+			}
+		}
+	}
+	return terms
+}
+
+// matchPattern matches any of the searched terms regardless of case
+func matchPattern(terms []string) *regexp.Regexp {
+	quoted := []string{}
+	for _, term := range terms {
+		quoted = append(quoted, regexp.QuoteMeta(term))
+	}
+	return regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+}
+
+// findMatches locates the searched terms in a field of an execution. The offsets count characters
+// rather than bytes, so that they point at the same text whatever the encoding of the client
+func findMatches(field string, text string, pattern *regexp.Regexp) []*MatchXML {
+	matches := []*MatchXML{}
+	for _, match := range pattern.FindAllStringIndex(text, -1) {
+		if match[0] == match[1] {
+			continue
+		}
+		start := utf8.RuneCountInString(text[:match[0]])
+		matches = append(matches, &MatchXML{
+			Field: field,
+			Start: start,
+			End:   start + utf8.RuneCountInString(text[match[0]:match[1]]),
+		})
+	}
+	return matches
+}
+
+// CSVRecords flattens the detailed results into one record per status of every metric, along with the
+// matches of searches
+func (docRoot *root) CSVRecords() [][]string {
+	records := [][]string{{"host", "metric", "timestamp", "status", "summary", "message"}}
+	if docRoot.matches {
+		records[0] = append(records[0], "matches")
+	}
+	for _, item := range docRoot.Result {
+		host := item.(*HostXML)
+		for _, metric := range host.Metrics {
+			for _, status := range metric.Details {
+				record := []string{host.Name, metric.Name, status.Timestamp, status.Value, status.Summary, status.Message}
+				if docRoot.matches {
+					matches := []string{}
+					for _, match := range status.Matches {
+						matches = append(matches, fmt.Sprintf("%s:%d-%d", match.Field, match.Start, match.End))
+					}
+					record = append(record, strings.Join(matches, " "))
+				}
+				records = append(records, record)
+			}
+		}
+	}
+	return records
+}
@@ -26,7 +26,17 @@
 
 package operationsProfiles
 
-import "sort"
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2/bson"
+	"github.com/ARGOeu/argo-web-api/app/reports"
+	"github.com/ARGOeu/argo-web-api/respond"
+	"github.com/ARGOeu/argo-web-api/utils/mongo"
+)
 
 // OpsProfile to retrieve and insert operationsProfiles in mongo
 type OpsProfile struct {
@@ -68,7 +78,8 @@ type Links struct {
 	Self string `json:"self"`
 }
 
-func (oprof *OpsProfile) hasState(state string) bool {
+// HasState checks if a state is declared in the available states of the profile
+func (oprof *OpsProfile) HasState(state string) bool {
 	for _, item := range oprof.AvailStates {
 		if item == state {
 			return true
@@ -82,25 +93,25 @@ func (oprof *OpsProfile) validateStates() []string {
 	// check default states
 	var errList []string
 
-	if !(oprof.hasState(oprof.Defaults.Down)) {
+	if !(oprof.HasState(oprof.Defaults.Down)) {
 		errList = append(errList, "Default Down State: "+oprof.Defaults.Down+" not in available States")
 	}
-	if !(oprof.hasState(oprof.Defaults.Missing)) {
+	if !(oprof.HasState(oprof.Defaults.Missing)) {
 		errList = append(errList, "Default Missing State: "+oprof.Defaults.Missing+" not in available States")
 	}
-	if !(oprof.hasState(oprof.Defaults.Unknown)) {
+	if !(oprof.HasState(oprof.Defaults.Unknown)) {
 		errList = append(errList, "Default Unknown State: "+oprof.Defaults.Unknown+" not in available States")
 	}
 	// check operations
 	for _, op := range oprof.Operations {
 		for _, st := range op.TruthTable {
-			if !(oprof.hasState(st.A)) {
+			if !(oprof.HasState(st.A)) {
 				errList = append(errList, "In Operation: "+op.Name+", statement member a: "+st.A+" contains undeclared state")
 			}
-			if !(oprof.hasState(st.B)) {
+			if !(oprof.HasState(st.B)) {
 				errList = append(errList, "In Operation: "+op.Name+", statement member b: "+st.B+" contains undeclared state")
 			}
-			if !(oprof.hasState(st.X)) {
+			if !(oprof.HasState(st.X)) {
 				errList = append(errList, "In Operation: "+op.Name+", statement member x: "+st.X+" contains undeclared state")
 			}
 		}
@@ -126,10 +137,10 @@ func (oprof *OpsProfile) validateMentions() []string {
 
 		// for all statements in truth table
 		for _, st := range op.TruthTable {
-			if oprof.hasState(st.A) {
+			if oprof.HasState(st.A) {
 				counters[st.A]++
 			}
-			if oprof.hasState(st.B) {
+			if oprof.HasState(st.B) {
 				counters[st.B]++
 			}
 
@@ -178,3 +189,161 @@ func (oprof *OpsProfile) validateDuplicates() []string {
 
 	return errList
 }
+
+// Operate applies an operation of the profile on two states by looking up its truth table
+func (oprof *OpsProfile) Operate(operation string, a string, b string) (string, error) {
+	for _, op := range oprof.Operations {
+		if op.Name != operation {
+			continue
+		}
+		for _, st := range op.TruthTable {
+			if (st.A == a && st.B == b) || (st.A == b && st.B == a) {
+				return st.X, nil
+			}
+		}
+		return "", fmt.Errorf("The operation %s of the operations profile %s does not define the result of %s and %s", operation, oprof.Name, a, b)
+	}
+	return "", fmt.Errorf("The operations profile %s does not define the operation %s", oprof.Name, operation)
+}
+
+// StateOrder returns the available states of the profile in the order they are declared along with its missing
+// state and the severity of every state as ranked by Severities
+func (oprof *OpsProfile) StateOrder() (respond.StateOrder, error) {
+	severities, err := oprof.Severities()
+	if err != nil {
+		return respond.StateOrder{}, err
+	}
+	return respond.StateOrder{States: oprof.AvailStates, Missing: oprof.Defaults.Missing, Severity: severities}, nil
+}
+
+// Severities ranks the available states of the profile from the least to the most severe. The AND operation
+// combines two states into the more severe of them, so a state ranks above every state it prevails over
+func (oprof *OpsProfile) Severities() (map[string]int, error) {
+	severities := map[string]int{}
+	for _, a := range oprof.AvailStates {
+		severities[a] = 0
+		for _, b := range oprof.AvailStates {
+			if a == b {
+				continue
+			}
+			x, err := oprof.Operate("AND", a, b)
+			if err != nil {
+				return nil, err
+			}
+			if x == a {
+				severities[a]++
+			}
+		}
+	}
+	return severities, nil
+}
+
+// OutageStates returns the states of the profile that count as an outage: its down state along with the states
+// ranked as failures, the ones more severe than both its unknown and its missing state
+func (oprof *OpsProfile) OutageStates() (map[string]bool, error) {
+	severities, err := oprof.Severities()
+	if err != nil {
+		return nil, err
+	}
+
+	outageStates := map[string]bool{oprof.Defaults.Down: true}
+	unknown, missing := severities[oprof.Defaults.Unknown], severities[oprof.Defaults.Missing]
+	for _, state := range oprof.AvailStates {
+		if severities[state] > unknown && severities[state] > missing {
+			outageStates[state] = true
+		}
+	}
+	return outageStates, nil
+}
+
+// GetReportProfile retrieves the operations profile used by the report with the given name
+func GetReportProfile(session *mgo.Session, db string, reportName string) (OpsProfile, error) {
+	profile := OpsProfile{}
+	report := reports.MongoInterface{}
+	err := mongo.FindOne(session, db, "reports", bson.M{"info.name": reportName}, &report)
+	if err != nil {
+		return profile, err
+	}
+
+	name, err := reports.GetOperationsProfile(report)
+	if err != nil {
+		return profile, err
+	}
+
+	err = mongo.FindOne(session, db, "operations_profiles", bson.M{"name": name}, &profile)
+	return profile, err
+}
+
+// ValidateStateFilter checks that the states requested by a state filter are declared in the
+// operations profile of the report with the given name. The profile is only read when the filter is set
+func ValidateStateFilter(session *mgo.Session, db string, reportName string, filter respond.StateFilter) []respond.ErrorResponse {
+	if !filter.IsSet() {
+		return nil
+	}
+
+	profile, err := GetReportProfile(session, db, reportName)
+	if err != nil {
+		return []respond.ErrorResponse{{
+			Message: "Operations profile not found",
+			Code:    "400",
+			Details: fmt.Sprintf("The report %s does not define an existing operations profile", reportName),
+		}}
+	}
+	return filter.Validate(profile.HasState, profile.Name)
+}
+
+// GetGapFilling returns the missing state that fills the gaps of the status timelines of the report with the
+// given name along with the threshold above which a gap is filled. A threshold given with the request takes
+// precedence over the gap threshold of the report
+func GetGapFilling(session *mgo.Session, db string, reportName string, threshold time.Duration) (string, time.Duration, []respond.ErrorResponse) {
+	errs := []respond.ErrorResponse{}
+
+	profile, err := GetReportProfile(session, db, reportName)
+	if err != nil {
+		errs = append(errs, respond.ErrorResponse{
+			Message: "Operations profile not found",
+			Code:    "400",
+			Details: fmt.Sprintf("The report %s does not define an existing operations profile", reportName),
+		})
+	}
+
+	if threshold == 0 {
+		report := reports.MongoInterface{}
+		err = mongo.FindOne(session, db, "reports", bson.M{"info.name": reportName}, &report)
+		if err == nil {
+			threshold, err = report.GetGapThreshold()
+		}
+		if err != nil || threshold == 0 {
+			errs = append(errs, respond.ErrorResponse{
+				Message: "No gap threshold set",
+				Code:    "400",
+				Details: fmt.Sprintf("Please use the gap_threshold url parameter or set a valid gap_threshold in the report %s", reportName),
+			})
+		}
+	}
+
+	return profile.Defaults.Missing, threshold, errs
+}
+
+// GetStateOrder returns the states of the operations profile of the report with the given name ranked by severity.
+// The profile ranks its states with its AND operation, so the operation must combine every pair of them
+func GetStateOrder(session *mgo.Session, db string, reportName string) (respond.StateOrder, []respond.ErrorResponse) {
+	profile, err := GetReportProfile(session, db, reportName)
+	if err != nil {
+		return respond.StateOrder{}, []respond.ErrorResponse{{
+			Message: "Operations profile not found",
+			Code:    "400",
+			Details: fmt.Sprintf("The report %s does not define an existing operations profile", reportName),
+		}}
+	}
+
+	order, err := profile.StateOrder()
+	if err != nil {
+		return order, []respond.ErrorResponse{{
+			Message: "Operations profile can not rank its states",
+			Code:    "400",
+			Details: err.Error(),
+		}}
+	}
+	return order, nil
+}
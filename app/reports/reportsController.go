@@ -105,8 +105,10 @@ func Create(r *http.Request, cfg config.Config) (int, http.Header, []byte, error
 		return code, h, output, err
 	}
 
-	// Validate profiles given in report
+	// Validate profiles, SLA targets and gap threshold given in report
 	validationErrors := input.ValidateProfiles(session.DB(tenantDbConfig.Db))
+	validationErrors = append(validationErrors, input.ValidateTargets()...)
+	validationErrors = append(validationErrors, input.ValidateGapThreshold()...)
 
 	if len(validationErrors) > 0 {
 		code = 422
@@ -375,6 +377,8 @@ func Update(r *http.Request, cfg config.Config) (int, http.Header, []byte, error
 			"profiles":        input.Profiles,
 			"filter_tags":     input.Tags,
 			"topology_schema": input.Topology,
+			"sla_targets":     input.Targets,
+			"gap_threshold":   input.GapThreshold,
 		}}
 
 	// Try to open the mongo session
@@ -386,8 +390,10 @@ func Update(r *http.Request, cfg config.Config) (int, http.Header, []byte, error
 		return code, h, output, err
 	}
 
-	// Validate profiles given in report
+	// Validate profiles, SLA targets and gap threshold given in report
 	validationErrors := input.ValidateProfiles(session.DB(tenantDbConfig.Db))
+	validationErrors = append(validationErrors, input.ValidateTargets()...)
+	validationErrors = append(validationErrors, input.ValidateGapThreshold()...)
 
 	if len(validationErrors) > 0 {
 		code = 422
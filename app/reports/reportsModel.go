@@ -30,6 +30,7 @@ import (
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/ARGOeu/argo-web-api/respond"
 
@@ -39,11 +40,13 @@ import (
 
 // MongoInterface is used as an interface to Marshal and Unmarshal from different formats
 type MongoInterface struct {
-	ID       string    `bson:"id,omitempty" json:"id,omitempty" xml:"id,omitempty"`
-	Info     Info      `bson:"info" json:"info" xml:"info"`
-	Topology Topology  `bson:"topology_schema" json:"topology_schema" xml:"topology_schema"`
-	Profiles []Profile `bson:"profiles" json:"profiles" xml:"profiles"`
-	Tags     []Tag     `bson:"filter_tags" json:"filter_tags" xml:"filter_tags"`
+	ID           string    `bson:"id,omitempty" json:"id,omitempty" xml:"id,omitempty"`
+	Info         Info      `bson:"info" json:"info" xml:"info"`
+	Topology     Topology  `bson:"topology_schema" json:"topology_schema" xml:"topology_schema"`
+	Profiles     []Profile `bson:"profiles" json:"profiles" xml:"profiles"`
+	Tags         []Tag     `bson:"filter_tags" json:"filter_tags" xml:"filter_tags"`
+	Targets      []Target  `bson:"sla_targets,omitempty" json:"sla_targets,omitempty" xml:"sla_targets>target,omitempty"`
+	GapThreshold string    `bson:"gap_threshold,omitempty" json:"gap_threshold,omitempty" xml:"gap_threshold,omitempty"`
 }
 
 // Info conatins info about a report and is used inside the main MongoInterface struct
@@ -81,6 +84,15 @@ type Tag struct {
 	Value   string   `bson:"value"      json:"value" xml:"value,attr"`
 }
 
+// Target holds the availability and reliability thresholds (SLA) that a group type or a specific
+// group of the report has to meet
+type Target struct {
+	GroupType    string  `bson:"type"            json:"type"            xml:"type,attr"`
+	Group        string  `bson:"group,omitempty" json:"group,omitempty" xml:"group,attr,omitempty"`
+	Availability float64 `bson:"availability"    json:"availability"    xml:"availability,attr"`
+	Reliability  float64 `bson:"reliability"     json:"reliability"     xml:"reliability,attr"`
+}
+
 // Message struct for xml message response
 type Message struct {
 	XMLName xml.Name `xml:"root"`
@@ -128,6 +140,76 @@ func (report MongoInterface) DetermineGroupType(groupType string) string {
 	return ""
 }
 
+// GetTarget retrieves the SLA target of a group. A target defined for the specific group takes
+// precedence over the one defined for its whole group type
+func (report MongoInterface) GetTarget(groupType string, group string) (Target, bool) {
+	target, found := Target{}, false
+	for _, element := range report.Targets {
+		if element.GroupType != groupType {
+			continue
+		}
+		if element.Group == group {
+			return element, true
+		}
+		if element.Group == "" {
+			target, found = element, true
+		}
+	}
+	return target, found
+}
+
+// ValidateTargets ensures that the SLA targets in a report refer to group types of its topology
+// (or to services and endpoints) and that their thresholds are percentages
+func (report MongoInterface) ValidateTargets() []respond.ErrorResponse {
+	errs := []respond.ErrorResponse{}
+	for _, element := range report.Targets {
+		if element.GroupType != "service" && element.GroupType != "endpoint" &&
+			(report.Topology.Group == nil || report.DetermineGroupType(element.GroupType) == "") {
+			errs = append(errs,
+				respond.ErrorResponse{
+					Message: "Target group type not in report",
+					Code:    "422",
+					Details: fmt.Sprintf("Group type %s of the SLA target is not present in the topology of the report", element.GroupType),
+				})
+		}
+		if element.Availability < 0 || element.Availability > 100 || element.Reliability < 0 || element.Reliability > 100 {
+			errs = append(errs,
+				respond.ErrorResponse{
+					Message: "Invalid target",
+					Code:    "422",
+					Details: fmt.Sprintf("The availability and reliability targets of %s must be between 0 and 100", element.GroupType),
+				})
+		}
+	}
+	return errs
+}
+
+// GetGapThreshold parses the gap threshold of the report. A report without a gap threshold returns zero
+func (report MongoInterface) GetGapThreshold() (time.Duration, error) {
+	if report.GapThreshold == "" {
+		return 0, nil
+	}
+	threshold, err := time.ParseDuration(report.GapThreshold)
+	if err == nil && threshold <= 0 {
+		err = fmt.Errorf("The gap threshold %s is not positive", report.GapThreshold)
+	}
+	return threshold, err
+}
+
+// ValidateGapThreshold ensures that the gap threshold of a report, when set, is a positive duration
+func (report MongoInterface) ValidateGapThreshold() []respond.ErrorResponse {
+	errs := []respond.ErrorResponse{}
+	if _, err := report.GetGapThreshold(); err != nil {
+		errs = append(errs,
+			respond.ErrorResponse{
+				Message: "Invalid gap threshold",
+				Code:    "422",
+				Details: fmt.Sprintf("The gap threshold %s must be a positive duration such as 30m or 2h", report.GapThreshold),
+			})
+	}
+	return errs
+}
+
 var validators = map[string]string{
 	"metric":      "metric_profiles",
 	"aggregation": "aggregation_profiles",
@@ -169,6 +251,28 @@ func GetMetricProfile(input MongoInterface) (string, error) {
 	return "", errors.New("Unable to find metric profile with specified name")
 }
 
+// GetOperationsProfile is a function that takes a report struc element
+// and returns the name of the operations profile (if exists)
+func GetOperationsProfile(input MongoInterface) (string, error) {
+	for _, element := range input.Profiles {
+		if element.Type == "operations" {
+			return element.Name, nil
+		}
+	}
+	return "", errors.New("Unable to find operations profile with specified name")
+}
+
+// GetAggregationProfile is a function that takes a report struc element
+// and returns the name of the aggregation profile (if exists)
+func GetAggregationProfile(input MongoInterface) (string, error) {
+	for _, element := range input.Profiles {
+		if element.Type == "aggregation" {
+			return element.Name, nil
+		}
+	}
+	return "", errors.New("Unable to find aggregation profile with specified name")
+}
+
 // searchName is used to create a simple query object based on name
 func searchName(name string) bson.M {
 	query := bson.M{
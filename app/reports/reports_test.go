@@ -661,7 +661,8 @@ func (suite *ReportTestSuite) TestUpdateReport() {
             "name": "monitored",
             "value": "N"
         }
-    ]
+    ],
+    "gap_threshold": "2h"
 }`
 	// Prepare the request object
 	request, _ := http.NewRequest("PUT", "/api/v2/reports/eba61a9e-22e9-4521-9e47-ecaa4a494364", strings.NewReader(postData))
@@ -679,7 +680,7 @@ func (suite *ReportTestSuite) TestUpdateReport() {
 	suite.Equal(200, code, "Incorrect Error Code")
 	suite.Equal(suite.respReportUpdated, output, "Response body mismatch")
 
-	// Double check that you read the newly inserted profile
+	// Double check that you read the newly inserted profile, including its gap threshold
 	// Create a string literal of the expected xml Response
 	respondJSON := `{
  "status": {
@@ -729,7 +730,8 @@ func (suite *ReportTestSuite) TestUpdateReport() {
      "name": "monitored",
      "value": "N"
     }
-   \]
+   \],
+   "gap_threshold": "2h"
   }
  \]
 }`
@@ -839,6 +841,139 @@ func (suite *ReportTestSuite) TestWrongUUIDUpdateReport() {
 	suite.Equal(respReportWrongProfileUUIDJSON, output, "Response body mismatch")
 }
 
+// TestWrongTargetUpdateReport function implements testing the validation of the
+// SLA targets of a report during the http PUT update report request
+func (suite *ReportTestSuite) TestWrongTargetUpdateReport() {
+
+	// create json input data for the request
+	postData := `{
+    "info": {
+        "name": "newname",
+        "description": "newdescription"
+    },
+    "topology_schema": {
+        "group": {
+            "type": "ngi",
+            "group": {
+                "type": "fight"
+            }
+        }
+    },
+    "profiles": [
+        {
+            "id": "6ac7d684-1f8e-4a02-a502-720e8f11e50b",
+            "type": "metric",
+            "name": "profile1"
+        }
+    ],
+    "filter_tags": [],
+    "sla_targets": [
+        {
+            "type": "country",
+            "availability": 80,
+            "reliability": 85
+        },
+        {
+            "type": "fight",
+            "group": "fight01",
+            "availability": 120,
+            "reliability": 85
+        }
+    ]
+}`
+	// Prepare the request object
+	request, _ := http.NewRequest("PUT", "/api/v2/reports/eba61a9e-22e9-4521-9e47-ecaa4a494364", strings.NewReader(postData))
+	// add the content-type header to application/json
+	request.Header.Set("Accept", "application/json;")
+	// add the authentication token which is seeded in testdb
+	request.Header.Set("x-api-key", "C4PK3Y")
+	response := httptest.NewRecorder()
+	suite.router.ServeHTTP(response, request)
+
+	// Execute the request in the controller
+	code := response.Code
+	output := response.Body.String()
+	respReportWrongTargetJSON := `{
+ "status": {
+  "message": "Unprocessable Entity",
+  "code": "422"
+ },
+ "errors": [
+  {
+   "message": "Target group type not in report",
+   "code": "422",
+   "details": "Group type country of the SLA target is not present in the topology of the report"
+  },
+  {
+   "message": "Invalid target",
+   "code": "422",
+   "details": "The availability and reliability targets of fight must be between 0 and 100"
+  }
+ ]
+}`
+
+	suite.Equal(422, code, "Incorrect Error Code")
+	suite.Equal(respReportWrongTargetJSON, output, "Response body mismatch")
+}
+
+// TestWrongGapThresholdUpdateReport function implements testing the validation of the
+// gap threshold of a report during the http PUT update report request
+func (suite *ReportTestSuite) TestWrongGapThresholdUpdateReport() {
+
+	// create json input data for the request
+	postData := `{
+    "info": {
+        "name": "newname",
+        "description": "newdescription"
+    },
+    "topology_schema": {
+        "group": {
+            "type": "ngi",
+            "group": {
+                "type": "fight"
+            }
+        }
+    },
+    "profiles": [
+        {
+            "id": "6ac7d684-1f8e-4a02-a502-720e8f11e50b",
+            "type": "metric",
+            "name": "profile1"
+        }
+    ],
+    "filter_tags": [],
+    "gap_threshold": "two hours"
+}`
+	// Prepare the request object
+	request, _ := http.NewRequest("PUT", "/api/v2/reports/eba61a9e-22e9-4521-9e47-ecaa4a494364", strings.NewReader(postData))
+	// add the content-type header to application/json
+	request.Header.Set("Accept", "application/json;")
+	// add the authentication token which is seeded in testdb
+	request.Header.Set("x-api-key", "C4PK3Y")
+	response := httptest.NewRecorder()
+	suite.router.ServeHTTP(response, request)
+
+	// Execute the request in the controller
+	code := response.Code
+	output := response.Body.String()
+	respReportWrongGapThresholdJSON := `{
+ "status": {
+  "message": "Unprocessable Entity",
+  "code": "422"
+ },
+ "errors": [
+  {
+   "message": "Invalid gap threshold",
+   "code": "422",
+   "details": "The gap threshold two hours must be a positive duration such as 30m or 2h"
+  }
+ ]
+}`
+
+	suite.Equal(422, code, "Incorrect Error Code")
+	suite.Equal(respReportWrongGapThresholdJSON, output, "Response body mismatch")
+}
+
 // TestDeleteReport function implements testing the http DELETE report request.
 // Request requires admin authentication and gets as input the name of the
 // report to be deleted. After the operation succeeds is double-checked
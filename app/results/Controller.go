@@ -23,11 +23,17 @@
 package results
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"strconv"
 
 	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/github.com/gorilla/mux"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2"
 	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2/bson"
+	"github.com/ARGOeu/argo-web-api/app/operationsProfiles"
 	"github.com/ARGOeu/argo-web-api/app/reports"
 	"github.com/ARGOeu/argo-web-api/respond"
 	"github.com/ARGOeu/argo-web-api/utils/authentication"
@@ -46,7 +52,7 @@ func ListServiceFlavorResults(r *http.Request, cfg config.Config) (int, http.Hea
 	charset := "utf-8"
 	//STANDARD DECLARATIONS END
 
-	contentType, err = respond.ParseAcceptHeader(r)
+	contentType, err = respond.ParseAcceptHeader(r, respond.TimelineContentTypes...)
 	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
 
 	if err != nil {
@@ -92,13 +98,16 @@ func ListServiceFlavorResults(r *http.Request, cfg config.Config) (int, http.Hea
 
 	input := serviceFlavorResultQuery{
 		basicQuery: basicQuery{
-			Name:        vars["service_type"],
-			Granularity: urlValues.Get("granularity"),
-			Format:      contentType,
-			StartTime:   urlValues.Get("start_time"),
-			EndTime:     urlValues.Get("end_time"),
-			Report:      report,
-			Vars:        vars,
+			Name:             vars["service_type"],
+			Granularity:      urlValues.Get("granularity"),
+			Period:           urlValues.Get("period"),
+			Format:           contentType,
+			StartTime:        urlValues.Get("start_time"),
+			EndTime:          urlValues.Get("end_time"),
+			CompareStartTime: urlValues.Get("compare_start_time"),
+			CompareEndTime:   urlValues.Get("compare_end_time"),
+			Report:           report,
+			Vars:             vars,
 		},
 		EndpointGroup: vars["lgroup_name"],
 	}
@@ -142,17 +151,37 @@ func ListServiceFlavorResults(r *http.Request, cfg config.Config) (int, http.Hea
 		filter["supergroup"] = input.EndpointGroup
 	}
 
+	// Compare the results of the two time spans instead of listing them
+	if input.comparing() {
+		query := PeriodicServiceFlavor(compareFilter(filter, input.basicQuery), compareBucket(input.basicQuery))
+		err = mongo.Pipe(session, tenantDbConfig.Db, "service_ar", query, &results)
+		if err != nil {
+			code = http.StatusInternalServerError
+			return code, h, output, err
+		}
+
+		rows := []comparisonRow{}
+		for _, row := range results {
+			rows = append(rows, comparisonRow{row.Name, row.SuperGroup, row.Date, row.Availability, row.Reliability})
+		}
+
+		output, err = createComparisonView(rows, "service", input.basicQuery, input.resultFormat())
+		if err != nil {
+			code = http.StatusInternalServerError
+		}
+		return code, h, output, err
+	}
+
 	// Select the granularity of the search daily/monthly
 	if input.Granularity == "daily" {
-		customForm[0] = "20060102"
-		customForm[1] = "2006-01-02"
 		query := DailyServiceFlavor(filter)
 		err = mongo.Pipe(session, tenantDbConfig.Db, "service_ar", query, &results)
 	} else if input.Granularity == "monthly" {
-		customForm[0] = "200601"
-		customForm[1] = "2006-01"
 		query := MonthlyServiceFlavor(filter)
 		err = mongo.Pipe(session, tenantDbConfig.Db, "service_ar", query, &results)
+	} else {
+		query := PeriodicServiceFlavor(filter, periodBucket(input.basicQuery))
+		err = mongo.Pipe(session, tenantDbConfig.Db, "service_ar", query, &results)
 	}
 
 	// mongo.Find(session, tenantDbConfig.Db, "endpoint_group_ar", bson.M{}, "_id", &results)
@@ -161,7 +190,7 @@ func ListServiceFlavorResults(r *http.Request, cfg config.Config) (int, http.Hea
 		return code, h, output, err
 	}
 
-	output, err = createServiceFlavorResultView(results, report, input.Format)
+	output, err = createServiceFlavorResultView(results, report, input.resultFormat())
 
 	if err != nil {
 		code = http.StatusInternalServerError
@@ -172,9 +201,139 @@ func ListServiceFlavorResults(r *http.Request, cfg config.Config) (int, http.Hea
 
 }
 
-// ListEndpointGroupResults endpoint group availabilities according to the http request
-func ListEndpointGroupResults(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
+// ListServiceTypeResults is responsible for handling request to list the results of a service type
+// aggregated across all the endpoint groups of a report
+func ListServiceTypeResults(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
+	//STANDARD DECLARATIONS START
+	code := http.StatusOK
+	h := http.Header{}
+	output := []byte("")
+	err := error(nil)
+	contentType := "application/xml"
+	charset := "utf-8"
+	//STANDARD DECLARATIONS END
+
+	contentType, err = respond.ParseAcceptHeader(r, respond.TimelineContentTypes...)
+	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
+
+	if err != nil {
+		code = http.StatusNotAcceptable
+		output, _ = respond.MarshalContent(respond.NotAcceptableContentType, contentType, "", " ")
+		return code, h, output, err
+	}
+
+	// Parse the request into the input
+	urlValues := r.URL.Query()
+	vars := mux.Vars(r)
+
+	tenantDbConfig, err := authentication.AuthenticateTenant(r.Header, cfg)
+	if err != nil {
+		if err.Error() == "Unauthorized" {
+			code = http.StatusUnauthorized
+			out := respond.UnauthorizedMessage
+			output = out.MarshalTo(contentType)
+			return code, h, output, err
+		}
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	session, err := mongo.OpenSession(tenantDbConfig)
+	defer mongo.CloseSession(session)
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	report := reports.MongoInterface{}
+	err = mongo.FindOne(session, tenantDbConfig.Db, "reports", bson.M{"info.name": vars["report_name"]}, &report)
+
+	if err != nil {
+		code = http.StatusBadRequest
+		message := "The report with the name " + vars["report_name"] + " does not exist"
+		output, err := createErrorMessage(message, contentType) //Render the response into XML or JSON
+		h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
+		return code, h, output, err
+	}
+
+	input := serviceTypeResultQuery{
+		basicQuery: basicQuery{
+			Name:        vars["service_type"],
+			Granularity: urlValues.Get("granularity"),
+			Period:      urlValues.Get("period"),
+			Format:      contentType,
+			StartTime:   urlValues.Get("start_time"),
+			EndTime:     urlValues.Get("end_time"),
+			Report:      report,
+			Vars:        vars,
+		},
+		Breakdown: urlValues.Get("breakdown"),
+	}
+
+	tenantDB := session.DB(tenantDbConfig.Db)
+	errs := input.Validate(tenantDB)
+	if len(errs) > 0 {
+		out := respond.BadRequestSimple
+		out.Errors = errs
+		output = out.MarshalTo(contentType)
+		code = 400
+		return code, h, output, err
+	}
+
+	results := []ServiceFlavorInterface{}
+	breakdown := []ServiceFlavorInterface{}
+
+	// Construct the query to mongodb based on the input
+	filter := bson.M{
+		"date":   bson.M{"$gte": input.StartTimeInt, "$lte": input.EndTimeInt},
+		"report": report.ID,
+		"name":   input.Name,
+	}
+
+	// Select the granularity of the search daily/monthly
+	var breakdownQuery []bson.M
+	if input.Granularity == "daily" {
+		query := AggregateServiceFlavor(filter, bson.D{{"$substr", list{"$date", 0, 8}}})
+		err = mongo.Pipe(session, tenantDbConfig.Db, "service_ar", query, &results)
+		breakdownQuery = DailyServiceFlavor(filter)
+	} else if input.Granularity == "monthly" {
+		query := AggregateServiceFlavor(filter, bson.D{{"$substr", list{"$date", 0, 6}}})
+		err = mongo.Pipe(session, tenantDbConfig.Db, "service_ar", query, &results)
+		breakdownQuery = MonthlyServiceFlavor(filter)
+	} else {
+		query := AggregateServiceFlavor(filter, periodBucket(input.basicQuery))
+		err = mongo.Pipe(session, tenantDbConfig.Db, "service_ar", query, &results)
+		breakdownQuery = PeriodicServiceFlavor(filter, periodBucket(input.basicQuery))
+	}
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	// Include the results of the service type in every endpoint group when asked to
+	if input.breakdown {
+		err = mongo.Pipe(session, tenantDbConfig.Db, "service_ar", breakdownQuery, &breakdown)
+		if err != nil {
+			code = http.StatusInternalServerError
+			return code, h, output, err
+		}
+	}
+
+	output, err = createServiceTypeView(results, breakdown, report, input.resultFormat())
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	return code, h, output, err
+
+}
 
+// ListEndpointResults is responsible for handling request to list endpoint results
+func ListEndpointResults(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
 	//STANDARD DECLARATIONS START
 	code := http.StatusOK
 	h := http.Header{}
@@ -184,7 +343,7 @@ func ListEndpointGroupResults(r *http.Request, cfg config.Config) (int, http.Hea
 	charset := "utf-8"
 	//STANDARD DECLARATIONS END
 
-	contentType, err = respond.ParseAcceptHeader(r)
+	contentType, err = respond.ParseAcceptHeader(r, respond.TimelineContentTypes...)
 	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
 
 	if err != nil {
@@ -228,16 +387,19 @@ func ListEndpointGroupResults(r *http.Request, cfg config.Config) (int, http.Hea
 		return code, h, output, err
 	}
 
-	input := endpointGroupResultQuery{
-		basicQuery{
-			Name:        vars["lgroup_name"],
+	input := endpointResultQuery{
+		basicQuery: basicQuery{
+			Name:        vars["endpoint_name"],
 			Granularity: urlValues.Get("granularity"),
+			Period:      urlValues.Get("period"),
 			Format:      contentType,
 			StartTime:   urlValues.Get("start_time"),
 			EndTime:     urlValues.Get("end_time"),
 			Report:      report,
 			Vars:        vars,
-		}, "",
+		},
+		EndpointGroup: vars["lgroup_name"],
+		Service:       vars["service_type"],
 	}
 
 	tenantDB := session.DB(tenantDbConfig.Db)
@@ -258,7 +420,7 @@ func ListEndpointGroupResults(r *http.Request, cfg config.Config) (int, http.Hea
 		return code, h, output, err
 	}
 
-	results := []EndpointGroupInterface{}
+	results := []EndpointInterface{}
 
 	if err != nil {
 		code = http.StatusInternalServerError
@@ -275,17 +437,24 @@ func ListEndpointGroupResults(r *http.Request, cfg config.Config) (int, http.Hea
 		filter["name"] = input.Name
 	}
 
+	if input.EndpointGroup != "" {
+		filter["supergroup"] = input.EndpointGroup
+	}
+
+	if input.Service != "" {
+		filter["service"] = input.Service
+	}
+
 	// Select the granularity of the search daily/monthly
 	if input.Granularity == "daily" {
-		customForm[0] = "20060102"
-		customForm[1] = "2006-01-02"
-		query := DailyEndpointGroup(filter)
-		err = mongo.Pipe(session, tenantDbConfig.Db, "endpoint_group_ar", query, &results)
+		query := DailyEndpoint(filter)
+		err = mongo.Pipe(session, tenantDbConfig.Db, "endpoint_ar", query, &results)
 	} else if input.Granularity == "monthly" {
-		customForm[0] = "200601"
-		customForm[1] = "2006-01"
-		query := MonthlyEndpointGroup(filter)
-		err = mongo.Pipe(session, tenantDbConfig.Db, "endpoint_group_ar", query, &results)
+		query := MonthlyEndpoint(filter)
+		err = mongo.Pipe(session, tenantDbConfig.Db, "endpoint_ar", query, &results)
+	} else {
+		query := PeriodicEndpoint(filter, periodBucket(input.basicQuery))
+		err = mongo.Pipe(session, tenantDbConfig.Db, "endpoint_ar", query, &results)
 	}
 
 	// mongo.Find(session, tenantDbConfig.Db, "endpoint_group_ar", bson.M{}, "_id", &results)
@@ -294,7 +463,7 @@ func ListEndpointGroupResults(r *http.Request, cfg config.Config) (int, http.Hea
 		return code, h, output, err
 	}
 
-	output, err = createEndpointGroupResultView(results, report, input.Format)
+	output, err = createEndpointResultView(results, report, input.resultFormat())
 
 	if err != nil {
 		code = http.StatusInternalServerError
@@ -302,10 +471,11 @@ func ListEndpointGroupResults(r *http.Request, cfg config.Config) (int, http.Hea
 	}
 
 	return code, h, output, err
+
 }
 
-// ListSuperGroupResults supergroup availabilities according to the http request
-func ListSuperGroupResults(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
+// ListEndpointGroupResults endpoint group availabilities according to the http request
+func ListEndpointGroupResults(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
 
 	//STANDARD DECLARATIONS START
 	code := http.StatusOK
@@ -316,7 +486,7 @@ func ListSuperGroupResults(r *http.Request, cfg config.Config) (int, http.Header
 	charset := "utf-8"
 	//STANDARD DECLARATIONS END
 
-	contentType, err = respond.ParseAcceptHeader(r)
+	contentType, err = respond.ParseAcceptHeader(r, respond.TimelineContentTypes...)
 	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
 
 	if err != nil {
@@ -330,7 +500,6 @@ func ListSuperGroupResults(r *http.Request, cfg config.Config) (int, http.Header
 	vars := mux.Vars(r)
 
 	tenantDbConfig, err := authentication.AuthenticateTenant(r.Header, cfg)
-
 	if err != nil {
 		if err.Error() == "Unauthorized" {
 			code = http.StatusUnauthorized
@@ -354,18 +523,25 @@ func ListSuperGroupResults(r *http.Request, cfg config.Config) (int, http.Header
 	err = mongo.FindOne(session, tenantDbConfig.Db, "reports", bson.M{"info.name": vars["report_name"]}, &report)
 
 	if err != nil {
-		code = http.StatusInternalServerError
+		code = http.StatusBadRequest
+		message := "The report with the name " + vars["report_name"] + " does not exist"
+		output, err := createErrorMessage(message, contentType) //Render the response into XML or JSON
+		h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
 		return code, h, output, err
 	}
+
 	input := endpointGroupResultQuery{
 		basicQuery{
-			Name:        vars["group_name"],
-			Granularity: urlValues.Get("granularity"),
-			Format:      contentType,
-			StartTime:   urlValues.Get("start_time"),
-			EndTime:     urlValues.Get("end_time"),
-			Report:      report,
-			Vars:        vars,
+			Name:             vars["lgroup_name"],
+			Granularity:      urlValues.Get("granularity"),
+			Period:           urlValues.Get("period"),
+			Format:           contentType,
+			StartTime:        urlValues.Get("start_time"),
+			EndTime:          urlValues.Get("end_time"),
+			CompareStartTime: urlValues.Get("compare_start_time"),
+			CompareEndTime:   urlValues.Get("compare_end_time"),
+			Report:           report,
+			Vars:             vars,
 		}, "",
 	}
 
@@ -379,7 +555,15 @@ func ListSuperGroupResults(r *http.Request, cfg config.Config) (int, http.Header
 		return code, h, output, err
 	}
 
-	results := []SuperGroupInterface{}
+	if vars["lgroup_type"] != report.GetEndpointGroupType() {
+		code = http.StatusBadRequest
+		message := "The report " + vars["report_name"] + " does not define endpoint group type: " + vars["lgroup_type"] + ". Try using " + report.GetEndpointGroupType() + " instead."
+		output, err := createErrorMessage(message, contentType) //Render the response into XML or JSON
+		h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
+		return code, h, output, err
+	}
+
+	results := []EndpointGroupInterface{}
 
 	if err != nil {
 		code = http.StatusInternalServerError
@@ -393,27 +577,49 @@ func ListSuperGroupResults(r *http.Request, cfg config.Config) (int, http.Header
 	}
 
 	if input.Name != "" {
-		filter["supergroup"] = input.Name
+		filter["name"] = input.Name
+	}
+
+	// Compare the results of the two time spans instead of listing them
+	if input.comparing() {
+		query := PeriodicEndpointGroup(compareFilter(filter, input.basicQuery), compareBucket(input.basicQuery))
+		err = mongo.Pipe(session, tenantDbConfig.Db, "endpoint_group_ar", query, &results)
+		if err != nil {
+			code = http.StatusInternalServerError
+			return code, h, output, err
+		}
+
+		rows := []comparisonRow{}
+		for _, row := range results {
+			rows = append(rows, comparisonRow{row.Name, row.SuperGroup, row.Date, row.Availability, row.Reliability})
+		}
+
+		output, err = createComparisonView(rows, report.GetEndpointGroupType(), input.basicQuery, input.resultFormat())
+		if err != nil {
+			code = http.StatusInternalServerError
+		}
+		return code, h, output, err
 	}
 
 	// Select the granularity of the search daily/monthly
 	if input.Granularity == "daily" {
-		customForm[0] = "20060102"
-		customForm[1] = "2006-01-02"
-		query := DailySuperGroup(filter)
+		query := DailyEndpointGroup(filter)
 		err = mongo.Pipe(session, tenantDbConfig.Db, "endpoint_group_ar", query, &results)
 	} else if input.Granularity == "monthly" {
-		customForm[0] = "200601"
-		customForm[1] = "2006-01"
-		query := MonthlySuperGroup(filter)
+		query := MonthlyEndpointGroup(filter)
+		err = mongo.Pipe(session, tenantDbConfig.Db, "endpoint_group_ar", query, &results)
+	} else {
+		query := PeriodicEndpointGroup(filter, periodBucket(input.basicQuery))
 		err = mongo.Pipe(session, tenantDbConfig.Db, "endpoint_group_ar", query, &results)
 	}
+
 	// mongo.Find(session, tenantDbConfig.Db, "endpoint_group_ar", bson.M{}, "_id", &results)
 	if err != nil {
 		code = http.StatusInternalServerError
 		return code, h, output, err
 	}
-	output, err = createSuperGroupView(results, report, input.Format)
+
+	output, err = createEndpointGroupResultView(results, report, input.resultFormat())
 
 	if err != nil {
 		code = http.StatusInternalServerError
@@ -423,65 +629,839 @@ func ListSuperGroupResults(r *http.Request, cfg config.Config) (int, http.Header
 	return code, h, output, err
 }
 
-// DailyServiceFlavor query to aggregate daily SF results from mongoDB
-func DailyServiceFlavor(filter bson.M) []bson.M {
-	query := []bson.M{
-		{"$match": filter},
-		{"$group": bson.M{
-			"_id": bson.M{
-				"date":         bson.D{{"$substr", list{"$date", 0, 8}}},
-				"name":         "$name",
-				"supergroup":   "$supergroup",
-				"availability": "$availability",
-				"reliability":  "$reliability",
-				"unknown":      "$unknown",
-				"up":           "$up",
-				"down":         "$down",
-				"report":       "$report"}}},
-		{"$project": bson.M{
-			"date":         "$_id.date",
-			"name":         "$_id.name",
-			"availability": "$_id.availability",
-			"reliability":  "$_id.reliability",
-			"unknown":      "$_id.unknown",
-			"up":           "$_id.up",
-			"down":         "$_id.down",
-			"supergroup":   "$_id.supergroup",
-			"report":       "$_id.report"}},
-		{"$sort": bson.D{
-			{"supergroup", 1},
-			{"name", 1},
-			{"date", 1}}}}
+// ListSuperGroupResults supergroup availabilities according to the http request
+func ListSuperGroupResults(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
 
-	return query
-}
+	//STANDARD DECLARATIONS START
+	code := http.StatusOK
+	h := http.Header{}
+	output := []byte("")
+	err := error(nil)
+	contentType := "application/xml"
+	charset := "utf-8"
+	//STANDARD DECLARATIONS END
 
-// MonthlyServiceFlavor query to aggregate daily SF results from mongoDB
-func MonthlyServiceFlavor(filter bson.M) []bson.M {
-	query := []bson.M{
-		{"$match": filter},
-		{"$group": bson.M{
-			"_id": bson.M{
-				"date":       bson.D{{"$substr", list{"$date", 0, 6}}},
-				"name":       "$name",
-				"supergroup": "$supergroup",
-				"report":     "$report"},
-			"avgup":      bson.M{"$avg": "$up"},
-			"avgunknown": bson.M{"$avg": "$unknown"},
-			"avgdown":    bson.M{"$avg": "$down"}}},
-		{"$project": bson.M{
-			"date":       "$_id.date",
-			"name":       "$_id.name",
-			"supergroup": "$_id.supergroup",
-			"report":     "$_id.report",
-			"unknown":    "$avgunknown",
-			"up":         "$avgup",
-			"down":       "$avgdown",
-			"availability": bson.M{
-				"$multiply": list{
-					bson.M{"$divide": list{
-						"$avgup", bson.M{"$subtract": list{1.00000001, "$avgunknown"}}}},
-					100}},
+	contentType, err = respond.ParseAcceptHeader(r, respond.TimelineContentTypes...)
+	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
+
+	if err != nil {
+		code = http.StatusNotAcceptable
+		output, _ = respond.MarshalContent(respond.NotAcceptableContentType, contentType, "", " ")
+		return code, h, output, err
+	}
+
+	// Parse the request into the input
+	urlValues := r.URL.Query()
+	vars := mux.Vars(r)
+
+	tenantDbConfig, err := authentication.AuthenticateTenant(r.Header, cfg)
+
+	if err != nil {
+		if err.Error() == "Unauthorized" {
+			code = http.StatusUnauthorized
+			out := respond.UnauthorizedMessage
+			output = out.MarshalTo(contentType)
+			return code, h, output, err
+		}
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	session, err := mongo.OpenSession(tenantDbConfig)
+	defer mongo.CloseSession(session)
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	report := reports.MongoInterface{}
+	err = mongo.FindOne(session, tenantDbConfig.Db, "reports", bson.M{"info.name": vars["report_name"]}, &report)
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+	input := superGroupResultQuery{
+		basicQuery{
+			Name:             vars["group_name"],
+			Granularity:      urlValues.Get("granularity"),
+			Period:           urlValues.Get("period"),
+			Format:           contentType,
+			StartTime:        urlValues.Get("start_time"),
+			EndTime:          urlValues.Get("end_time"),
+			CompareStartTime: urlValues.Get("compare_start_time"),
+			CompareEndTime:   urlValues.Get("compare_end_time"),
+			Report:           report,
+			Vars:             vars,
+		}, urlValues.Get("weighting"),
+	}
+
+	tenantDB := session.DB(tenantDbConfig.Db)
+	errs := input.Validate(tenantDB)
+	if len(errs) > 0 {
+		out := respond.BadRequestSimple
+		out.Errors = errs
+		output = out.MarshalTo(contentType)
+		code = 400
+		return code, h, output, err
+	}
+
+	results := []SuperGroupInterface{}
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	// Construct the query to mongodb based on the input
+	filter := bson.M{
+		"date":   bson.M{"$gte": input.StartTimeInt, "$lte": input.EndTimeInt},
+		"report": report.ID,
+	}
+
+	if input.Name != "" {
+		filter["supergroup"] = input.Name
+	}
+
+	// Compare the results of the two time spans instead of listing them
+	if input.comparing() {
+		query := PeriodicSuperGroup(compareFilter(filter, input.basicQuery), compareBucket(input.basicQuery), input.Weighting)
+		err = mongo.Pipe(session, tenantDbConfig.Db, "endpoint_group_ar", query, &results)
+		if err != nil {
+			code = http.StatusInternalServerError
+			return code, h, output, err
+		}
+
+		rows := []comparisonRow{}
+		for _, row := range results {
+			rows = append(rows, comparisonRow{row.SuperGroup, "", row.Date, row.Availability, row.Reliability})
+		}
+
+		output, err = createComparisonView(rows, report.GetGroupType(), input.basicQuery, input.resultFormat())
+		if err != nil {
+			code = http.StatusInternalServerError
+		}
+		return code, h, output, err
+	}
+
+	// Select the granularity of the search daily/monthly
+	if input.Granularity == "daily" {
+		query := DailySuperGroup(filter, input.Weighting)
+		err = mongo.Pipe(session, tenantDbConfig.Db, "endpoint_group_ar", query, &results)
+	} else if input.Granularity == "monthly" {
+		query := MonthlySuperGroup(filter, input.Weighting)
+		err = mongo.Pipe(session, tenantDbConfig.Db, "endpoint_group_ar", query, &results)
+	} else {
+		query := PeriodicSuperGroup(filter, periodBucket(input.basicQuery), input.Weighting)
+		err = mongo.Pipe(session, tenantDbConfig.Db, "endpoint_group_ar", query, &results)
+	}
+	// mongo.Find(session, tenantDbConfig.Db, "endpoint_group_ar", bson.M{}, "_id", &results)
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+	output, err = createSuperGroupView(results, report, report.GetGroupType(), input.resultFormat())
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	return code, h, output, err
+}
+
+// ListAdhocResults aggregates the results of an ad-hoc list of endpoint groups given in the
+// request body as if they were a single group of endpoint groups
+func ListAdhocResults(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
+
+	//STANDARD DECLARATIONS START
+	code := http.StatusOK
+	h := http.Header{}
+	output := []byte("")
+	err := error(nil)
+	contentType := "application/xml"
+	charset := "utf-8"
+	//STANDARD DECLARATIONS END
+
+	contentType, err = respond.ParseAcceptHeader(r, respond.TimelineContentTypes...)
+	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
+
+	if err != nil {
+		code = http.StatusNotAcceptable
+		output, _ = respond.MarshalContent(respond.NotAcceptableContentType, contentType, "", " ")
+		return code, h, output, err
+	}
+
+	// Parse the request into the input
+	urlValues := r.URL.Query()
+	vars := mux.Vars(r)
+
+	tenantDbConfig, err := authentication.AuthenticateTenant(r.Header, cfg)
+
+	if err != nil {
+		if err.Error() == "Unauthorized" {
+			code = http.StatusUnauthorized
+			out := respond.UnauthorizedMessage
+			output = out.MarshalTo(contentType)
+			return code, h, output, err
+		}
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	session, err := mongo.OpenSession(tenantDbConfig)
+	defer mongo.CloseSession(session)
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	incoming := AdhocGroup{}
+
+	// Try ingest request body
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, cfg.Server.ReqSizeLimit))
+	if err != nil {
+		panic(err)
+	}
+	if err := r.Body.Close(); err != nil {
+		panic(err)
+	}
+
+	// Parse body json
+	if err := json.Unmarshal(body, &incoming); err != nil {
+		out := respond.BadRequestBadJSON
+		output = out.MarshalTo(contentType)
+		code = 400
+		return code, h, output, err
+	}
+
+	report := reports.MongoInterface{}
+	err = mongo.FindOne(session, tenantDbConfig.Db, "reports", bson.M{"info.name": vars["report_name"]}, &report)
+
+	if err != nil {
+		code = http.StatusBadRequest
+		message := "The report with the name " + vars["report_name"] + " does not exist"
+		output, err := createErrorMessage(message, contentType) //Render the response into XML or JSON
+		h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
+		return code, h, output, err
+	}
+
+	// The weighting of the body takes precedence over the one of the url
+	if incoming.Weighting == "" {
+		incoming.Weighting = urlValues.Get("weighting")
+	}
+
+	input := adhocResultQuery{
+		superGroupResultQuery{
+			basicQuery{
+				Name:        incoming.Name,
+				Granularity: urlValues.Get("granularity"),
+				Period:      urlValues.Get("period"),
+				Format:      contentType,
+				StartTime:   urlValues.Get("start_time"),
+				EndTime:     urlValues.Get("end_time"),
+				Report:      report,
+				Vars:        vars,
+			}, incoming.Weighting,
+		}, incoming.Groups,
+	}
+
+	tenantDB := session.DB(tenantDbConfig.Db)
+	errs := input.Validate(tenantDB)
+	if len(errs) > 0 {
+		out := respond.BadRequestSimple
+		out.Errors = errs
+		output = out.MarshalTo(contentType)
+		code = 400
+		return code, h, output, err
+	}
+
+	results := []SuperGroupInterface{}
+
+	// Construct the query to mongodb based on the input
+	filter := bson.M{
+		"date":   bson.M{"$gte": input.StartTimeInt, "$lte": input.EndTimeInt},
+		"report": report.ID,
+		"name":   bson.M{"$in": input.Groups},
+	}
+
+	// Select the granularity of the search daily/monthly
+	if input.Granularity == "daily" {
+		query := adhocSuperGroup(DailySuperGroup(filter, input.Weighting), input.Name)
+		err = mongo.Pipe(session, tenantDbConfig.Db, "endpoint_group_ar", query, &results)
+	} else if input.Granularity == "monthly" {
+		query := adhocSuperGroup(MonthlySuperGroup(filter, input.Weighting), input.Name)
+		err = mongo.Pipe(session, tenantDbConfig.Db, "endpoint_group_ar", query, &results)
+	} else {
+		query := adhocSuperGroup(PeriodicSuperGroup(filter, periodBucket(input.basicQuery), input.Weighting), input.Name)
+		err = mongo.Pipe(session, tenantDbConfig.Db, "endpoint_group_ar", query, &results)
+	}
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	output, err = createSuperGroupView(results, report, "adhoc", input.resultFormat())
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	return code, h, output, err
+}
+
+// ListComputedResults computes the availability and reliability of an endpoint group for an arbitrary
+// time span of second precision by walking its status timeline instead of using the daily results
+func ListComputedResults(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
+
+	//STANDARD DECLARATIONS START
+	code := http.StatusOK
+	h := http.Header{}
+	output := []byte("")
+	err := error(nil)
+	contentType := "application/xml"
+	charset := "utf-8"
+	//STANDARD DECLARATIONS END
+
+	contentType, err = respond.ParseAcceptHeader(r, respond.TimelineContentTypes...)
+	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
+
+	if err != nil {
+		code = http.StatusNotAcceptable
+		output, _ = respond.MarshalContent(respond.NotAcceptableContentType, contentType, "", " ")
+		return code, h, output, err
+	}
+
+	// Parse the request into the input
+	urlValues := r.URL.Query()
+	vars := mux.Vars(r)
+
+	tenantDbConfig, err := authentication.AuthenticateTenant(r.Header, cfg)
+
+	if err != nil {
+		if err.Error() == "Unauthorized" {
+			code = http.StatusUnauthorized
+			out := respond.UnauthorizedMessage
+			output = out.MarshalTo(contentType)
+			return code, h, output, err
+		}
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	session, err := mongo.OpenSession(tenantDbConfig)
+	defer mongo.CloseSession(session)
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	report := reports.MongoInterface{}
+	err = mongo.FindOne(session, tenantDbConfig.Db, "reports", bson.M{"info.name": vars["report_name"]}, &report)
+
+	if err != nil {
+		code = http.StatusBadRequest
+		message := "The report with the name " + vars["report_name"] + " does not exist"
+		output, err := createErrorMessage(message, contentType) //Render the response into XML or JSON
+		h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
+		return code, h, output, err
+	}
+
+	input := computedResultQuery{
+		basicQuery: basicQuery{
+			Name:      vars["group_name"],
+			Format:    contentType,
+			StartTime: urlValues.Get("start_time"),
+			EndTime:   urlValues.Get("end_time"),
+			Report:    report,
+			Vars:      vars,
+		},
+	}
+
+	tenantDB := session.DB(tenantDbConfig.Db)
+	errs := input.Validate(tenantDB)
+	if len(errs) > 0 {
+		out := respond.BadRequestSimple
+		out.Errors = errs
+		output = out.MarshalTo(contentType)
+		code = 400
+		return code, h, output, err
+	}
+
+	// The states of the timeline are classified according to the operations profile of the report
+	profileName, err := reports.GetOperationsProfile(report)
+	profile := operationsProfiles.OpsProfile{}
+	if err == nil {
+		err = mongo.FindOne(session, tenantDbConfig.Db, "operations_profiles", bson.M{"name": profileName}, &profile)
+	}
+
+	if err != nil {
+		code = http.StatusBadRequest
+		message := "The report " + vars["report_name"] + " does not define an existing operations profile"
+		output, err := createErrorMessage(message, contentType) //Render the response into XML or JSON
+		h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
+		return code, h, output, err
+	}
+
+	classes, err := classifyStates(profile)
+	if err != nil {
+		code = http.StatusBadRequest
+		output, err := createErrorMessage(err.Error(), contentType) //Render the response into XML or JSON
+		h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
+		return code, h, output, err
+	}
+
+	startTime, endTime := input.start.Format(zuluForm), input.end.Format(zuluForm)
+	timeline := []StatusInterface{}
+	previous := StatusInterface{}
+
+	// The last status before the time span holds the state of the endpoint group when the time span begins
+	err = tenantDB.C("status_endpoint_groups").Find(bson.M{
+		"report":         report.ID,
+		"endpoint_group": input.Name,
+		"date_integer":   bson.M{"$lte": input.StartTimeInt},
+		"timestamp":      bson.M{"$lt": startTime},
+	}).Sort("-timestamp").One(&previous)
+
+	if err == nil {
+		timeline = append(timeline, previous)
+	} else if err != mgo.ErrNotFound {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	filter := bson.M{
+		"report":         report.ID,
+		"endpoint_group": input.Name,
+		"date_integer":   bson.M{"$gte": input.StartTimeInt, "$lte": input.EndTimeInt},
+		"timestamp":      bson.M{"$gte": startTime, "$lte": endTime},
+	}
+
+	window := []StatusInterface{}
+	err = mongo.Find(session, tenantDbConfig.Db, "status_endpoint_groups", filter, "timestamp", &window)
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	durations := computeDurations(append(timeline, window...), input.start, input.end, profile.Defaults.Missing, classes)
+	output, err = createComputedView(durations, input, input.resultFormat())
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	return code, h, output, err
+}
+
+// ListRankedResults ranks the endpoint groups or groups of endpoint groups of a type according
+// to their average availability or reliability over the requested time span
+func ListRankedResults(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
+
+	//STANDARD DECLARATIONS START
+	code := http.StatusOK
+	h := http.Header{}
+	output := []byte("")
+	err := error(nil)
+	contentType := "application/xml"
+	charset := "utf-8"
+	//STANDARD DECLARATIONS END
+
+	contentType, err = respond.ParseAcceptHeader(r, respond.TimelineContentTypes...)
+	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
+
+	if err != nil {
+		code = http.StatusNotAcceptable
+		output, _ = respond.MarshalContent(respond.NotAcceptableContentType, contentType, "", " ")
+		return code, h, output, err
+	}
+
+	// Parse the request into the input
+	urlValues := r.URL.Query()
+	vars := mux.Vars(r)
+
+	tenantDbConfig, err := authentication.AuthenticateTenant(r.Header, cfg)
+	if err != nil {
+		if err.Error() == "Unauthorized" {
+			code = http.StatusUnauthorized
+			out := respond.UnauthorizedMessage
+			output = out.MarshalTo(contentType)
+			return code, h, output, err
+		}
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	session, err := mongo.OpenSession(tenantDbConfig)
+	defer mongo.CloseSession(session)
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	report := reports.MongoInterface{}
+	err = mongo.FindOne(session, tenantDbConfig.Db, "reports", bson.M{"info.name": vars["report_name"]}, &report)
+
+	if err != nil {
+		code = http.StatusBadRequest
+		message := "The report with the name " + vars["report_name"] + " does not exist"
+		output, err := createErrorMessage(message, contentType) //Render the response into XML or JSON
+		h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
+		return code, h, output, err
+	}
+
+	selectedGroupType := report.DetermineGroupType(vars["group_type"])
+
+	input := rankResultQuery{
+		basicQuery: basicQuery{
+			Format:    contentType,
+			StartTime: urlValues.Get("start_time"),
+			EndTime:   urlValues.Get("end_time"),
+			Report:    report,
+			Vars:      vars,
+		},
+		Order:  urlValues.Get("order"),
+		Metric: urlValues.Get("metric"),
+		Limit:  urlValues.Get("limit"),
+	}
+
+	tenantDB := session.DB(tenantDbConfig.Db)
+	errs := input.Validate(tenantDB)
+	if len(errs) > 0 {
+		out := respond.BadRequestSimple
+		out.Errors = errs
+		output = out.MarshalTo(contentType)
+		code = 400
+		return code, h, output, err
+	}
+
+	results := []RankInterface{}
+
+	// Construct the query to mongodb based on the input
+	filter := bson.M{
+		"date":   bson.M{"$gte": input.StartTimeInt, "$lte": input.EndTimeInt},
+		"report": report.ID,
+	}
+
+	order := 1
+	if input.Order == "desc" {
+		order = -1
+	}
+
+	// Rank either the endpoint groups or the groups of endpoint groups
+	groupType := report.GetGroupType()
+	if selectedGroupType == "endpoint" {
+		groupType = report.GetEndpointGroupType()
+		query := RankEndpointGroup(filter, input.Metric, order, input.limit)
+		err = mongo.Pipe(session, tenantDbConfig.Db, "endpoint_group_ar", query, &results)
+	} else {
+		query := RankSuperGroup(filter, input.Metric, order, input.limit)
+		err = mongo.Pipe(session, tenantDbConfig.Db, "endpoint_group_ar", query, &results)
+	}
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	output, err = createRankView(results, groupType, input.resultFormat())
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	return code, h, output, err
+}
+
+// ListViolations lists the endpoint groups or groups of endpoint groups of a type that did not meet
+// their SLA targets in the periods of the requested time span
+func ListViolations(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
+
+	//STANDARD DECLARATIONS START
+	code := http.StatusOK
+	h := http.Header{}
+	output := []byte("")
+	err := error(nil)
+	contentType := "application/xml"
+	charset := "utf-8"
+	//STANDARD DECLARATIONS END
+
+	contentType, err = respond.ParseAcceptHeader(r, respond.TimelineContentTypes...)
+	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
+
+	if err != nil {
+		code = http.StatusNotAcceptable
+		output, _ = respond.MarshalContent(respond.NotAcceptableContentType, contentType, "", " ")
+		return code, h, output, err
+	}
+
+	// Parse the request into the input
+	urlValues := r.URL.Query()
+	vars := mux.Vars(r)
+
+	tenantDbConfig, err := authentication.AuthenticateTenant(r.Header, cfg)
+	if err != nil {
+		if err.Error() == "Unauthorized" {
+			code = http.StatusUnauthorized
+			out := respond.UnauthorizedMessage
+			output = out.MarshalTo(contentType)
+			return code, h, output, err
+		}
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	session, err := mongo.OpenSession(tenantDbConfig)
+	defer mongo.CloseSession(session)
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	report := reports.MongoInterface{}
+	err = mongo.FindOne(session, tenantDbConfig.Db, "reports", bson.M{"info.name": vars["report_name"]}, &report)
+
+	if err != nil {
+		code = http.StatusBadRequest
+		message := "The report with the name " + vars["report_name"] + " does not exist"
+		output, err := createErrorMessage(message, contentType) //Render the response into XML or JSON
+		h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
+		return code, h, output, err
+	}
+
+	selectedGroupType := report.DetermineGroupType(vars["group_type"])
+
+	input := basicQuery{
+		Granularity: urlValues.Get("granularity"),
+		Period:      urlValues.Get("period"),
+		Format:      contentType,
+		StartTime:   urlValues.Get("start_time"),
+		EndTime:     urlValues.Get("end_time"),
+		Report:      report,
+		Vars:        vars,
+	}
+
+	tenantDB := session.DB(tenantDbConfig.Db)
+	errs := input.Validate(tenantDB)
+	if len(errs) > 0 {
+		out := respond.BadRequestSimple
+		out.Errors = errs
+		output = out.MarshalTo(contentType)
+		code = 400
+		return code, h, output, err
+	}
+
+	results := []EndpointGroupInterface{}
+
+	// Construct the query to mongodb based on the input
+	filter := bson.M{
+		"date":   bson.M{"$gte": input.StartTimeInt, "$lte": input.EndTimeInt},
+		"report": report.ID,
+	}
+
+	// Find the results of either the endpoint groups or the groups of endpoint groups
+	// in the requested granularity
+	groupType := report.GetGroupType()
+	if selectedGroupType == "endpoint" {
+		groupType = report.GetEndpointGroupType()
+		if input.Granularity == "daily" {
+			err = mongo.Pipe(session, tenantDbConfig.Db, "endpoint_group_ar", DailyEndpointGroup(filter), &results)
+		} else if input.Granularity == "monthly" {
+			err = mongo.Pipe(session, tenantDbConfig.Db, "endpoint_group_ar", MonthlyEndpointGroup(filter), &results)
+		} else {
+			query := PeriodicEndpointGroup(filter, periodBucket(input))
+			err = mongo.Pipe(session, tenantDbConfig.Db, "endpoint_group_ar", query, &results)
+		}
+	} else {
+		superGroups := []SuperGroupInterface{}
+		if input.Granularity == "daily" {
+			err = mongo.Pipe(session, tenantDbConfig.Db, "endpoint_group_ar", DailySuperGroup(filter, "none"), &superGroups)
+		} else if input.Granularity == "monthly" {
+			err = mongo.Pipe(session, tenantDbConfig.Db, "endpoint_group_ar", MonthlySuperGroup(filter, "none"), &superGroups)
+		} else {
+			query := PeriodicSuperGroup(filter, periodBucket(input), "none")
+			err = mongo.Pipe(session, tenantDbConfig.Db, "endpoint_group_ar", query, &superGroups)
+		}
+		for _, row := range superGroups {
+			results = append(results, EndpointGroupInterface{
+				Name:         row.SuperGroup,
+				Date:         row.Date,
+				Availability: row.Availability,
+				Reliability:  row.Reliability,
+			})
+		}
+	}
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	output, err = createViolationView(results, groupType, report, input.resultFormat())
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	return code, h, output, err
+}
+
+// DailyServiceFlavor query to aggregate daily SF results from mongoDB
+func DailyServiceFlavor(filter bson.M) []bson.M {
+	query := []bson.M{
+		{"$match": filter},
+		{"$group": bson.M{
+			"_id": bson.M{
+				"date":         bson.D{{"$substr", list{"$date", 0, 8}}},
+				"name":         "$name",
+				"supergroup":   "$supergroup",
+				"availability": "$availability",
+				"reliability":  "$reliability",
+				"unknown":      "$unknown",
+				"up":           "$up",
+				"down":         "$down",
+				"report":       "$report"}}},
+		{"$project": bson.M{
+			"date":         "$_id.date",
+			"name":         "$_id.name",
+			"availability": "$_id.availability",
+			"reliability":  "$_id.reliability",
+			"unknown":      "$_id.unknown",
+			"up":           "$_id.up",
+			"down":         "$_id.down",
+			"supergroup":   "$_id.supergroup",
+			"report":       "$_id.report"}},
+		{"$sort": bson.D{
+			{"supergroup", 1},
+			{"name", 1},
+			{"date", 1}}}}
+
+	return query
+}
+
+// MonthlyServiceFlavor query to aggregate daily SF results from mongoDB
+func MonthlyServiceFlavor(filter bson.M) []bson.M {
+	query := []bson.M{
+		{"$match": filter},
+		{"$group": bson.M{
+			"_id": bson.M{
+				"date":       bson.D{{"$substr", list{"$date", 0, 6}}},
+				"name":       "$name",
+				"supergroup": "$supergroup",
+				"report":     "$report"},
+			"avgup":      bson.M{"$avg": "$up"},
+			"avgunknown": bson.M{"$avg": "$unknown"},
+			"avgdown":    bson.M{"$avg": "$down"}}},
+		{"$project": bson.M{
+			"date":       "$_id.date",
+			"name":       "$_id.name",
+			"supergroup": "$_id.supergroup",
+			"report":     "$_id.report",
+			"unknown":    "$avgunknown",
+			"up":         "$avgup",
+			"down":       "$avgdown",
+			"availability": bson.M{
+				"$multiply": list{
+					bson.M{"$divide": list{
+						"$avgup", bson.M{"$subtract": list{1.00000001, "$avgunknown"}}}},
+					100}},
+			"reliability": bson.M{
+				"$multiply": list{
+					bson.M{"$divide": list{
+						"$avgup", bson.M{"$subtract": list{bson.M{"$subtract": list{1.00000001, "$avgunknown"}}, "$avgdown"}}}},
+					100}}}},
+		{"$sort": bson.D{
+			{"supergroup", 1},
+			{"name", 1},
+			{"date", 1}}}}
+	return query
+}
+
+// DailyEndpoint query to aggregate daily endpoint results from mongoDB
+func DailyEndpoint(filter bson.M) []bson.M {
+	query := []bson.M{
+		{"$match": filter},
+		{"$project": bson.M{
+			"date":         bson.M{"$substr": list{"$date", 0, 8}},
+			"name":         1,
+			"service":      1,
+			"supergroup":   1,
+			"availability": 1,
+			"reliability":  1,
+			"unknown":      1,
+			"up":           1,
+			"down":         1,
+			"report":       1}},
+		{"$sort": bson.D{
+			{"supergroup", 1},
+			{"service", 1},
+			{"name", 1},
+			{"date", 1}}}}
+
+	return query
+}
+
+// MonthlyEndpoint query to aggregate monthly endpoint results from mongoDB
+func MonthlyEndpoint(filter bson.M) []bson.M {
+	return PeriodicEndpoint(filter, bson.M{"$substr": list{"$date", 0, 6}})
+}
+
+// PeriodicEndpoint query to aggregate daily endpoint results from mongoDB into arbitrary buckets
+func PeriodicEndpoint(filter bson.M, bucket interface{}) []bson.M {
+	// Mongo aggregation pipeline
+	// Select all the records that match q
+	// Project the date of each record to the bucket it belongs to
+	// Group them by bucket, name, service, supergroup and report and find the averages of up, unknown and down
+	// Project the result to a better format and do this computation
+	// availability = (avgup/(1.00000001 - avgu))*100
+	// reliability = (avgup/((1.00000001 - avgu)-avgd))*100
+	// Sort the results by supergroup->service->name->datetime
+	query := []bson.M{
+		{"$match": filter},
+		{"$project": bson.M{
+			"date":       bucket,
+			"name":       1,
+			"service":    1,
+			"supergroup": 1,
+			"report":     1,
+			"up":         1,
+			"unknown":    1,
+			"down":       1}},
+		{"$group": bson.M{
+			"_id": bson.M{
+				"date":       "$date",
+				"name":       "$name",
+				"service":    "$service",
+				"supergroup": "$supergroup",
+				"report":     "$report"},
+			"avgup":      bson.M{"$avg": "$up"},
+			"avgunknown": bson.M{"$avg": "$unknown"},
+			"avgdown":    bson.M{"$avg": "$down"}}},
+		{"$project": bson.M{
+			"date":       "$_id.date",
+			"name":       "$_id.name",
+			"service":    "$_id.service",
+			"supergroup": "$_id.supergroup",
+			"report":     "$_id.report",
+			"unknown":    "$avgunknown",
+			"up":         "$avgup",
+			"down":       "$avgdown",
+			"availability": bson.M{
+				"$multiply": list{
+					bson.M{"$divide": list{
+						"$avgup", bson.M{"$subtract": list{1.00000001, "$avgunknown"}}}},
+					100}},
 			"reliability": bson.M{
 				"$multiply": list{
 					bson.M{"$divide": list{
@@ -489,8 +1469,10 @@ func MonthlyServiceFlavor(filter bson.M) []bson.M {
 					100}}}},
 		{"$sort": bson.D{
 			{"supergroup", 1},
+			{"service", 1},
 			{"name", 1},
 			{"date", 1}}}}
+
 	return query
 }
 
@@ -575,28 +1557,30 @@ func MonthlyEndpointGroup(filter bson.M) []bson.M {
 }
 
 // DailySuperGroup function to build the MongoDB aggregation query for daily calculations
-func DailySuperGroup(filter bson.M) []bson.M {
+func DailySuperGroup(filter bson.M, weighting string) []bson.M {
 	// Mongo aggregation pipeline
 	// Select all the records that match q
-	// Project the results to add 1 to every weights to avoid having 0 as a weights
+	// Join them with their factors when they are weighted by them
+	// Project the results to the weight of every record according to the weighting
 	// Group them by the first 8 digits of datetime (YYYYMMDD) and each group find
 	// availability = sum(availability*weights)
 	// reliability = sum(reliability*weights)
 	// weights = sum(weights)
+	// Drop the groups that have no weight at all
 	// Project to a better format and do these computations
 	// availability = availability/weights
 	// reliability = reliability/weights
 	// Sort by report->supergroup->name->datetime
-	query := []bson.M{
-		{"$match": filter},
+	lookup, weight := superGroupWeight(weighting)
+	query := append([]bson.M{{"$match": filter}}, lookup...)
+	query = append(query, []bson.M{
 		{"$project": bson.M{
 			"date":         1,
 			"availability": 1,
 			"reliability":  1,
 			"report":       1,
 			"supergroup":   1,
-			"weight": bson.M{
-				"$add": list{"$weight", 1}}},
+			"weight":       weight},
 		},
 		{"$group": bson.M{
 			"_id": bson.M{
@@ -611,6 +1595,9 @@ func DailySuperGroup(filter bson.M) []bson.M {
 					"$multiply": list{"$reliability", "$weight"}}},
 			"weight": bson.M{"$sum": "$weight"}},
 		},
+		{"$match": bson.M{
+			"weight": bson.M{"$gt": 0}},
+		},
 		{"$project": bson.M{
 			"date":       "$_id.date",
 			"supergroup": "$_id.supergroup",
@@ -624,18 +1611,19 @@ func DailySuperGroup(filter bson.M) []bson.M {
 			{"report", 1},
 			{"supergroup", 1},
 			{"date", 1}},
-		}}
+		}}...)
 
 	return query
 }
 
 // MonthlySuperGroup function to build the MongoDB aggregation query for monthly calculations
-func MonthlySuperGroup(filter bson.M) []bson.M {
+func MonthlySuperGroup(filter bson.M, weighting string) []bson.M {
 	filter["availability"] = bson.M{"$gte": 0}
 	filter["reliability"] = bson.M{"$gte": 0}
 	// Mongo aggregation pipeline
 	// Select all the records that match q
-	// Project the results to add 1 to every weights to avoid having 0 as a weights
+	// Join them with their factors when they are weighted by them
+	// Project the results to the weight of every record according to the weighting
 	// Group them by the first 8 digits of datetime (YYYYMMDD) and each group find
 	// availability = sum(availability*weights)
 	// reliability = sum(reliability*weights)
@@ -649,16 +1637,16 @@ func MonthlySuperGroup(filter bson.M) []bson.M {
 	// Project the results to a better format
 	// Sort by namespace->report->supergroup->datetime
 
-	query := []bson.M{
-		{"$match": filter},
+	lookup, weight := superGroupWeight(weighting)
+	query := append([]bson.M{{"$match": filter}}, lookup...)
+	query = append(query, []bson.M{
 		{"$project": bson.M{
 			"date":         1,
 			"availability": 1,
 			"reliability":  1,
 			"report":       1,
 			"supergroup":   1,
-			"weight": bson.M{
-				"$add": list{"$weight", 1}}},
+			"weight":       weight},
 		},
 		{"$group": bson.M{
 			"_id": bson.M{
@@ -697,7 +1685,358 @@ func MonthlySuperGroup(filter bson.M) []bson.M {
 			{"report", 1},
 			{"supergroup", 1},
 			{"date", 1}},
-		}}
+		}}...)
+
+	return query
+}
+
+// compareFilter replaces the time span of a filter with the two time spans that are compared
+func compareFilter(filter bson.M, query basicQuery) bson.M {
+	delete(filter, "date")
+	filter["$or"] = list{
+		bson.M{"date": bson.M{"$gte": query.StartTimeInt, "$lte": query.EndTimeInt}},
+		bson.M{"date": bson.M{"$gte": query.CompareStartTimeInt, "$lte": query.CompareEndTimeInt}},
+	}
+	return filter
+}
+
+// compareBucket builds the aggregation expression that maps the date of a daily document
+// to the start of the compared time span it belongs to
+func compareBucket(query basicQuery) interface{} {
+	return bson.M{"$cond": list{
+		bson.M{"$and": list{
+			bson.M{"$gte": list{"$date", query.StartTimeInt}},
+			bson.M{"$lte": list{"$date", query.EndTimeInt}}}},
+		strconv.Itoa(query.StartTimeInt),
+		strconv.Itoa(query.CompareStartTimeInt)}}
+}
+
+// periodBucket builds the aggregation expression that maps the date of a daily document
+// to the bucket it belongs to in weekly, yearly and custom period queries
+func periodBucket(query basicQuery) interface{} {
+	if query.Granularity == "yearly" {
+		return bson.M{"$substr": list{"$date", 0, 4}}
+	}
+	// pick the latest bucket start that is not after the document's date
+	return bson.M{"$substr": list{
+		bson.M{"$max": bson.M{"$filter": bson.M{
+			"input": query.bucketStarts(),
+			"as":    "start",
+			"cond":  bson.M{"$lte": list{"$$start", "$date"}}}}},
+		0, 8}}
+}
+
+// PeriodicServiceFlavor query to aggregate daily SF results from mongoDB into arbitrary buckets
+func PeriodicServiceFlavor(filter bson.M, bucket interface{}) []bson.M {
+	// Mongo aggregation pipeline
+	// Select all the records that match q
+	// Project the date of each record to the bucket it belongs to
+	// Group them by bucket, name, supergroup and report and compute the averages
+	// of up, unknown and down in the same way as the monthly pipeline does
+	query := []bson.M{
+		{"$match": filter},
+		{"$project": bson.M{
+			"date":       bucket,
+			"name":       1,
+			"supergroup": 1,
+			"report":     1,
+			"up":         1,
+			"unknown":    1,
+			"down":       1}},
+		{"$group": bson.M{
+			"_id": bson.M{
+				"date":       "$date",
+				"name":       "$name",
+				"supergroup": "$supergroup",
+				"report":     "$report"},
+			"avgup":      bson.M{"$avg": "$up"},
+			"avgunknown": bson.M{"$avg": "$unknown"},
+			"avgdown":    bson.M{"$avg": "$down"}}},
+		{"$project": bson.M{
+			"date":       "$_id.date",
+			"name":       "$_id.name",
+			"supergroup": "$_id.supergroup",
+			"report":     "$_id.report",
+			"unknown":    "$avgunknown",
+			"up":         "$avgup",
+			"down":       "$avgdown",
+			"availability": bson.M{
+				"$multiply": list{
+					bson.M{"$divide": list{
+						"$avgup", bson.M{"$subtract": list{1.00000001, "$avgunknown"}}}},
+					100}},
+			"reliability": bson.M{
+				"$multiply": list{
+					bson.M{"$divide": list{
+						"$avgup", bson.M{"$subtract": list{bson.M{"$subtract": list{1.00000001, "$avgunknown"}}, "$avgdown"}}}},
+					100}}}},
+		{"$sort": bson.D{
+			{"supergroup", 1},
+			{"name", 1},
+			{"date", 1}}}}
+	return query
+}
+
+// AggregateServiceFlavor query to aggregate daily SF results of all endpoint groups from mongoDB
+func AggregateServiceFlavor(filter bson.M, bucket interface{}) []bson.M {
+	// Mongo aggregation pipeline
+	// Select all the records that match q
+	// Project the date of each record to the bucket it belongs to
+	// Group them by bucket, name and report regardless of their endpoint group and compute
+	// the averages of up, unknown and down in the same way as the monthly pipeline does
+	query := []bson.M{
+		{"$match": filter},
+		{"$project": bson.M{
+			"date":    bucket,
+			"name":    1,
+			"report":  1,
+			"up":      1,
+			"unknown": 1,
+			"down":    1}},
+		{"$group": bson.M{
+			"_id": bson.M{
+				"date":   "$date",
+				"name":   "$name",
+				"report": "$report"},
+			"avgup":      bson.M{"$avg": "$up"},
+			"avgunknown": bson.M{"$avg": "$unknown"},
+			"avgdown":    bson.M{"$avg": "$down"}}},
+		{"$project": bson.M{
+			"date":    "$_id.date",
+			"name":    "$_id.name",
+			"report":  "$_id.report",
+			"unknown": "$avgunknown",
+			"up":      "$avgup",
+			"down":    "$avgdown",
+			"availability": bson.M{
+				"$multiply": list{
+					bson.M{"$divide": list{
+						"$avgup", bson.M{"$subtract": list{1.00000001, "$avgunknown"}}}},
+					100}},
+			"reliability": bson.M{
+				"$multiply": list{
+					bson.M{"$divide": list{
+						"$avgup", bson.M{"$subtract": list{bson.M{"$subtract": list{1.00000001, "$avgunknown"}}, "$avgdown"}}}},
+					100}}}},
+		{"$sort": bson.D{
+			{"name", 1},
+			{"date", 1}}}}
+	return query
+}
+
+// PeriodicEndpointGroup query to aggregate daily endpoint group results from mongodb into arbitrary buckets
+func PeriodicEndpointGroup(filter bson.M, bucket interface{}) []bson.M {
+	// Mongo aggregation pipeline
+	// Select all the records that match q
+	// Project the date of each record to the bucket it belongs to
+	// Group them by bucket, name, supergroup and report and compute the averages
+	// of up, unknown and down in the same way as the monthly pipeline does
+	query := []bson.M{
+		{"$match": filter},
+		{"$project": bson.M{
+			"date":       bucket,
+			"name":       1,
+			"supergroup": 1,
+			"report":     1,
+			"up":         1,
+			"unknown":    1,
+			"down":       1}},
+		{"$group": bson.M{
+			"_id": bson.M{
+				"date":       "$date",
+				"name":       "$name",
+				"supergroup": "$supergroup",
+				"report":     "$report"},
+			"avgup":      bson.M{"$avg": "$up"},
+			"avgunknown": bson.M{"$avg": "$unknown"},
+			"avgdown":    bson.M{"$avg": "$down"}}},
+		{"$project": bson.M{
+			"date":       "$_id.date",
+			"name":       "$_id.name",
+			"report":     "$_id.report",
+			"supergroup": "$_id.supergroup",
+			"unknown":    "$avgunknown",
+			"up":         "$avgup",
+			"down":       "$avgdown",
+			"availability": bson.M{
+				"$multiply": list{
+					bson.M{"$divide": list{
+						"$avgup", bson.M{"$subtract": list{1.00000001, "$avgunknown"}}}},
+					100}},
+			"reliability": bson.M{
+				"$multiply": list{
+					bson.M{"$divide": list{
+						"$avgup", bson.M{"$subtract": list{bson.M{"$subtract": list{1.00000001, "$avgunknown"}}, "$avgdown"}}}},
+					100}}}},
+		{"$sort": bson.D{
+			{"report", 1},
+			{"supergroup", 1},
+			{"name", 1},
+			{"date", 1}}}}
+
+	return query
+}
+
+// PeriodicSuperGroup function to build the MongoDB aggregation query for calculations over arbitrary buckets
+func PeriodicSuperGroup(filter bson.M, bucket interface{}, weighting string) []bson.M {
+	filter["availability"] = bson.M{"$gte": 0}
+	filter["reliability"] = bson.M{"$gte": 0}
+	// Mongo aggregation pipeline
+	// Select all the records that match q
+	// Join them with their factors when they are weighted by them
+	// Project the results to the weight of every record according to the weighting
+	// and to find the bucket each record belongs to
+	// Group them by date and bucket and each group find the weighted
+	// availability and reliability as the daily pipeline does
+	// Group by bucket, supergroup and report and for each group find
+	// availability = average(availability)
+	// reliability = average(reliability)
+	// Project the results to a better format
+	// Sort by report->supergroup->datetime
+	lookup, weight := superGroupWeight(weighting)
+	query := append([]bson.M{{"$match": filter}}, lookup...)
+	query = append(query, []bson.M{
+		{"$project": bson.M{
+			"date":         1,
+			"bucket":       bucket,
+			"availability": 1,
+			"reliability":  1,
+			"report":       1,
+			"supergroup":   1,
+			"weight":       weight},
+		},
+		{"$group": bson.M{
+			"_id": bson.M{
+				"date":       "$date",
+				"bucket":     "$bucket",
+				"supergroup": "$supergroup",
+				"report":     "$report"},
+			"availability": bson.M{"$sum": bson.M{"$multiply": list{"$availability", "$weight"}}},
+			"reliability":  bson.M{"$sum": bson.M{"$multiply": list{"$reliability", "$weight"}}},
+			"weight":       bson.M{"$sum": "$weight"}},
+		},
+		{"$match": bson.M{
+			"weight": bson.M{"$gt": 0}},
+		},
+		{"$project": bson.M{
+			"bucket":       "$_id.bucket",
+			"supergroup":   "$_id.supergroup",
+			"report":       "$_id.report",
+			"availability": bson.M{"$divide": list{"$availability", "$weight"}},
+			"reliability":  bson.M{"$divide": list{"$reliability", "$weight"}}},
+		},
+		{"$group": bson.M{
+			"_id": bson.M{
+				"date":       "$bucket",
+				"supergroup": "$supergroup", "report": "$report"},
+			"availability": bson.M{"$avg": "$availability"},
+			"reliability":  bson.M{"$avg": "$reliability"}},
+		},
+		{"$project": bson.M{
+			"date":         "$_id.date",
+			"supergroup":   "$_id.supergroup",
+			"report":       "$_id.report",
+			"availability": 1,
+			"reliability":  1},
+		},
+		{"$sort": bson.D{
+			{"report", 1},
+			{"supergroup", 1},
+			{"date", 1}},
+		}}...)
 
 	return query
 }
+
+// adhocSuperGroup places every endpoint group matched by a supergroup pipeline under the same
+// supergroup name so that the pipeline aggregates them as a single ad-hoc group
+func adhocSuperGroup(query []bson.M, name string) []bson.M {
+	relabel := bson.M{"$addFields": bson.M{"supergroup": bson.M{"$literal": name}}}
+	return append(query[:1], append([]bson.M{relabel}, query[1:]...)...)
+}
+
+// superGroupWeight returns the stages that have to run before the endpoint group records are weighted
+// together with the expression of the weight for the requested weighting
+func superGroupWeight(weighting string) ([]bson.M, interface{}) {
+	switch weighting {
+	case "factors":
+		// Join every endpoint group with its factor. Endpoint groups without a factor do not count
+		lookup := []bson.M{
+			{"$lookup": bson.M{
+				"from":         "weights",
+				"localField":   "name",
+				"foreignField": "name",
+				"as":           "factors"},
+			}}
+		return lookup, bson.M{"$ifNull": list{bson.M{"$arrayElemAt": list{"$factors.hepspec", 0}}, 0}}
+	case "equal":
+		return nil, bson.M{"$literal": 1}
+	}
+	// Add 1 to every weight to avoid having 0 as a weight
+	return nil, bson.M{"$add": list{"$weight", 1}}
+}
+
+// RankEndpointGroup function to build the MongoDB aggregation query that ranks the endpoint groups
+func RankEndpointGroup(filter bson.M, metric string, order int, limit int) []bson.M {
+	filter["availability"] = bson.M{"$gte": 0}
+	filter["reliability"] = bson.M{"$gte": 0}
+	// Mongo aggregation pipeline
+	// Select the daily results of the endpoint groups as the daily pipeline does
+	// Group them by name, supergroup and report and for each group find
+	// availability = average(availability)
+	// reliability = average(reliability)
+	// Sort and limit them according to the ranking
+	query := DailyEndpointGroup(filter)
+	query = append(query, bson.M{
+		"$group": bson.M{
+			"_id": bson.M{
+				"name":       "$name",
+				"supergroup": "$supergroup",
+				"report":     "$report"},
+			"availability": bson.M{"$avg": "$availability"},
+			"reliability":  bson.M{"$avg": "$reliability"}},
+	})
+
+	return append(query, rankStages(metric, order, limit)...)
+}
+
+// RankSuperGroup function to build the MongoDB aggregation query that ranks the groups of endpoint groups
+func RankSuperGroup(filter bson.M, metric string, order int, limit int) []bson.M {
+	filter["availability"] = bson.M{"$gte": 0}
+	filter["reliability"] = bson.M{"$gte": 0}
+	// Mongo aggregation pipeline
+	// Find the weighted daily results of the supergroups as the daily pipeline does
+	// Group them by supergroup and report and for each group find
+	// availability = average(availability)
+	// reliability = average(reliability)
+	// Sort and limit them according to the ranking
+	query := DailySuperGroup(filter, "none")
+	query = append(query, bson.M{
+		"$group": bson.M{
+			"_id": bson.M{
+				"name":   "$supergroup",
+				"report": "$report"},
+			"availability": bson.M{"$avg": "$availability"},
+			"reliability":  bson.M{"$avg": "$reliability"}},
+	})
+
+	return append(query, rankStages(metric, order, limit)...)
+}
+
+// rankStages returns the stages that sort the averaged results by the metric and keep the top of them
+func rankStages(metric string, order int, limit int) []bson.M {
+	return []bson.M{
+		{"$project": bson.M{
+			"name":         "$_id.name",
+			"supergroup":   "$_id.supergroup",
+			"report":       "$_id.report",
+			"availability": 1,
+			"reliability":  1},
+		},
+		{"$sort": bson.D{
+			{metric, order},
+			{"name", 1}},
+		},
+		{"$limit": limit},
+	}
+}
@@ -25,29 +25,29 @@ package results
 import (
 	"encoding/xml"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2"
+	"github.com/ARGOeu/argo-web-api/app/operationsProfiles"
 	"github.com/ARGOeu/argo-web-api/app/reports"
 	"github.com/ARGOeu/argo-web-api/respond"
 )
 
 type list []interface{}
 
-var customForm []string
-
-func init() {
-	customForm = []string{"20060102", "2006-01-02T15:04:05Z"} //{"Format that is returned by the database" , "Format that will be used in the generated report"}
-}
-
 const zuluForm = "2006-01-02T15:04:05Z"
 const ymdForm = "20060102"
 
+// periodForm matches the subset of ISO 8601 durations accepted by the period url parameter (e.g. P14D, P2W, P3M, P1Y)
+var periodForm = regexp.MustCompile(`^P([1-9][0-9]*)([DWMY])$`)
+
 type basicQuery struct {
 	Name         string                 `bson:"name"`
 	Granularity  string                 `bson:"-"`
+	Period       string                 `bson:"-"` // ISO 8601 duration of a custom bucket
 	Format       string                 `bson:"-"`
 	StartTime    string                 `bson:"-"` // UTC time in W3C format
 	EndTime      string                 `bson:"-"` // UTC time in W3C format
@@ -55,6 +55,20 @@ type basicQuery struct {
 	StartTimeInt int                    `bson:"start_time"`
 	EndTimeInt   int                    `bson:"end_time"`
 	Vars         map[string]string      `bson:"-"`
+	periodLength int
+	periodUnit   string
+
+	CompareStartTime    string `bson:"-"` // UTC time in W3C format of the time span to compare with
+	CompareEndTime      string `bson:"-"` // UTC time in W3C format of the time span to compare with
+	CompareStartTimeInt int    `bson:"-"`
+	CompareEndTimeInt   int    `bson:"-"`
+}
+
+// resultFormat holds the per request information needed to render the results
+type resultFormat struct {
+	ContentType string
+	DBLayout    string // Format of the dates that are returned by the database
+	Layout      string // Format of the dates that will be used in the generated report
 }
 
 type serviceFlavorResultQuery struct {
@@ -62,11 +76,78 @@ type serviceFlavorResultQuery struct {
 	EndpointGroup string `bson:"supergroup"`
 }
 
+type endpointResultQuery struct {
+	basicQuery
+	EndpointGroup string `bson:"supergroup"`
+	Service       string `bson:"service"`
+}
+
 type endpointGroupResultQuery struct {
 	basicQuery
 	Group string `bson:"supergroup"`
 }
 
+type superGroupResultQuery struct {
+	basicQuery
+	Weighting string `bson:"-"`
+}
+
+type rankResultQuery struct {
+	basicQuery
+	Order  string `bson:"-"`
+	Metric string `bson:"-"`
+	Limit  string `bson:"-"`
+	limit  int
+}
+
+type serviceTypeResultQuery struct {
+	basicQuery
+	Breakdown string `bson:"-"`
+	breakdown bool
+}
+
+type adhocResultQuery struct {
+	superGroupResultQuery
+	Groups []string `bson:"-"`
+}
+
+type computedResultQuery struct {
+	basicQuery
+	start time.Time
+	end   time.Time
+}
+
+// AdhocGroup is the request body describing an ad-hoc group of endpoint groups
+type AdhocGroup struct {
+	Name      string   `json:"name"`
+	Groups    []string `json:"groups"`
+	Weighting string   `json:"weighting"`
+}
+
+// StatusInterface for mongodb object exchanging of the status timelines
+type StatusInterface struct {
+	Timestamp string `bson:"timestamp"`
+	Status    string `bson:"status"`
+}
+
+// stateDurations holds the seconds that a status timeline spent in each class of states
+type stateDurations struct {
+	up      int64
+	unknown int64
+	down    int64
+	total   int64
+}
+
+// stateClass is the kind of time that a state of a status timeline counts as
+type stateClass int
+
+const (
+	failureTime stateClass = iota
+	upTime
+	unknownTime
+	downTime
+)
+
 // ReportInterface for mongodb object exchanging
 // type ReportInterface struct {
 // 	Name              string `bson:"name"`
@@ -89,6 +170,21 @@ type ServiceFlavorInterface struct {
 	SuperGroup   string  `bson:"supergroup"`
 }
 
+// EndpointInterface for mongodb object exchanging
+type EndpointInterface struct {
+	Name         string  `bson:"name"`
+	Service      string  `bson:"service"`
+	Report       string  `bson:"report"`
+	Date         string  `bson:"date"`
+	Type         string  `bson:"type"`
+	Up           float64 `bson:"up"`
+	Down         float64 `bson:"down"`
+	Unknown      float64 `bson:"unknown"`
+	Availability float64 `bson:"availability"`
+	Reliability  float64 `bson:"reliability"`
+	SuperGroup   string  `bson:"supergroup"`
+}
+
 // EndpointGroupInterface for mongodb object exchanging
 type EndpointGroupInterface struct {
 	Name         string  `bson:"name"`
@@ -104,6 +200,14 @@ type EndpointGroupInterface struct {
 	SuperGroup   string  `bson:"supergroup"`
 }
 
+// RankInterface for mongodb object exchanging
+type RankInterface struct {
+	Name         string  `bson:"name"`
+	SuperGroup   string  `bson:"supergroup"`
+	Availability float64 `bson:"availability"`
+	Reliability  float64 `bson:"reliability"`
+}
+
 // SuperGroupInterface for mongodb object exchanging
 type SuperGroupInterface struct {
 	Report       string  `bson:"report"`
@@ -127,6 +231,48 @@ type Availability struct {
 	Unknown      string   `xml:"unknown,attr,omitempty" json:"unknown,omitempty"`
 	Uptime       string   `xml:"uptime,attr,omitempty" json:"uptime,omitempty"`
 	Downtime     string   `xml:"downtime,attr,omitempty" json:"downtime,omitempty"`
+	Compliant    string   `xml:"compliant,attr,omitempty" json:"compliant,omitempty"`
+	AvailMargin  string   `xml:"availability_margin,attr,omitempty" json:"availability_margin,omitempty"`
+	RelMargin    string   `xml:"reliability_margin,attr,omitempty" json:"reliability_margin,omitempty"`
+}
+
+// Comparison struct for formating xml/json
+type Comparison struct {
+	XMLName  xml.Name `xml:"group" json:"-"`
+	Name     string   `xml:"name,attr" json:"name"`
+	Type     string   `xml:"type,attr" json:"type"`
+	Group    string   `xml:"group,attr,omitempty" json:"group,omitempty"`
+	Current  *Window  `xml:"current,omitempty" json:"current,omitempty"`
+	Compared *Window  `xml:"compared,omitempty" json:"compared,omitempty"`
+	Delta    *Window  `xml:"delta,omitempty" json:"delta,omitempty"`
+}
+
+// Window struct for formating xml/json
+type Window struct {
+	Start        string `xml:"start,attr,omitempty" json:"start,omitempty"`
+	End          string `xml:"end,attr,omitempty" json:"end,omitempty"`
+	Availability string `xml:"availability,attr" json:"availability"`
+	Reliability  string `xml:"reliability,attr" json:"reliability"`
+}
+
+// comparisonRow holds the results of an item in one of the compared time spans
+type comparisonRow struct {
+	name         string
+	group        string
+	date         string
+	availability float64
+	reliability  float64
+}
+
+// RankedGroup struct for formating xml/json
+type RankedGroup struct {
+	XMLName      xml.Name `xml:"group" json:"-"`
+	Rank         int      `xml:"rank,attr" json:"rank"`
+	Name         string   `xml:"name,attr" json:"name"`
+	Type         string   `xml:"type,attr" json:"type"`
+	SuperGroup   string   `xml:"supergroup,attr,omitempty" json:"supergroup,omitempty"`
+	Availability string   `xml:"availability,attr" json:"availability"`
+	Reliability  string   `xml:"reliability,attr" json:"reliability"`
 }
 
 // ServiceFlavor struct for formating xml/json
@@ -137,6 +283,59 @@ type ServiceFlavor struct {
 	Availability []interface{} `json:"results"`
 }
 
+// ServiceType struct for formating xml/json
+type ServiceType struct {
+	XMLName      xml.Name      `xml:"group" json:"-"`
+	Name         string        `xml:"name,attr" json:"name"`
+	Type         string        `xml:"type,attr" json:"type"`
+	Availability []interface{} `json:"results"`
+	Groups       []interface{} `json:"groups,omitempty"`
+}
+
+// Endpoint struct for formating xml/json
+type Endpoint struct {
+	XMLName      xml.Name      `xml:"group" json:"-"`
+	Name         string        `xml:"name,attr" json:"name"`
+	Type         string        `xml:"type,attr" json:"type"`
+	Availability []interface{} `json:"results"`
+}
+
+// EndpointService struct for formating xml/json
+type EndpointService struct {
+	XMLName   xml.Name      `xml:"group" json:"-"`
+	Name      string        `xml:"name,attr" json:"name"`
+	Type      string        `xml:"type,attr" json:"type"`
+	Endpoints []interface{} `json:"endpoints"`
+}
+
+// EndpointServiceGroup struct for formating xml/json
+type EndpointServiceGroup struct {
+	XMLName  xml.Name      `xml:"group" json:"-"`
+	Name     string        `xml:"name,attr" json:"name"`
+	Type     string        `xml:"type,attr" json:"type"`
+	Services []interface{} `json:"services"`
+}
+
+// ComputedAvailability struct for formating xml/json
+type ComputedAvailability struct {
+	XMLName      xml.Name `xml:"results" json:"-"`
+	StartTime    string   `xml:"start_time,attr" json:"start_time"`
+	EndTime      string   `xml:"end_time,attr" json:"end_time"`
+	Availability string   `xml:"availability,attr" json:"availability"`
+	Reliability  string   `xml:"reliability,attr" json:"reliability"`
+	Up           string   `xml:"up_seconds,attr" json:"up_seconds"`
+	Unknown      string   `xml:"unknown_seconds,attr" json:"unknown_seconds"`
+	Down         string   `xml:"down_seconds,attr" json:"down_seconds"`
+}
+
+// ComputedGroup struct for formating xml/json
+type ComputedGroup struct {
+	XMLName xml.Name      `xml:"group" json:"-"`
+	Name    string        `xml:"name,attr" json:"name"`
+	Type    string        `xml:"type,attr" json:"type"`
+	Results []interface{} `json:"results"`
+}
+
 // Group struct for formating xml/json
 type Group struct {
 	XMLName      xml.Name      `xml:"group" json:"-"`
@@ -179,13 +378,32 @@ type ErrorResponse respond.ErrorResponse
 func (query *basicQuery) Validate(db *mgo.Database) []ErrorResponse {
 	errs := []ErrorResponse{}
 	query.Granularity = strings.ToLower(query.Granularity)
-	if query.Granularity == "" {
+	if query.Period != "" {
+		match := periodForm.FindStringSubmatch(strings.ToUpper(query.Period))
+		if match == nil {
+			errs = append(errs, ErrorResponse{
+				Message: "Wrong Period",
+				Code:    "400",
+				Details: fmt.Sprintf("%s is not accepted as period parameter, please provide an ISO 8601 duration like P14D, P2W, P3M or P1Y", query.Period),
+			})
+		} else if query.Granularity != "" && query.Granularity != "custom" {
+			errs = append(errs, ErrorResponse{
+				Message: "Conflicting Granularity",
+				Code:    "400",
+				Details: "Please provide either the granularity or the period parameter, not both",
+			})
+		} else {
+			query.Granularity = "custom"
+			query.periodLength, _ = strconv.Atoi(match[1])
+			query.periodUnit = match[2]
+		}
+	} else if query.Granularity == "" {
 		query.Granularity = "daily"
-	} else if query.Granularity != "daily" && query.Granularity != "monthly" {
+	} else if query.Granularity != "daily" && query.Granularity != "weekly" && query.Granularity != "monthly" && query.Granularity != "yearly" {
 		errs = append(errs, ErrorResponse{
 			Message: "Wrong Granularity",
 			Code:    "400",
-			Details: fmt.Sprintf("%s is not accepted as granularity parameter, please provide either daily or monthly", query.Granularity),
+			Details: fmt.Sprintf("%s is not accepted as granularity parameter, please provide either daily, weekly, monthly or yearly", query.Granularity),
 		})
 	}
 
@@ -220,6 +438,50 @@ func (query *basicQuery) Validate(db *mgo.Database) []ErrorResponse {
 		}
 	}
 
+	if query.CompareStartTime != "" || query.CompareEndTime != "" {
+		if query.StartTime == "" || query.EndTime == "" || query.CompareStartTime == "" || query.CompareEndTime == "" {
+			errs = append(errs, ErrorResponse{
+				Message: "Incomplete comparison",
+				Code:    "400",
+				Details: "Please use start_time, end_time, compare_start_time and compare_end_time url parameters to compare two time spans",
+			})
+		} else {
+			cs, cserr := time.Parse(zuluForm, query.CompareStartTime)
+			if cserr != nil {
+				errs = append(errs, ErrorResponse{
+					Message: "compare_start_time parsing error",
+					Code:    "400",
+					Details: fmt.Sprintf("Error parsing date string %s please use zulu format like %s", query.CompareStartTime, zuluForm),
+				})
+			}
+			query.CompareStartTimeInt, _ = strconv.Atoi(cs.Format(ymdForm))
+			ce, ceerr := time.Parse(zuluForm, query.CompareEndTime)
+			if ceerr != nil {
+				errs = append(errs, ErrorResponse{
+					Message: "compare_end_time parsing error",
+					Code:    "400",
+					Details: fmt.Sprintf("Error parsing date string %s please use zulu format like %s", query.CompareEndTime, zuluForm),
+				})
+			}
+			query.CompareEndTimeInt, _ = strconv.Atoi(ce.Format(ymdForm))
+			if query.CompareStartTimeInt <= query.EndTimeInt && query.CompareEndTimeInt >= query.StartTimeInt {
+				errs = append(errs, ErrorResponse{
+					Message: "Overlapping time spans",
+					Code:    "400",
+					Details: "The time span to compare with must not overlap with the one of start_time and end_time",
+				})
+			}
+		}
+	}
+
+	if (query.Granularity == "weekly" || query.Granularity == "custom") && (query.StartTime == "" || query.EndTime == "") {
+		errs = append(errs, ErrorResponse{
+			Message: "Incomplete time span",
+			Code:    "400",
+			Details: "Please use both start_time and end_time url parameters when requesting weekly or custom period results",
+		})
+	}
+
 	if query.Report.DetermineGroupType(query.Vars["group_type"]) == "endpoint" {
 		query.Vars["lgroup_type"] = query.Vars["group_type"]
 		query.Vars["lgroup_name"] = query.Vars["group_name"]
@@ -247,3 +509,298 @@ func (query *basicQuery) Validate(db *mgo.Database) []ErrorResponse {
 
 	return errs
 }
+
+// Validate checks the common query parameters along with the weighting of the endpoint groups
+func (query *superGroupResultQuery) Validate(db *mgo.Database) []ErrorResponse {
+	errs := query.basicQuery.Validate(db)
+	query.Weighting = strings.ToLower(query.Weighting)
+	if query.Weighting == "" {
+		query.Weighting = "none"
+	} else if query.Weighting != "factors" && query.Weighting != "equal" && query.Weighting != "none" {
+		errs = append(errs, ErrorResponse{
+			Message: "Wrong Weighting",
+			Code:    "400",
+			Details: fmt.Sprintf("%s is not accepted as weighting parameter, please provide either factors, equal or none", query.Weighting),
+		})
+	}
+
+	return errs
+}
+
+// Validate checks the common query parameters along with the request for the per endpoint group breakdown
+func (query *serviceTypeResultQuery) Validate(db *mgo.Database) []ErrorResponse {
+	errs := query.basicQuery.Validate(db)
+	if query.Breakdown != "" {
+		breakdown, err := strconv.ParseBool(query.Breakdown)
+		if err != nil {
+			errs = append(errs, ErrorResponse{
+				Message: "Wrong Breakdown",
+				Code:    "400",
+				Details: fmt.Sprintf("%s is not accepted as breakdown parameter, please provide either true or false", query.Breakdown),
+			})
+		}
+		query.breakdown = breakdown
+	}
+
+	return errs
+}
+
+// Validate checks the common query parameters and the weighting along with the endpoint groups
+// that make up the ad-hoc group
+func (query *adhocResultQuery) Validate(db *mgo.Database) []ErrorResponse {
+	errs := query.superGroupResultQuery.Validate(db)
+	if query.Name == "" {
+		query.Name = "adhoc"
+	}
+	if len(query.Groups) == 0 {
+		errs = append(errs, ErrorResponse{
+			Message: "No Endpoint Groups",
+			Code:    "400",
+			Details: "Please provide the names of the endpoint groups that make up the ad-hoc group",
+		})
+	}
+
+	return errs
+}
+
+// Validate checks the common query parameters and requires a time span of second precision
+// on an endpoint group, as computed results are based on the endpoint group status timelines
+func (query *computedResultQuery) Validate(db *mgo.Database) []ErrorResponse {
+	errs := query.basicQuery.Validate(db)
+
+	if query.StartTime == "" || query.EndTime == "" {
+		errs = append(errs, ErrorResponse{
+			Message: "Incomplete time span",
+			Code:    "400",
+			Details: "Please use both start_time and end_time url parameters when requesting computed results",
+		})
+	} else {
+		start, serr := time.Parse(zuluForm, query.StartTime)
+		end, eerr := time.Parse(zuluForm, query.EndTime)
+		if serr == nil && eerr == nil && !end.After(start) {
+			errs = append(errs, ErrorResponse{
+				Message: "Wrong time span",
+				Code:    "400",
+				Details: "The end_time must be later than the start_time",
+			})
+		}
+		query.start, query.end = start, end
+	}
+
+	if _, exists := query.Vars["lgroup_type"]; !exists && query.Report.DetermineGroupType(query.Vars["group_type"]) == "group" {
+		errs = append(errs, ErrorResponse{
+			Message: "Endpoint Group type not in report",
+			Code:    "400",
+			Details: fmt.Sprintf("Computed results are only available for endpoint groups. Try using %s instead",
+				query.Report.GetEndpointGroupType()),
+		})
+	}
+
+	return errs
+}
+
+// Validate checks the common query parameters along with the order, metric and limit of the ranking
+func (query *rankResultQuery) Validate(db *mgo.Database) []ErrorResponse {
+	errs := query.basicQuery.Validate(db)
+
+	query.Order = strings.ToLower(query.Order)
+	if query.Order == "" {
+		query.Order = "asc"
+	} else if query.Order != "asc" && query.Order != "desc" {
+		errs = append(errs, ErrorResponse{
+			Message: "Wrong Order",
+			Code:    "400",
+			Details: fmt.Sprintf("%s is not accepted as order parameter, please provide either asc or desc", query.Order),
+		})
+	}
+
+	query.Metric = strings.ToLower(query.Metric)
+	if query.Metric == "" {
+		query.Metric = "availability"
+	} else if query.Metric != "availability" && query.Metric != "reliability" {
+		errs = append(errs, ErrorResponse{
+			Message: "Wrong Metric",
+			Code:    "400",
+			Details: fmt.Sprintf("%s is not accepted as metric parameter, please provide either availability or reliability", query.Metric),
+		})
+	}
+
+	query.limit = 10
+	if query.Limit != "" {
+		limit, err := strconv.Atoi(query.Limit)
+		if err != nil || limit < 1 {
+			errs = append(errs, ErrorResponse{
+				Message: "Wrong Limit",
+				Code:    "400",
+				Details: fmt.Sprintf("%s is not accepted as limit parameter, please provide a positive integer", query.Limit),
+			})
+		}
+		query.limit = limit
+	}
+
+	return errs
+}
+
+// comparing reports whether the results of two time spans are requested to be compared
+func (query basicQuery) comparing() bool {
+	return query.CompareStartTime != ""
+}
+
+// resultFormat returns the formatting context that the views need to render the results of the query
+func (query basicQuery) resultFormat() resultFormat {
+	format := resultFormat{
+		ContentType: query.Format,
+		DBLayout:    "20060102",
+		Layout:      "2006-01-02",
+	}
+	switch query.Granularity {
+	case "monthly":
+		format.DBLayout, format.Layout = "200601", "2006-01"
+	case "yearly":
+		format.DBLayout, format.Layout = "2006", "2006"
+	}
+	return format
+}
+
+// bucketStarts returns the first day (YYYYMMDD) of every weekly or custom period
+// bucket that overlaps the query's time span. Buckets are anchored at start_time
+// apart from weekly ones which always begin on a monday. Month and year buckets
+// anchored past the 28th start on the last day of the shorter months.
+func (query basicQuery) bucketStarts() []int {
+	start, _ := time.Parse(ymdForm, strconv.Itoa(query.StartTimeInt))
+	end, _ := time.Parse(ymdForm, strconv.Itoa(query.EndTimeInt))
+
+	years, months, days := 0, 0, 7
+	if query.Granularity == "weekly" {
+		start = start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+	} else {
+		switch query.periodUnit {
+		case "D":
+			days = query.periodLength
+		case "W":
+			days = 7 * query.periodLength
+		case "M":
+			months, days = query.periodLength, 0
+		case "Y":
+			years, days = query.periodLength, 0
+		}
+	}
+
+	starts := []int{}
+	for i := 0; ; i++ {
+		t := start.AddDate(0, 0, i*days)
+		if years > 0 || months > 0 {
+			t = addMonths(start, i*(12*years+months))
+		}
+		if t.After(end) {
+			break
+		}
+		day, _ := strconv.Atoi(t.Format(ymdForm))
+		starts = append(starts, day)
+	}
+	return starts
+}
+
+// addMonths moves a date by a number of months keeping its day of the month, clamped to the
+// last day of the target month instead of overflowing into the next one like time.AddDate does
+func addMonths(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	day := t.Day()
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// classifyStates maps every state declared by an operations profile to the kind of time it counts as. Apart
+// from the default states, the states the profile ranks as less severe than both its unknown and missing states
+// count as up time and the ones ranked as more severe as failures. An error is returned when the profile can not
+// rank its states, declares a state ranked between its unknown and missing states or no up state at all
+func classifyStates(profile operationsProfiles.OpsProfile) (map[string]stateClass, error) {
+	severities, err := profile.Severities()
+	if err != nil {
+		return nil, err
+	}
+
+	classes := map[string]stateClass{
+		profile.Defaults.Down:    downTime,
+		profile.Defaults.Unknown: unknownTime,
+		profile.Defaults.Missing: unknownTime,
+	}
+	unknown, missing := severities[profile.Defaults.Unknown], severities[profile.Defaults.Missing]
+	up := false
+	for _, state := range profile.AvailStates {
+		if _, ok := classes[state]; ok {
+			continue
+		}
+		switch {
+		case severities[state] < unknown && severities[state] < missing:
+			classes[state] = upTime
+			up = true
+		case severities[state] > unknown && severities[state] > missing:
+			classes[state] = failureTime
+		default:
+			return nil, fmt.Errorf("The operations profile %s declares the state %s which can not be classified", profile.Name, state)
+		}
+	}
+	if !up {
+		return nil, fmt.Errorf("The operations profile %s does not declare any state that counts as up time", profile.Name)
+	}
+	return classes, nil
+}
+
+// computeDurations walks a status timeline sorted by timestamp and sums the seconds spent in
+// up, unknown and down states between start and end. Entries preceding start only set the
+// state at the beginning of the window and any time not covered by the timeline counts as missing
+func computeDurations(timeline []StatusInterface, start time.Time, end time.Time, missing string, classes map[string]stateClass) stateDurations {
+	durations := stateDurations{total: int64(end.Sub(start).Seconds())}
+	state, from := missing, start
+
+	for _, row := range timeline {
+		ts, err := time.Parse(zuluForm, row.Timestamp)
+		if err != nil {
+			continue
+		}
+		if ts.After(end) {
+			break
+		}
+		if ts.After(from) {
+			durations.add(classes[state], int64(ts.Sub(from).Seconds()))
+			from = ts
+		}
+		state = row.Status
+	}
+	durations.add(classes[state], int64(end.Sub(from).Seconds()))
+
+	return durations
+}
+
+// add sums the seconds spent in a state to the kind of time the state counts as
+func (durations *stateDurations) add(class stateClass, seconds int64) {
+	switch class {
+	case downTime:
+		durations.down += seconds
+	case unknownTime:
+		durations.unknown += seconds
+	case upTime:
+		durations.up += seconds
+	default:
+		// Failures and states missing from the operations profile only count towards the total time
+	}
+}
+
+// availability is computed in the same way as the aggregation pipelines compute it from the daily results
+func (durations stateDurations) availability() float64 {
+	up, unknown := durations.fraction(durations.up), durations.fraction(durations.unknown)
+	return up / (1.00000001 - unknown) * 100
+}
+
+// reliability is computed in the same way as the aggregation pipelines compute it from the daily results
+func (durations stateDurations) reliability() float64 {
+	up, unknown, down := durations.fraction(durations.up), durations.fraction(durations.unknown), durations.fraction(durations.down)
+	return up / ((1.00000001 - unknown) - down) * 100
+}
+
+func (durations stateDurations) fraction(seconds int64) float64 {
+	return float64(seconds) / float64(durations.total)
+}
@@ -26,13 +26,16 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/ARGOeu/argo-web-api/app/reports"
+	"github.com/ARGOeu/argo-web-api/respond"
 )
 
-func createServiceFlavorResultView(results []ServiceFlavorInterface, report reports.MongoInterface, format string) ([]byte, error) {
+func createServiceFlavorResultView(results []ServiceFlavorInterface, report reports.MongoInterface, format resultFormat) ([]byte, error) {
 
 	docRoot := &root{}
 
@@ -44,7 +47,7 @@ func createServiceFlavorResultView(results []ServiceFlavorInterface, report repo
 	// we iterate through the results struct array
 	// keeping only the value of each row
 	for _, row := range results {
-		timestamp, _ := time.Parse(customForm[0], fmt.Sprint(row.Date))
+		timestamp, _ := time.Parse(format.DBLayout, fmt.Sprint(row.Date))
 		//if new superGroup value does not match the previous superGroup value
 		//we create a new superGroup in the xml
 		if prevServiceFlavorGroup != row.SuperGroup {
@@ -68,25 +71,166 @@ func createServiceFlavorResultView(results []ServiceFlavorInterface, report repo
 		}
 		//we append the new availability values
 		serviceFlavor.Availability = append(serviceFlavor.Availability,
-			&Availability{
-				Timestamp:    timestamp.Format(customForm[1]),
+			evaluateTarget(&Availability{
+				Timestamp:    timestamp.Format(format.Layout),
 				Availability: fmt.Sprintf("%g", row.Availability),
 				Reliability:  fmt.Sprintf("%g", row.Reliability),
 				Unknown:      fmt.Sprintf("%g", row.Unknown),
 				Uptime:       fmt.Sprintf("%g", row.Up),
 				Downtime:     fmt.Sprintf("%g", row.Down),
-			})
+			}, report, "service", row.Name, row.Availability, row.Reliability))
+	}
+
+	if strings.ToLower(format.ContentType) == "application/json" {
+		return json.MarshalIndent(docRoot, " ", "  ")
+	} else if strings.ToLower(format.ContentType) == "text/csv" {
+		return respond.MarshalCSV(docRoot)
+	} else {
+		return xml.MarshalIndent(docRoot, " ", "  ")
+	}
+
+}
+
+func createServiceTypeView(results []ServiceFlavorInterface, breakdown []ServiceFlavorInterface, report reports.MongoInterface, format resultFormat) ([]byte, error) {
+
+	docRoot := &root{}
+
+	prevServiceType := ""
+	serviceTypes := map[string]*ServiceType{}
+	serviceType := &ServiceType{}
+
+	// we iterate through the aggregated results
+	// keeping a single group per service type
+	for _, row := range results {
+		timestamp, _ := time.Parse(format.DBLayout, fmt.Sprint(row.Date))
+		if prevServiceType != row.Name {
+			prevServiceType = row.Name
+			serviceType = &ServiceType{
+				Name: row.Name,
+				Type: "service",
+			}
+			serviceTypes[row.Name] = serviceType
+			docRoot.Result = append(docRoot.Result, serviceType)
+		}
+		serviceType.Availability = append(serviceType.Availability,
+			evaluateTarget(&Availability{
+				Timestamp:    timestamp.Format(format.Layout),
+				Availability: fmt.Sprintf("%g", row.Availability),
+				Reliability:  fmt.Sprintf("%g", row.Reliability),
+				Unknown:      fmt.Sprintf("%g", row.Unknown),
+				Uptime:       fmt.Sprintf("%g", row.Up),
+				Downtime:     fmt.Sprintf("%g", row.Down),
+			}, report, "service", row.Name, row.Availability, row.Reliability))
+	}
+
+	// the breakdown is sorted by endpoint group so we look up the service type of every row
+	prevGroup := ""
+	group := &Group{}
+	for _, row := range breakdown {
+		timestamp, _ := time.Parse(format.DBLayout, fmt.Sprint(row.Date))
+		serviceType, ok := serviceTypes[row.Name]
+		if !ok {
+			continue
+		}
+		if prevGroup != row.Name+"/"+row.SuperGroup {
+			prevGroup = row.Name + "/" + row.SuperGroup
+			group = &Group{
+				Name: row.SuperGroup,
+				Type: report.GetEndpointGroupType(), // Endpoint groups are parents of SFs
+			}
+			serviceType.Groups = append(serviceType.Groups, group)
+		}
+		group.Availability = append(group.Availability,
+			evaluateTarget(&Availability{
+				Timestamp:    timestamp.Format(format.Layout),
+				Availability: fmt.Sprintf("%g", row.Availability),
+				Reliability:  fmt.Sprintf("%g", row.Reliability),
+				Unknown:      fmt.Sprintf("%g", row.Unknown),
+				Uptime:       fmt.Sprintf("%g", row.Up),
+				Downtime:     fmt.Sprintf("%g", row.Down),
+			}, report, "service", row.Name, row.Availability, row.Reliability))
 	}
 
-	if strings.ToLower(format) == "application/json" {
+	if strings.ToLower(format.ContentType) == "application/json" {
 		return json.MarshalIndent(docRoot, " ", "  ")
+	} else if strings.ToLower(format.ContentType) == "text/csv" {
+		return respond.MarshalCSV(docRoot)
 	} else {
 		return xml.MarshalIndent(docRoot, " ", "  ")
 	}
 
 }
 
-func createEndpointGroupResultView(results []EndpointGroupInterface, report reports.MongoInterface, format string) ([]byte, error) {
+func createEndpointResultView(results []EndpointInterface, report reports.MongoInterface, format resultFormat) ([]byte, error) {
+
+	docRoot := &root{}
+
+	prevEndpointGroup := ""
+	prevService := ""
+	prevEndpoint := ""
+	endpoint := &Endpoint{}
+	service := &EndpointService{}
+	endpointGroup := &EndpointServiceGroup{}
+
+	// we iterate through the results struct array
+	// keeping only the value of each row
+	for _, row := range results {
+		timestamp, _ := time.Parse(format.DBLayout, fmt.Sprint(row.Date))
+		//if new endpoint group value does not match the previous endpoint group value
+		//we create a new endpoint group in the xml
+		if prevEndpointGroup != row.SuperGroup {
+			prevEndpointGroup = row.SuperGroup
+			endpointGroup = &EndpointServiceGroup{
+				Name: row.SuperGroup,
+				Type: report.GetEndpointGroupType(), // Endpoint groups are parents of services
+			}
+			docRoot.Result = append(docRoot.Result, endpointGroup)
+			prevService = ""
+		}
+		//if new service does not match the previous service value
+		//we create a new service entry in the xml/json output
+		if prevService != row.Service {
+			prevService = row.Service
+			service = &EndpointService{
+				Name: row.Service,
+				Type: "service",
+			}
+			endpointGroup.Services = append(endpointGroup.Services, service)
+			prevEndpoint = ""
+		}
+		//if new endpoint does not match the previous endpoint value
+		//we create a new endpoint entry in the xml/json output
+		if prevEndpoint != row.Name {
+			prevEndpoint = row.Name
+			endpoint = &Endpoint{
+				Name: row.Name,
+				Type: "endpoint",
+			}
+			service.Endpoints = append(service.Endpoints, endpoint)
+		}
+		//we append the new availability values
+		endpoint.Availability = append(endpoint.Availability,
+			evaluateTarget(&Availability{
+				Timestamp:    timestamp.Format(format.Layout),
+				Availability: fmt.Sprintf("%g", row.Availability),
+				Reliability:  fmt.Sprintf("%g", row.Reliability),
+				Unknown:      fmt.Sprintf("%g", row.Unknown),
+				Uptime:       fmt.Sprintf("%g", row.Up),
+				Downtime:     fmt.Sprintf("%g", row.Down),
+			}, report, "endpoint", row.Name, row.Availability, row.Reliability))
+	}
+
+	if strings.ToLower(format.ContentType) == "application/json" {
+		return json.MarshalIndent(docRoot, " ", "  ")
+	} else if strings.ToLower(format.ContentType) == "text/csv" {
+		return respond.MarshalCSV(docRoot)
+	} else {
+		return xml.MarshalIndent(docRoot, " ", "  ")
+	}
+
+}
+
+func createEndpointGroupResultView(results []EndpointGroupInterface, report reports.MongoInterface, format resultFormat) ([]byte, error) {
 
 	docRoot := &root{}
 
@@ -99,7 +243,7 @@ func createEndpointGroupResultView(results []EndpointGroupInterface, report repo
 	// keeping only the value of each row
 
 	for _, row := range results {
-		timestamp, _ := time.Parse(customForm[0], fmt.Sprint(row.Date))
+		timestamp, _ := time.Parse(format.DBLayout, fmt.Sprint(row.Date))
 		//if new superGroup value does not match the previous superGroup value
 		//we create a new superGroup in the xml
 		if prevSuperGroup != row.SuperGroup {
@@ -123,24 +267,26 @@ func createEndpointGroupResultView(results []EndpointGroupInterface, report repo
 		}
 		//we append the new availability values
 		endpointGroup.Availability = append(endpointGroup.Availability,
-			&Availability{
-				Timestamp:    timestamp.Format(customForm[1]),
+			evaluateTarget(&Availability{
+				Timestamp:    timestamp.Format(format.Layout),
 				Availability: fmt.Sprintf("%g", row.Availability),
 				Reliability:  fmt.Sprintf("%g", row.Reliability),
 				Unknown:      fmt.Sprintf("%g", row.Unknown),
 				Uptime:       fmt.Sprintf("%g", row.Up),
 				Downtime:     fmt.Sprintf("%g", row.Down),
-			})
+			}, report, report.GetEndpointGroupType(), row.Name, row.Availability, row.Reliability))
 	}
-	if strings.ToLower(format) == "application/json" {
+	if strings.ToLower(format.ContentType) == "application/json" {
 		return json.MarshalIndent(docRoot, " ", "  ")
+	} else if strings.ToLower(format.ContentType) == "text/csv" {
+		return respond.MarshalCSV(docRoot)
 	} else {
 		return xml.MarshalIndent(docRoot, " ", "  ")
 	}
 
 }
 
-func createSuperGroupView(results []SuperGroupInterface, report reports.MongoInterface, format string) ([]byte, error) {
+func createSuperGroupView(results []SuperGroupInterface, report reports.MongoInterface, groupType string, format resultFormat) ([]byte, error) {
 
 	docRoot := &root{}
 
@@ -150,7 +296,7 @@ func createSuperGroupView(results []SuperGroupInterface, report reports.MongoInt
 	// we iterate through the results struct array
 	// keeping only the value of each row
 	for _, row := range results {
-		timestamp, _ := time.Parse(customForm[0], row.Date)
+		timestamp, _ := time.Parse(format.DBLayout, row.Date)
 
 		//if new superGroup does not match the previous superGroup value
 		//we create a new superGroup entry in the xml
@@ -158,25 +304,212 @@ func createSuperGroupView(results []SuperGroupInterface, report reports.MongoInt
 			prevSuperGroup = row.SuperGroup
 			superGroup = &SuperGroup{
 				Name: row.SuperGroup,
-				Type: report.GetGroupType(),
+				Type: groupType,
 			}
 			docRoot.Result = append(docRoot.Result, superGroup)
 		}
 		//we append the new availability values
 		superGroup.Results = append(superGroup.Results,
-			&Availability{
-				Timestamp:    timestamp.Format(customForm[1]),
+			evaluateTarget(&Availability{
+				Timestamp:    timestamp.Format(format.Layout),
 				Availability: fmt.Sprintf("%g", row.Availability),
-				Reliability:  fmt.Sprintf("%g", row.Reliability)})
+				Reliability:  fmt.Sprintf("%g", row.Reliability)},
+				report, groupType, row.SuperGroup, row.Availability, row.Reliability))
+	}
+
+	if strings.ToLower(format.ContentType) == "application/json" {
+		return json.MarshalIndent(docRoot, " ", "  ")
+	} else if strings.ToLower(format.ContentType) == "text/csv" {
+		return respond.MarshalCSV(docRoot)
+	} else {
+		return xml.MarshalIndent(docRoot, " ", "  ")
+	}
+}
+
+func createRankView(results []RankInterface, groupType string, format resultFormat) ([]byte, error) {
+
+	docRoot := &root{}
+
+	// the results are already ranked so we just keep their order
+	for i, row := range results {
+		docRoot.Result = append(docRoot.Result,
+			&RankedGroup{
+				Rank:         i + 1,
+				Name:         row.Name,
+				Type:         groupType,
+				SuperGroup:   row.SuperGroup,
+				Availability: fmt.Sprintf("%g", row.Availability),
+				Reliability:  fmt.Sprintf("%g", row.Reliability),
+			})
+	}
+
+	if strings.ToLower(format.ContentType) == "application/json" {
+		return json.MarshalIndent(docRoot, " ", "  ")
+	} else if strings.ToLower(format.ContentType) == "text/csv" {
+		return respond.MarshalCSV(docRoot)
+	} else {
+		return xml.MarshalIndent(docRoot, " ", "  ")
+	}
+}
+
+func createViolationView(results []EndpointGroupInterface, groupType string, report reports.MongoInterface, format resultFormat) ([]byte, error) {
+
+	docRoot := &root{}
+
+	prevGroup := ""
+	group := &Group{}
+
+	// we iterate through the results struct array
+	// keeping only the values that violate the SLA target of each group
+	for _, row := range results {
+		timestamp, _ := time.Parse(format.DBLayout, fmt.Sprint(row.Date))
+		entry := evaluateTarget(&Availability{
+			Timestamp:    timestamp.Format(format.Layout),
+			Availability: fmt.Sprintf("%g", row.Availability),
+			Reliability:  fmt.Sprintf("%g", row.Reliability),
+		}, report, groupType, row.Name, row.Availability, row.Reliability)
+
+		if entry.Compliant != "false" {
+			continue
+		}
+		//if new group does not match the previous group value
+		//we create a new group entry in the xml
+		if prevGroup != row.Name {
+			prevGroup = row.Name
+			group = &Group{
+				Name: row.Name,
+				Type: groupType,
+			}
+			docRoot.Result = append(docRoot.Result, group)
+		}
+		group.Availability = append(group.Availability, entry)
+	}
+
+	if strings.ToLower(format.ContentType) == "application/json" {
+		return json.MarshalIndent(docRoot, " ", "  ")
+	} else if strings.ToLower(format.ContentType) == "text/csv" {
+		return respond.MarshalCSV(docRoot)
+	} else {
+		return xml.MarshalIndent(docRoot, " ", "  ")
+	}
+}
+
+func createComparisonView(rows []comparisonRow, groupType string, query basicQuery, format resultFormat) ([]byte, error) {
+
+	docRoot := &root{}
+
+	prevGroup := ""
+	prevName := ""
+	comparison := &Comparison{}
+	var current, compared *comparisonRow
+
+	// the time spans are labeled by the day they start
+	currentLabel := strconv.Itoa(query.StartTimeInt)
+	currentStart, currentEnd := spanDates(query.StartTimeInt, query.EndTimeInt)
+	comparedStart, comparedEnd := spanDates(query.CompareStartTimeInt, query.CompareEndTimeInt)
+
+	// we iterate through the results struct array
+	// keeping both time spans of every item together
+	for i, row := range rows {
+		if prevGroup != row.group || prevName != row.name {
+			prevGroup, prevName = row.group, row.name
+			comparison = &Comparison{
+				Name:  row.name,
+				Type:  groupType,
+				Group: row.group,
+			}
+			docRoot.Result = append(docRoot.Result, comparison)
+			current, compared = nil, nil
+		}
+
+		window := &Window{
+			Availability: fmt.Sprintf("%g", row.availability),
+			Reliability:  fmt.Sprintf("%g", row.reliability),
+		}
+		if row.date == currentLabel {
+			window.Start, window.End = currentStart, currentEnd
+			comparison.Current = window
+			current = &rows[i]
+		} else {
+			window.Start, window.End = comparedStart, comparedEnd
+			comparison.Compared = window
+			compared = &rows[i]
+		}
+
+		// the delta is computed when both time spans of the item are found
+		if current != nil && compared != nil {
+			comparison.Delta = &Window{
+				Availability: fmt.Sprintf("%g", roundMargin(current.availability-compared.availability)),
+				Reliability:  fmt.Sprintf("%g", roundMargin(current.reliability-compared.reliability)),
+			}
+		}
 	}
 
-	if strings.ToLower(format) == "application/json" {
+	if strings.ToLower(format.ContentType) == "application/json" {
 		return json.MarshalIndent(docRoot, " ", "  ")
+	} else if strings.ToLower(format.ContentType) == "text/csv" {
+		return respond.MarshalCSV(docRoot)
 	} else {
 		return xml.MarshalIndent(docRoot, " ", "  ")
 	}
 }
 
+func createComputedView(durations stateDurations, query computedResultQuery, format resultFormat) ([]byte, error) {
+
+	docRoot := &root{}
+
+	group := &ComputedGroup{
+		Name: query.Vars["lgroup_name"],
+		Type: query.Vars["lgroup_type"],
+	}
+	group.Results = append(group.Results, &ComputedAvailability{
+		StartTime:    query.start.Format(zuluForm),
+		EndTime:      query.end.Format(zuluForm),
+		Availability: fmt.Sprintf("%g", durations.availability()),
+		Reliability:  fmt.Sprintf("%g", durations.reliability()),
+		Up:           strconv.FormatInt(durations.up, 10),
+		Unknown:      strconv.FormatInt(durations.unknown, 10),
+		Down:         strconv.FormatInt(durations.down, 10),
+	})
+	docRoot.Result = append(docRoot.Result, group)
+
+	if strings.ToLower(format.ContentType) == "application/json" {
+		return json.MarshalIndent(docRoot, " ", "  ")
+	} else if strings.ToLower(format.ContentType) == "text/csv" {
+		return respond.MarshalCSV(docRoot)
+	} else {
+		return xml.MarshalIndent(docRoot, " ", "  ")
+	}
+}
+
+// spanDates formats the first and the last day of a time span
+func spanDates(start int, end int) (string, string) {
+	s, _ := time.Parse(ymdForm, strconv.Itoa(start))
+	e, _ := time.Parse(ymdForm, strconv.Itoa(end))
+	return s.Format("2006-01-02"), e.Format("2006-01-02")
+}
+
+// evaluateTarget marks an availability entry as compliant or not with the SLA target that the report
+// defines for the group and adds the margins of its values to the target
+func evaluateTarget(entry *Availability, report reports.MongoInterface, groupType string, group string,
+	availability float64, reliability float64) *Availability {
+
+	target, found := report.GetTarget(groupType, group)
+	if !found {
+		return entry
+	}
+
+	entry.Compliant = strconv.FormatBool(availability >= target.Availability && reliability >= target.Reliability)
+	entry.AvailMargin = fmt.Sprintf("%g", roundMargin(availability-target.Availability))
+	entry.RelMargin = fmt.Sprintf("%g", roundMargin(reliability-target.Reliability))
+	return entry
+}
+
+// roundMargin rounds a margin to five decimal digits to hide the noise of the float subtraction
+func roundMargin(margin float64) float64 {
+	return math.Floor(margin*100000+0.5) / 100000
+}
+
 func createErrorMessage(message string, format string) ([]byte, error) {
 
 	output := []byte("message placeholder")
@@ -186,8 +519,143 @@ func createErrorMessage(message string, format string) ([]byte, error) {
 	docRoot.Message = message
 	if strings.EqualFold(format, "application/json") {
 		output, err = json.MarshalIndent(docRoot, " ", "  ")
+	} else if strings.EqualFold(format, "text/csv") {
+		output, err = respond.MarshalCSV(docRoot)
 	} else {
 		output, err = xml.MarshalIndent(docRoot, " ", "  ")
 	}
 	return output, err
 }
+
+// CSVRecords flattens the results into one record per timestamp of every group, service or endpoint
+func (docRoot *root) CSVRecords() [][]string {
+	records := [][]string{}
+	for _, item := range docRoot.Result {
+		switch group := item.(type) {
+		case *SuperGroup:
+			if len(group.Results) > 0 {
+				if len(records) == 0 {
+					records = append(records, append([]string{"type", "name", "timestamp", "availability", "reliability"}, complianceHeader...))
+				}
+				for _, result := range group.Results {
+					a := result.(*Availability)
+					records = append(records, append([]string{group.Type, group.Name, a.Timestamp, a.Availability, a.Reliability},
+						complianceRecord(a)...))
+				}
+				continue
+			}
+			if len(records) == 0 {
+				records = append(records, append([]string{"group_type", "group", "type", "name"}, availabilityHeader...))
+			}
+			for _, item := range group.Endpoints {
+				endpointGroup := item.(*Group)
+				for _, result := range endpointGroup.Availability {
+					records = append(records, append([]string{group.Type, group.Name, endpointGroup.Type, endpointGroup.Name},
+						availabilityRecord(result.(*Availability))...))
+				}
+			}
+		case *ServiceFlavorGroup:
+			if len(records) == 0 {
+				records = append(records, append([]string{"group_type", "group", "type", "name"}, availabilityHeader...))
+			}
+			for _, item := range group.ServiceFlavor {
+				service := item.(*ServiceFlavor)
+				for _, result := range service.Availability {
+					records = append(records, append([]string{group.Type, group.Name, service.Type, service.Name},
+						availabilityRecord(result.(*Availability))...))
+				}
+			}
+		case *ServiceType:
+			if len(records) == 0 {
+				records = append(records, append([]string{"type", "name", "group_type", "group"}, availabilityHeader...))
+			}
+			for _, result := range group.Availability {
+				records = append(records, append([]string{group.Type, group.Name, "", ""}, availabilityRecord(result.(*Availability))...))
+			}
+			for _, item := range group.Groups {
+				endpointGroup := item.(*Group)
+				for _, result := range endpointGroup.Availability {
+					records = append(records, append([]string{group.Type, group.Name, endpointGroup.Type, endpointGroup.Name},
+						availabilityRecord(result.(*Availability))...))
+				}
+			}
+		case *Group:
+			if len(records) == 0 {
+				records = append(records, append([]string{"type", "name"}, availabilityHeader...))
+			}
+			for _, result := range group.Availability {
+				records = append(records, append([]string{group.Type, group.Name}, availabilityRecord(result.(*Availability))...))
+			}
+		case *ComputedGroup:
+			if len(records) == 0 {
+				records = append(records, []string{"type", "name", "start_time", "end_time", "availability", "reliability",
+					"up_seconds", "unknown_seconds", "down_seconds"})
+			}
+			for _, result := range group.Results {
+				c := result.(*ComputedAvailability)
+				records = append(records, []string{group.Type, group.Name, c.StartTime, c.EndTime, c.Availability, c.Reliability,
+					c.Up, c.Unknown, c.Down})
+			}
+		case *Comparison:
+			if len(records) == 0 {
+				records = append(records, []string{"type", "name", "group", "start", "end", "availability", "reliability",
+					"compare_start", "compare_end", "compare_availability", "compare_reliability",
+					"availability_delta", "reliability_delta"})
+			}
+			current, compared, delta := windowOrEmpty(group.Current), windowOrEmpty(group.Compared), windowOrEmpty(group.Delta)
+			records = append(records, []string{group.Type, group.Name, group.Group,
+				current.Start, current.End, current.Availability, current.Reliability,
+				compared.Start, compared.End, compared.Availability, compared.Reliability,
+				delta.Availability, delta.Reliability})
+		case *RankedGroup:
+			if len(records) == 0 {
+				records = append(records, []string{"rank", "type", "name", "supergroup", "availability", "reliability"})
+			}
+			records = append(records, []string{strconv.Itoa(group.Rank), group.Type, group.Name, group.SuperGroup,
+				group.Availability, group.Reliability})
+		case *EndpointServiceGroup:
+			if len(records) == 0 {
+				records = append(records, append([]string{"group_type", "group", "service", "type", "name"}, availabilityHeader...))
+			}
+			for _, item := range group.Services {
+				service := item.(*EndpointService)
+				for _, item := range service.Endpoints {
+					endpoint := item.(*Endpoint)
+					for _, result := range endpoint.Availability {
+						records = append(records, append([]string{group.Type, group.Name, service.Name, endpoint.Type, endpoint.Name},
+							availabilityRecord(result.(*Availability))...))
+					}
+				}
+			}
+		}
+	}
+	return records
+}
+
+// windowOrEmpty returns an empty window for the missing windows of a comparison
+func windowOrEmpty(window *Window) *Window {
+	if window == nil {
+		return &Window{}
+	}
+	return window
+}
+
+// availabilityHeader holds the columns of the availability values in csv records
+var availabilityHeader = []string{"timestamp", "availability", "reliability", "unknown", "uptime", "downtime",
+	"compliant", "availability_margin", "reliability_margin"}
+
+// complianceHeader holds the columns of the SLA compliance in csv records
+var complianceHeader = availabilityHeader[6:]
+
+func availabilityRecord(a *Availability) []string {
+	return append([]string{a.Timestamp, a.Availability, a.Reliability, a.Unknown, a.Uptime, a.Downtime}, complianceRecord(a)...)
+}
+
+func complianceRecord(a *Availability) []string {
+	return []string{a.Compliant, a.AvailMargin, a.RelMargin}
+}
+
+// CSVRecords renders the error message as a single csv record
+func (docRoot *errorMessage) CSVRecords() [][]string {
+	return [][]string{{"message"}, {docRoot.Message}}
+}
@@ -382,6 +382,367 @@ func (suite *endpointGroupAvailabilityTestSuite) TestListAllEndpointGroupAvailab
 
 }
 
+// TestListRankedEndpointGroupAvailability test if endpoint groups are ranked correctly
+func (suite *endpointGroupAvailabilityTestSuite) TestListRankedEndpointGroupAvailability() {
+
+	request, _ := http.NewRequest("GET", "/api/v2/results/Report_A/SITE/rank?start_time=2015-06-20T12:00:00Z&end_time=2015-06-23T23:00:00Z", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/xml")
+
+	response := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	rankedAvailabilityXML := ` <root>
+   <group rank="1" name="ST02" type="SITE" supergroup="GROUP_A" availability="56.75" reliability="50.5"></group>
+   <group rank="2" name="ST01" type="SITE" supergroup="GROUP_A" availability="83.35" reliability="77.3"></group>
+ </root>`
+
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	// Compare the expected and actual xml response
+	suite.Equal(rankedAvailabilityXML, response.Body.String(), "Response body mismatch")
+
+	request, _ = http.NewRequest("GET", "/api/v2/results/Report_A/SITE/rank?start_time=2015-06-20T12:00:00Z&end_time=2015-06-23T23:00:00Z&order=desc&metric=reliability&limit=1", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/json")
+
+	response = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	rankedAvailabilityJSON := `{
+   "root": [
+     {
+       "rank": 1,
+       "name": "ST01",
+       "type": "SITE",
+       "supergroup": "GROUP_A",
+       "availability": "83.35",
+       "reliability": "77.3"
+     }
+   ]
+ }`
+
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	// Compare the expected and actual json response
+	suite.Equal(rankedAvailabilityJSON, response.Body.String(), "Response body mismatch")
+
+	request, _ = http.NewRequest("GET", "/api/v2/results/Report_A/SITE/rank?start_time=2015-06-20T12:00:00Z&end_time=2015-06-23T23:00:00Z&limit=0", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/json")
+
+	response = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	// Check that we must have a 400 bad request code
+	suite.Equal(400, response.Code, "Incorrect HTTP response code")
+	suite.Contains(response.Body.String(), "Wrong Limit", "Response body mismatch")
+
+}
+
+// TestListEndpointGroupAvailabilityTargets test if results are evaluated against the SLA targets of the report
+func (suite *endpointGroupAvailabilityTestSuite) TestListEndpointGroupAvailabilityTargets() {
+
+	session, err := mgo.Dial(suite.cfg.MongoDB.Host)
+	if err != nil {
+		panic(err)
+	}
+	defer session.Close()
+
+	// Add SLA targets for all the sites and a stricter one for ST01
+	session.DB(suite.tenantDbConf.Db).C("reports").Update(
+		bson.M{"info.name": "Report_A"},
+		bson.M{"$set": bson.M{"sla_targets": []bson.M{
+			bson.M{"type": "SITE", "availability": 80, "reliability": 50},
+			bson.M{"type": "SITE", "group": "ST01", "availability": 90, "reliability": 60},
+		}}})
+
+	request, _ := http.NewRequest("GET", "/api/v2/results/Report_A/SITE?start_time=2015-06-20T12:00:00Z&end_time=2015-06-23T23:00:00Z&granularity=daily", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/xml")
+
+	response := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	endpointGroupAvailabilityXML := ` <root>
+   <group name="GROUP_A" type="GROUP">
+     <group name="ST01" type="SITE">
+       <results timestamp="2015-06-22" availability="66.7" reliability="54.6" unknown="0" uptime="1" downtime="0" compliant="false" availability_margin="-23.3" reliability_margin="-5.4"></results>
+       <results timestamp="2015-06-23" availability="100" reliability="100" unknown="0" uptime="1" downtime="0" compliant="true" availability_margin="10" reliability_margin="40"></results>
+     </group>
+     <group name="ST02" type="SITE">
+       <results timestamp="2015-06-22" availability="70" reliability="45" unknown="0" uptime="1" downtime="0" compliant="false" availability_margin="-10" reliability_margin="-5"></results>
+       <results timestamp="2015-06-23" availability="43.5" reliability="56" unknown="0" uptime="1" downtime="0" compliant="false" availability_margin="-36.5" reliability_margin="6"></results>
+     </group>
+   </group>
+ </root>`
+
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	// Compare the expected and actual xml response
+	suite.Equal(endpointGroupAvailabilityXML, response.Body.String(), "Response body mismatch")
+
+	request, _ = http.NewRequest("GET", "/api/v2/results/Report_A/SITE/violations?start_time=2015-06-20T12:00:00Z&end_time=2015-06-23T23:00:00Z", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/json")
+
+	response = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	violationsJSON := `{
+   "root": [
+     {
+       "name": "ST01",
+       "type": "SITE",
+       "results": [
+         {
+           "timestamp": "2015-06-22",
+           "availability": "66.7",
+           "reliability": "54.6",
+           "compliant": "false",
+           "availability_margin": "-23.3",
+           "reliability_margin": "-5.4"
+         }
+       ]
+     },
+     {
+       "name": "ST02",
+       "type": "SITE",
+       "results": [
+         {
+           "timestamp": "2015-06-22",
+           "availability": "70",
+           "reliability": "45",
+           "compliant": "false",
+           "availability_margin": "-10",
+           "reliability_margin": "-5"
+         },
+         {
+           "timestamp": "2015-06-23",
+           "availability": "43.5",
+           "reliability": "56",
+           "compliant": "false",
+           "availability_margin": "-36.5",
+           "reliability_margin": "6"
+         }
+       ]
+     }
+   ]
+ }`
+
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	// Compare the expected and actual json response
+	suite.Equal(violationsJSON, response.Body.String(), "Response body mismatch")
+
+}
+
+// TestListEndpointGroupAvailabilityComparison test if the results of two time spans are compared correctly
+func (suite *endpointGroupAvailabilityTestSuite) TestListEndpointGroupAvailabilityComparison() {
+
+	request, _ := http.NewRequest("GET", "/api/v2/results/Report_A/SITE?start_time=2015-06-23T00:00:00Z&end_time=2015-06-23T23:59:59Z&compare_start_time=2015-06-22T00:00:00Z&compare_end_time=2015-06-22T23:59:59Z", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/xml")
+
+	response := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	comparisonXML := ` <root>
+   <group name="ST01" type="SITE" group="GROUP_A">
+     <current start="2015-06-23" end="2015-06-23" availability="100" reliability="100"></current>
+     <compared start="2015-06-22" end="2015-06-22" availability="66.7" reliability="54.6"></compared>
+     <delta availability="33.3" reliability="45.4"></delta>
+   </group>
+   <group name="ST02" type="SITE" group="GROUP_A">
+     <current start="2015-06-23" end="2015-06-23" availability="43.5" reliability="56"></current>
+     <compared start="2015-06-22" end="2015-06-22" availability="70" reliability="45"></compared>
+     <delta availability="-26.5" reliability="11"></delta>
+   </group>
+ </root>`
+
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	// Compare the expected and actual xml response
+	suite.Equal(comparisonXML, response.Body.String(), "Response body mismatch")
+
+	request, _ = http.NewRequest("GET", "/api/v2/results/Report_A/SITE/ST01?start_time=2015-06-22T00:00:00Z&end_time=2015-06-23T23:59:59Z&compare_start_time=2015-06-23T00:00:00Z&compare_end_time=2015-06-24T23:59:59Z", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/json")
+
+	response = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	// Check that we must have a 400 bad request code
+	suite.Equal(400, response.Code, "Incorrect HTTP response code")
+	suite.Contains(response.Body.String(), "Overlapping time spans", "Response body mismatch")
+
+}
+
+// TestListComputedEndpointGroupAvailability tests if results are computed correctly from the status timelines
+func (suite *endpointGroupAvailabilityTestSuite) TestListComputedEndpointGroupAvailability() {
+
+	session, _ := mgo.Dial(suite.cfg.MongoDB.Host)
+	defer session.Close()
+
+	session.DB(suite.tenantDbConf.Db).C("operations_profiles").Insert(bson.M{
+		"id":               "6ac7d684-1f8e-4a02-a502-720e8f11e50b",
+		"name":             "ARGO_MON_OPS",
+		"available_states": []string{"OK", "WARNING", "UNKNOWN", "MISSING", "CRITICAL", "DOWNTIME"},
+		"defaults": bson.M{
+			"down":    "DOWNTIME",
+			"missing": "MISSING",
+			"unknown": "UNKNOWN",
+		},
+		"operations": []bson.M{
+			bson.M{
+				"name": "AND",
+				"truth_table": []bson.M{
+					bson.M{"a": "OK", "b": "OK", "x": "OK"},
+					bson.M{"a": "OK", "b": "WARNING", "x": "WARNING"},
+					bson.M{"a": "OK", "b": "UNKNOWN", "x": "UNKNOWN"},
+					bson.M{"a": "OK", "b": "MISSING", "x": "MISSING"},
+					bson.M{"a": "OK", "b": "CRITICAL", "x": "CRITICAL"},
+					bson.M{"a": "OK", "b": "DOWNTIME", "x": "DOWNTIME"},
+					bson.M{"a": "WARNING", "b": "WARNING", "x": "WARNING"},
+					bson.M{"a": "WARNING", "b": "UNKNOWN", "x": "UNKNOWN"},
+					bson.M{"a": "WARNING", "b": "MISSING", "x": "MISSING"},
+					bson.M{"a": "WARNING", "b": "CRITICAL", "x": "CRITICAL"},
+					bson.M{"a": "WARNING", "b": "DOWNTIME", "x": "DOWNTIME"},
+					bson.M{"a": "UNKNOWN", "b": "UNKNOWN", "x": "UNKNOWN"},
+					bson.M{"a": "UNKNOWN", "b": "MISSING", "x": "MISSING"},
+					bson.M{"a": "UNKNOWN", "b": "CRITICAL", "x": "CRITICAL"},
+					bson.M{"a": "UNKNOWN", "b": "DOWNTIME", "x": "DOWNTIME"},
+					bson.M{"a": "MISSING", "b": "MISSING", "x": "MISSING"},
+					bson.M{"a": "MISSING", "b": "CRITICAL", "x": "CRITICAL"},
+					bson.M{"a": "MISSING", "b": "DOWNTIME", "x": "DOWNTIME"},
+					bson.M{"a": "CRITICAL", "b": "CRITICAL", "x": "CRITICAL"},
+					bson.M{"a": "CRITICAL", "b": "DOWNTIME", "x": "CRITICAL"},
+					bson.M{"a": "DOWNTIME", "b": "DOWNTIME", "x": "DOWNTIME"},
+				},
+			},
+		},
+	})
+	session.DB(suite.tenantDbConf.Db).C("reports").Update(
+		bson.M{"info.name": "Report_A"},
+		bson.M{"$push": bson.M{"profiles": bson.M{"type": "operations", "name": "ARGO_MON_OPS"}}})
+
+	c := session.DB(suite.tenantDbConf.Db).C("status_endpoint_groups")
+	c.Insert(
+		bson.M{"report": "eba61a9e-22e9-4521-9e47-ecaa4a49436", "date_integer": 20150621,
+			"timestamp": "2015-06-21T22:00:00Z", "endpoint_group": "ST01", "status": "OK"},
+		bson.M{"report": "eba61a9e-22e9-4521-9e47-ecaa4a49436", "date_integer": 20150622,
+			"timestamp": "2015-06-22T01:00:00Z", "endpoint_group": "ST01", "status": "CRITICAL"},
+		bson.M{"report": "eba61a9e-22e9-4521-9e47-ecaa4a49436", "date_integer": 20150622,
+			"timestamp": "2015-06-22T03:00:00Z", "endpoint_group": "ST01", "status": "DOWNTIME"},
+		bson.M{"report": "eba61a9e-22e9-4521-9e47-ecaa4a49436", "date_integer": 20150622,
+			"timestamp": "2015-06-22T04:00:00Z", "endpoint_group": "ST01", "status": "UNKNOWN"},
+		bson.M{"report": "eba61a9e-22e9-4521-9e47-ecaa4a49436", "date_integer": 20150622,
+			"timestamp": "2015-06-22T05:00:00Z", "endpoint_group": "ST01", "status": "OK"},
+		bson.M{"report": "eba61a9e-22e9-4521-9e47-ecaa4a49436", "date_integer": 20150622,
+			"timestamp": "2015-06-22T09:00:00Z", "endpoint_group": "ST01", "status": "CRITICAL"})
+
+	request, _ := http.NewRequest("GET", "/api/v2/results/Report_A/SITE/ST01/computed?start_time=2015-06-22T00:00:00Z&end_time=2015-06-22T08:00:00Z", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/xml")
+
+	response := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	computedXML := ` <root>
+   <group name="ST01" type="SITE">
+     <results start_time="2015-06-22T00:00:00Z" end_time="2015-06-22T08:00:00Z" availability="57.14285648979593" reliability="66.6666657777778" up_seconds="14400" unknown_seconds="3600" down_seconds="3600"></results>
+   </group>
+ </root>`
+
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	// Compare the expected and actual xml response
+	suite.Equal(computedXML, response.Body.String(), "Response body mismatch")
+
+	request, _ = http.NewRequest("GET", "/api/v2/results/Report_A/GROUP/GROUP_A/computed?start_time=2015-06-22T00:00:00Z&end_time=2015-06-22T08:00:00Z", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/json")
+
+	response = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	// Check that we must have a 400 bad request code
+	suite.Equal(400, response.Code, "Incorrect HTTP response code")
+	suite.Contains(response.Body.String(), "Endpoint Group type not in report", "Response body mismatch")
+
+	request, _ = http.NewRequest("GET", "/api/v2/results/Report_A/SITE/ST01/computed?start_time=2015-06-22T08:00:00Z&end_time=2015-06-22T00:00:00Z", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/json")
+
+	response = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	// Check that we must have a 400 bad request code
+	suite.Equal(400, response.Code, "Incorrect HTTP response code")
+	suite.Contains(response.Body.String(), "Wrong time span", "Response body mismatch")
+
+	// States other than the usual ones are classified by their severity in the operations profile,
+	// DEGRADED is ranked between WARNING and UNKNOWN so it counts as up time
+	session.DB(suite.tenantDbConf.Db).C("operations_profiles").Update(
+		bson.M{"name": "ARGO_MON_OPS"},
+		bson.M{"$push": bson.M{
+			"available_states": "DEGRADED",
+			"operations.0.truth_table": bson.M{"$each": []bson.M{
+				bson.M{"a": "OK", "b": "DEGRADED", "x": "DEGRADED"},
+				bson.M{"a": "WARNING", "b": "DEGRADED", "x": "DEGRADED"},
+				bson.M{"a": "DEGRADED", "b": "DEGRADED", "x": "DEGRADED"},
+				bson.M{"a": "DEGRADED", "b": "UNKNOWN", "x": "UNKNOWN"},
+				bson.M{"a": "DEGRADED", "b": "MISSING", "x": "MISSING"},
+				bson.M{"a": "DEGRADED", "b": "CRITICAL", "x": "CRITICAL"},
+				bson.M{"a": "DEGRADED", "b": "DOWNTIME", "x": "DOWNTIME"},
+			}},
+		}})
+
+	request, _ = http.NewRequest("GET", "/api/v2/results/Report_A/SITE/ST01/computed?start_time=2015-06-22T00:00:00Z&end_time=2015-06-22T08:00:00Z", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/xml")
+
+	response = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	// Compare the expected and actual xml response
+	suite.Equal(computedXML, response.Body.String(), "Response body mismatch")
+
+	// Operations profiles with states ranked between the unknown and missing states are rejected
+	session.DB(suite.tenantDbConf.Db).C("operations_profiles").Update(
+		bson.M{"name": "ARGO_MON_OPS"},
+		bson.M{"$pull": bson.M{"operations.0.truth_table": bson.M{"a": "DEGRADED", "b": "UNKNOWN"}}})
+	session.DB(suite.tenantDbConf.Db).C("operations_profiles").Update(
+		bson.M{"name": "ARGO_MON_OPS"},
+		bson.M{"$push": bson.M{"operations.0.truth_table": bson.M{"a": "DEGRADED", "b": "UNKNOWN", "x": "DEGRADED"}}})
+
+	request, _ = http.NewRequest("GET", "/api/v2/results/Report_A/SITE/ST01/computed?start_time=2015-06-22T00:00:00Z&end_time=2015-06-22T08:00:00Z", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/xml")
+
+	response = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	// Check that we must have a 400 bad request code
+	suite.Equal(400, response.Code, "Incorrect HTTP response code")
+	suite.Contains(response.Body.String(), "The operations profile ARGO_MON_OPS declares the state DEGRADED which can not be classified", "Response body mismatch")
+
+}
+
 // TestListEndpointGroupAvailabilityErrors tests if errors/exceptions are returned correctly
 func (suite *endpointGroupAvailabilityTestSuite) TestListEndpointGroupAvailabilityErrors() {
 
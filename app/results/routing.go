@@ -42,6 +42,36 @@ func HandleSubrouter(s *mux.Router, confhandler *respond.ConfHandler) {
 
 	serviceSubrouter := s.PathPrefix("/{report_name}").Subrouter()
 
+	serviceSubrouter.Path("/adhoc").
+		Methods("POST").
+		Name("Adhoc").
+		Handler(confhandler.Respond(ListAdhocResults))
+
+	serviceSubrouter.Path("/services/{service_type}").
+		Methods("GET").
+		Name("Service Type").
+		Handler(confhandler.Respond(ListServiceTypeResults))
+
+	serviceSubrouter.Path("/{group_type}/{group_name}/{lgroup_type}/{lgroup_name}/services/{service_type}/endpoints/{endpoint_name}").
+		Methods("GET").
+		Name("Endpoint").
+		Handler(confhandler.Respond(ListEndpointResults))
+
+	serviceSubrouter.Path("/{group_type}/{group_name}/{lgroup_type}/{lgroup_name}/services/{service_type}/endpoints").
+		Methods("GET").
+		Name("Endpoint").
+		Handler(confhandler.Respond(ListEndpointResults))
+
+	serviceSubrouter.Path("/{lgroup_type}/{lgroup_name}/services/{service_type}/endpoints/{endpoint_name}").
+		Methods("GET").
+		Name("Endpoint").
+		Handler(confhandler.Respond(ListEndpointResults))
+
+	serviceSubrouter.Path("/{lgroup_type}/{lgroup_name}/services/{service_type}/endpoints").
+		Methods("GET").
+		Name("Endpoint").
+		Handler(confhandler.Respond(ListEndpointResults))
+
 	serviceSubrouter.Path("/{group_type}/{group_name}/{lgroup_type}/{lgroup_name}/services/{service_type}").
 		Methods("GET").
 		Name("Service Flavor").
@@ -63,6 +93,21 @@ func HandleSubrouter(s *mux.Router, confhandler *respond.ConfHandler) {
 		Handler(confhandler.Respond(ListServiceFlavorResults))
 
 	groupSubrouter := s.PathPrefix("/{report_name}/{group_type}").Subrouter()
+	groupSubrouter.
+		Path("/rank").
+		Methods("GET").
+		Name("Rank").
+		Handler(confhandler.Respond(ListRankedResults))
+	groupSubrouter.
+		Path("/violations").
+		Methods("GET").
+		Name("Violations").
+		Handler(confhandler.Respond(ListViolations))
+	groupSubrouter.
+		Path("/{group_name}/computed").
+		Methods("GET").
+		Name("Computed").
+		Handler(confhandler.Respond(ListComputedResults))
 	groupSubrouter.
 		Path("/{group_name}/{lgroup_type}/{lgroup_name}").
 		Methods("GET").
@@ -100,6 +145,8 @@ func routeGroup(r *http.Request, cfg config.Config) (int, http.Header, []byte, e
 	format := r.Header.Get("Accept")
 	if strings.EqualFold(format, "application/json") {
 		contentType = "application/json"
+	} else if strings.EqualFold(format, "text/csv") {
+		contentType = "text/csv"
 	}
 
 	vars := mux.Vars(r)
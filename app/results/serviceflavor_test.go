@@ -23,9 +23,11 @@
 package results
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/github.com/gorilla/mux"
@@ -207,6 +209,40 @@ func (suite *serviceFlavorAvailabilityTestSuite) SetupTest() {
 				},
 			},
 		},
+		bson.M{
+			"report":       "eba61a9e-22e9-4521-9e47-ecaa4a49436",
+			"date":         20150622,
+			"name":         "SF01",
+			"supergroup":   "ST02",
+			"up":           0.96875,
+			"down":         0,
+			"unknown":      0,
+			"availability": 96.875,
+			"reliability":  96.875,
+			"tags": []bson.M{
+				bson.M{
+					"name":  "production",
+					"value": "Y",
+				},
+			},
+		},
+		bson.M{
+			"report":       "eba61a9e-22e9-4521-9e47-ecaa4a49436",
+			"date":         20150623,
+			"name":         "SF01",
+			"supergroup":   "ST02",
+			"up":           0.75,
+			"down":         0.25,
+			"unknown":      0,
+			"availability": 75,
+			"reliability":  100,
+			"tags": []bson.M{
+				bson.M{
+					"name":  "production",
+					"value": "Y",
+				},
+			},
+		},
 		bson.M{
 			"report":       "eba61a9e-22e9-4521-9e47-ecaa4a49436",
 			"date":         20150623,
@@ -465,6 +501,168 @@ func (suite *serviceFlavorAvailabilityTestSuite) TestListServiceFlavorAvailabili
 
 }
 
+// TestListServiceFlavorAvailabilityConcurrent tests that requests of different granularities
+// running in parallel do not affect each other's timestamps
+func (suite *serviceFlavorAvailabilityTestSuite) TestListServiceFlavorAvailabilityConcurrent() {
+
+	expected := map[string][]string{
+		"daily":   {`timestamp="2015-06-22"`, `timestamp="2015-06-23"`},
+		"monthly": {`timestamp="2015-06"`},
+		"yearly":  {`timestamp="2015"`},
+	}
+	granularities := []string{"daily", "monthly", "yearly"}
+
+	type result struct {
+		granularity string
+		code        int
+		body        string
+	}
+
+	results := make(chan result, 30)
+	var wg sync.WaitGroup
+
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func(granularity string) {
+			defer wg.Done()
+			request, _ := http.NewRequest("GET", "/api/v2/results/Report_A/SITE/ST01/services/SF01?start_time=2015-06-22T00:00:00Z&end_time=2015-06-23T23:59:59Z&granularity="+granularity, strings.NewReader(""))
+			request.Header.Set("x-api-key", suite.clientkey)
+			request.Header.Set("Accept", "application/xml")
+
+			response := httptest.NewRecorder()
+			suite.router.ServeHTTP(response, request)
+			results <- result{granularity, response.Code, response.Body.String()}
+		}(granularities[i%len(granularities)])
+	}
+
+	wg.Wait()
+	close(results)
+
+	for res := range results {
+		// Check that we must have a 200 ok code
+		suite.Equal(200, res.code, "Incorrect HTTP response code")
+		// Check that every timestamp is rendered with the layout of the request's granularity
+		suite.Equal(len(expected[res.granularity]), strings.Count(res.body, "timestamp="), "Response body mismatch for "+res.granularity)
+		for _, timestamp := range expected[res.granularity] {
+			suite.Contains(res.body, timestamp, "Response body mismatch for "+res.granularity)
+		}
+	}
+
+}
+
+// TestListServiceFlavorAvailabilityPeriods tests if weekly, yearly and custom period results are returned correctly
+func (suite *serviceFlavorAvailabilityTestSuite) TestListServiceFlavorAvailabilityPeriods() {
+
+	serviceFlavorAvailabilityXML := ` <root>
+   <group name="ST01" type="SITE">
+     <group name="SF01" type="service">
+       <results timestamp="%s" availability="76.26534166743393" reliability="91.61418757296076" unknown="0.00521" uptime="0.75868" downtime="0.166665"></results>
+     </group>
+     <group name="SF02" type="service">
+       <results timestamp="%s" availability="98.43749901562502" reliability="98.43749901562502" unknown="0" uptime="0.984375" downtime="0"></results>
+     </group>
+   </group>
+ </root>`
+
+	periods := []struct {
+		params    string
+		timestamp string
+	}{
+		{"granularity=weekly", "2015-06-22"},
+		{"granularity=yearly", "2015"},
+		{"period=P14D", "2015-06-22"},
+		{"period=P1M", "2015-06-22"},
+	}
+
+	for _, period := range periods {
+		request, _ := http.NewRequest("GET", "/api/v2/results/Report_A/SITE/ST01/services?start_time=2015-06-22T00:00:00Z&end_time=2015-06-23T23:59:59Z&"+period.params, strings.NewReader(""))
+		request.Header.Set("x-api-key", suite.clientkey)
+		request.Header.Set("Accept", "application/xml")
+
+		response := httptest.NewRecorder()
+
+		suite.router.ServeHTTP(response, request)
+
+		// Check that we must have a 200 ok code
+		suite.Equal(200, response.Code, "Incorrect HTTP response code")
+		// Compare the expected and actual xml response
+		suite.Equal(fmt.Sprintf(serviceFlavorAvailabilityXML, period.timestamp, period.timestamp), response.Body.String(), "Response body mismatch")
+	}
+
+	periodErrorXML := `<root>
+ <status>
+  <message>Bad Request</message>
+  <code>400</code>
+ </status>
+ <errors>
+  <error>
+   <message>Wrong Period</message>
+   <code>400</code>
+   <details>P2H is not accepted as period parameter, please provide an ISO 8601 duration like P14D, P2W, P3M or P1Y</details>
+  </error>
+ </errors>
+</root>`
+
+	request, _ := http.NewRequest("GET", "/api/v2/results/Report_A/SITE/ST01/services?start_time=2015-06-22T00:00:00Z&end_time=2015-06-23T23:59:59Z&period=P2H", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/xml")
+
+	response := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	// Check that we must have a 400 bad request code
+	suite.Equal(400, response.Code, "Incorrect HTTP response code")
+	// Compare the expected and actual xml response
+	suite.Equal(periodErrorXML, response.Body.String(), "Response body mismatch")
+
+	// every day of the time span falls in its own bucket
+	request, _ = http.NewRequest("GET", "/api/v2/results/Report_A/SITE/ST01/services?start_time=2015-06-22T00:00:00Z&end_time=2015-06-23T23:59:59Z&period=P1D", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/xml")
+
+	response = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	dailyPeriodXML := ` <root>
+   <group name="ST01" type="SITE">
+     <group name="SF01" type="service">
+       <results timestamp="2015-06-22" availability="98.26399901736002" reliability="98.26399901736002" unknown="0" uptime="0.98264" downtime="0"></results>
+       <results timestamp="2015-06-23" availability="54.03504462463828" reliability="81.48114161552546" unknown="0.01042" uptime="0.53472" downtime="0.33333"></results>
+     </group>
+     <group name="SF02" type="service">
+       <results timestamp="2015-06-22" availability="96.87499903125001" reliability="96.87499903125001" unknown="0" uptime="0.96875" downtime="0"></results>
+       <results timestamp="2015-06-23" availability="99.99999900000002" reliability="99.99999900000002" unknown="0" uptime="1" downtime="0"></results>
+     </group>
+   </group>
+ </root>`
+
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	// Compare the expected and actual xml response
+	suite.Equal(dailyPeriodXML, response.Body.String(), "Response body mismatch")
+
+}
+
+// TestBucketStarts tests that custom period buckets are evenly spread over long time spans
+func (suite *serviceFlavorAvailabilityTestSuite) TestBucketStarts() {
+
+	query := basicQuery{Granularity: "custom", periodLength: 14, periodUnit: "D", StartTimeInt: 20150622, EndTimeInt: 20150720}
+	suite.Equal([]int{20150622, 20150706, 20150720}, query.bucketStarts())
+
+	// month buckets anchored on the last day of a month stay on the last day of the shorter months
+	query = basicQuery{Granularity: "custom", periodLength: 1, periodUnit: "M", StartTimeInt: 20150131, EndTimeInt: 20150505}
+	suite.Equal([]int{20150131, 20150228, 20150331, 20150430}, query.bucketStarts())
+
+	query = basicQuery{Granularity: "custom", periodLength: 1, periodUnit: "Y", StartTimeInt: 20120229, EndTimeInt: 20140301}
+	suite.Equal([]int{20120229, 20130228, 20140228}, query.bucketStarts())
+
+	query = basicQuery{Granularity: "weekly", StartTimeInt: 20150624, EndTimeInt: 20150707}
+	suite.Equal([]int{20150622, 20150629, 20150706}, query.bucketStarts())
+
+}
+
 // TestListServiceFlavorAvailabilityErrors tests if errors/exceptions are returned correctly
 func (suite *serviceFlavorAvailabilityTestSuite) TestListServiceFlavorAvailabilityErrors() {
 
@@ -516,6 +714,51 @@ func (suite *serviceFlavorAvailabilityTestSuite) TestListServiceFlavorAvailabili
 
 }
 
+// TestListServiceTypeAvailability tests if the results of a service type are aggregated across all endpoint groups
+func (suite *serviceFlavorAvailabilityTestSuite) TestListServiceTypeAvailability() {
+
+	request, _ := http.NewRequest("GET", "/api/v2/results/Report_A/services/SF01?start_time=2015-06-22T00:00:00Z&end_time=2015-06-23T23:59:59Z&breakdown=true", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/xml")
+
+	response := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	serviceTypeAvailabilityXML := ` <root>
+   <group name="SF01" type="service">
+     <results timestamp="2015-06-22" availability="97.56949902430502" reliability="97.56949902430502" unknown="0" uptime="0.975695" downtime="0"></results>
+     <results timestamp="2015-06-23" availability="64.57242167118265" reliability="91.35786536735482" unknown="0.00521" uptime="0.64236" downtime="0.291665"></results>
+     <group name="ST01" type="SITE">
+       <results timestamp="2015-06-22" availability="98.26389" reliability="98.26389" unknown="0" uptime="0.98264" downtime="0"></results>
+       <results timestamp="2015-06-23" availability="54.03509" reliability="81.48148" unknown="0.01042" uptime="0.53472" downtime="0.33333"></results>
+     </group>
+     <group name="ST02" type="SITE">
+       <results timestamp="2015-06-22" availability="96.875" reliability="96.875" unknown="0" uptime="0.96875" downtime="0"></results>
+       <results timestamp="2015-06-23" availability="75" reliability="100" unknown="0" uptime="0.75" downtime="0.25"></results>
+     </group>
+   </group>
+ </root>`
+
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	// Compare the expected and actual xml response
+	suite.Equal(serviceTypeAvailabilityXML, response.Body.String(), "Response body mismatch")
+
+	request, _ = http.NewRequest("GET", "/api/v2/results/Report_A/services/SF01?start_time=2015-06-22T00:00:00Z&end_time=2015-06-23T23:59:59Z&breakdown=maybe", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/xml")
+
+	response = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	// Check that we must have a 400 bad request code
+	suite.Equal(400, response.Code, "Incorrect HTTP response code")
+	suite.Contains(response.Body.String(), "Wrong Breakdown", "Response body mismatch")
+
+}
+
 //TearDownTest to tear down every test
 func (suite *serviceFlavorAvailabilityTestSuite) TearDownTest() {
 
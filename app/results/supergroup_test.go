@@ -313,6 +313,13 @@ func (suite *SuperGroupAvailabilityTestSuite) SetupTest() {
 			},
 		})
 
+	// Seed database with the factors of the endpoint groups
+	c = session.DB(suite.tenantDbConf.Db).C("weights")
+	c.Insert(
+		bson.M{"name": "ST01", "hepspec": 100},
+		bson.M{"name": "ST02", "hepspec": 300},
+	)
+
 	c = session.DB(suite.tenantDbConf.Db).C("reports")
 
 	c.Insert(bson.M{
@@ -415,6 +422,229 @@ func (suite *SuperGroupAvailabilityTestSuite) TestListSuperGroupAvailability() {
 
 }
 
+// TestListSuperGroupAvailabilityWeekly test if weekly and custom period results are returned correctly
+func (suite *SuperGroupAvailabilityTestSuite) TestListSuperGroupAvailabilityWeekly() {
+
+	request, _ := http.NewRequest("GET", "/api/v2/results/Report_A/GROUP/GROUP_A?start_time=2015-06-20T12:00:00Z&end_time=2015-06-26T23:00:00Z&granularity=weekly", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/xml")
+
+	response := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	superGroupAvailabilityXML := ` <root>
+   <group name="GROUP_A" type="GROUP">
+     <results timestamp="2015-06-22" availability="71.75110088070457" reliability="65.61389111289031"></results>
+   </group>
+ </root>`
+
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	// Compare the expected and actual xml response
+	suite.Equal(superGroupAvailabilityXML, response.Body.String(), "Response body mismatch")
+
+	request, _ = http.NewRequest("GET", "/api/v2/results/Report_A/GROUP/GROUP_A?start_time=2015-06-20T12:00:00Z&end_time=2015-06-26T23:00:00Z&period=P14D", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/json")
+
+	response = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	superGroupAvailabilityJSON := `{
+   "root": [
+     {
+       "name": "GROUP_A",
+       "type": "GROUP",
+       "results": [
+         {
+           "timestamp": "2015-06-20",
+           "availability": "71.75110088070457",
+           "reliability": "65.61389111289031"
+         }
+       ]
+     }
+   ]
+ }`
+
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	// Compare the expected and actual json response
+	suite.Equal(superGroupAvailabilityJSON, response.Body.String(), "Response body mismatch")
+
+}
+
+// TestListSuperGroupAvailabilityWeighting test if results are weighted according to the weighting parameter
+func (suite *SuperGroupAvailabilityTestSuite) TestListSuperGroupAvailabilityWeighting() {
+
+	request, _ := http.NewRequest("GET", "/api/v2/results/Report_A/GROUP/GROUP_A?start_time=2015-06-20T12:00:00Z&end_time=2015-06-26T23:00:00Z&weighting=factors", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/xml")
+
+	response := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	superGroupAvailabilityXML := ` <root>
+   <group name="GROUP_A" type="GROUP">
+     <results timestamp="2015-06-22" availability="69.175" reliability="47.4"></results>
+     <results timestamp="2015-06-23" availability="57.625" reliability="67"></results>
+   </group>
+ </root>`
+
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	// Compare the expected and actual xml response
+	suite.Equal(superGroupAvailabilityXML, response.Body.String(), "Response body mismatch")
+
+	request, _ = http.NewRequest("GET", "/api/v2/results/Report_A/GROUP/GROUP_A?start_time=2015-06-20T12:00:00Z&end_time=2015-06-26T23:00:00Z&weighting=equal", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/xml")
+
+	response = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	superGroupAvailabilityXML = ` <root>
+   <group name="GROUP_A" type="GROUP">
+     <results timestamp="2015-06-22" availability="68.35" reliability="49.8"></results>
+     <results timestamp="2015-06-23" availability="71.75" reliability="78"></results>
+   </group>
+ </root>`
+
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	// Compare the expected and actual xml response
+	suite.Equal(superGroupAvailabilityXML, response.Body.String(), "Response body mismatch")
+
+	request, _ = http.NewRequest("GET", "/api/v2/results/Report_A/GROUP/GROUP_A?start_time=2015-06-20T12:00:00Z&end_time=2015-06-26T23:00:00Z&weighting=heavy", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/json")
+
+	response = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	// Check that we must have a 400 bad request code
+	suite.Equal(400, response.Code, "Incorrect HTTP response code")
+	suite.Contains(response.Body.String(), "Wrong Weighting", "Response body mismatch")
+
+}
+
+// TestListAdhocAvailability test if an ad-hoc group of endpoint groups is aggregated correctly
+func (suite *SuperGroupAvailabilityTestSuite) TestListAdhocAvailability() {
+
+	request, _ := http.NewRequest("POST", "/api/v2/results/Report_A/adhoc?start_time=2015-06-20T12:00:00Z&end_time=2015-06-26T23:00:00Z&granularity=daily", strings.NewReader(`{"name": "PROJECT_X", "groups": ["ST01", "ST04"], "weighting": "equal"}`))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/xml")
+
+	response := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	adhocAvailabilityXML := ` <root>
+   <group name="PROJECT_X" type="adhoc">
+     <results timestamp="2015-06-22" availability="66.7" reliability="54.6"></results>
+     <results timestamp="2015-06-23" availability="65" reliability="100"></results>
+   </group>
+ </root>`
+
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	// Compare the expected and actual xml response
+	suite.Equal(adhocAvailabilityXML, response.Body.String(), "Response body mismatch")
+
+	request, _ = http.NewRequest("POST", "/api/v2/results/Report_A/adhoc?start_time=2015-06-20T12:00:00Z&end_time=2015-06-26T23:00:00Z", strings.NewReader(`{"groups": []}`))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/json")
+
+	response = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	// Check that we must have a 400 bad request code
+	suite.Equal(400, response.Code, "Incorrect HTTP response code")
+	suite.Contains(response.Body.String(), "No Endpoint Groups", "Response body mismatch")
+
+	request, _ = http.NewRequest("POST", "/api/v2/results/Report_A/adhoc?start_time=2015-06-20T12:00:00Z&end_time=2015-06-26T23:00:00Z", strings.NewReader(`{"groups": ["ST01",`))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/json")
+
+	response = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	// Check that we must have a 400 bad request code
+	suite.Equal(400, response.Code, "Incorrect HTTP response code")
+	suite.Contains(response.Body.String(), "malformed JSON", "Response body mismatch")
+
+}
+
+// TestListSuperGroupAvailabilityCSV test if results are returned correctly as csv
+func (suite *SuperGroupAvailabilityTestSuite) TestListSuperGroupAvailabilityCSV() {
+
+	request, _ := http.NewRequest("GET", "/api/v2/results/Report_A/GROUP/GROUP_A?start_time=2015-06-20T12:00:00Z&end_time=2015-06-26T23:00:00Z&granularity=daily", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "text/csv")
+
+	response := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	superGroupAvailabilityCSV := `type,name,timestamp,availability,reliability,compliant,availability_margin,reliability_margin
+GROUP,GROUP_A,2015-06-22,68.13896116893515,50.413931144915935,,,
+GROUP,GROUP_A,2015-06-23,75.36324059247399,80.8138510808647,,,
+`
+
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	suite.Equal("text/csv; charset=utf-8", response.Header().Get("Content-Type"), "Incorrect content type")
+	// Compare the expected and actual csv response
+	suite.Equal(superGroupAvailabilityCSV, response.Body.String(), "Response body mismatch")
+
+}
+
+// TestListRankedSuperGroupAvailability test if groups of endpoint groups are ranked correctly
+func (suite *SuperGroupAvailabilityTestSuite) TestListRankedSuperGroupAvailability() {
+
+	request, _ := http.NewRequest("GET", "/api/v2/results/Report_A/GROUP/rank?start_time=2015-06-20T12:00:00Z&end_time=2015-06-26T23:00:00Z", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "application/xml")
+
+	response := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	rankedAvailabilityXML := ` <root>
+   <group rank="1" name="GROUP_B" type="GROUP" availability="46.930783242258656" reliability="80"></group>
+   <group rank="2" name="GROUP_A" type="GROUP" availability="71.75110088070457" reliability="65.61389111289031"></group>
+ </root>`
+
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	// Compare the expected and actual xml response
+	suite.Equal(rankedAvailabilityXML, response.Body.String(), "Response body mismatch")
+
+	request, _ = http.NewRequest("GET", "/api/v2/results/Report_A/GROUP/rank?start_time=2015-06-20T12:00:00Z&end_time=2015-06-26T23:00:00Z&order=desc&limit=1", strings.NewReader(""))
+	request.Header.Set("x-api-key", suite.clientkey)
+	request.Header.Set("Accept", "text/csv")
+
+	response = httptest.NewRecorder()
+
+	suite.router.ServeHTTP(response, request)
+
+	rankedAvailabilityCSV := `rank,type,name,supergroup,availability,reliability
+1,GROUP,GROUP_A,,71.75110088070457,65.61389111289031
+`
+
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	// Compare the expected and actual csv response
+	suite.Equal(rankedAvailabilityCSV, response.Body.String(), "Response body mismatch")
+
+}
+
 // TestListAllSuperGroupAvailability test if daily results are returned correctly
 func (suite *SuperGroupAvailabilityTestSuite) TestListAllSuperGroupAvailability() {
 
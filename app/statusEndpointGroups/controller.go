@@ -25,9 +25,15 @@ package statusEndpointGroups
 import (
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/github.com/gorilla/mux"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2"
 	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2/bson"
+	"github.com/ARGOeu/argo-web-api/app/aggregationProfiles"
+	"github.com/ARGOeu/argo-web-api/app/operationsProfiles"
+	"github.com/ARGOeu/argo-web-api/app/reports"
 	"github.com/ARGOeu/argo-web-api/respond"
 	"github.com/ARGOeu/argo-web-api/utils/authentication"
 	"github.com/ARGOeu/argo-web-api/utils/config"
@@ -48,7 +54,7 @@ func ListEndpointGroupTimelines(r *http.Request, cfg config.Config) (int, http.H
 
 	//STANDARD DECLARATIONS END
 
-	contentType, err = respond.ParseAcceptHeader(r)
+	contentType, err = respond.ParseAcceptHeader(r, respond.TimelineContentTypes...)
 	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
 
 	if err != nil {
@@ -62,9 +68,18 @@ func ListEndpointGroupTimelines(r *http.Request, cfg config.Config) (int, http.H
 	vars := mux.Vars(r)
 
 	parsedStart, parsedEnd, errs := respond.ValidateDateRange(urlValues.Get("start_time"), urlValues.Get("end_time"))
+	intervals, end, intervalErrs := respond.ValidateIntervals(urlValues.Get("intervals"), urlValues.Get("end_time"))
+	errs = append(errs, intervalErrs...)
+	bucket, start, bucketErrs := respond.ValidateBucket(urlValues.Get("bucket"), urlValues.Get("start_time"), urlValues.Get("end_time"))
+	errs = append(errs, bucketErrs...)
+	explain, explainErrs := respond.ValidateExplain(urlValues.Get("explain"), urlValues.Get("bucket"), urlValues.Get("end_time"))
+	errs = append(errs, explainErrs...)
+	fillGaps, gapThreshold, gapErrs := respond.ValidateFillGaps(urlValues.Get("fill_gaps"), urlValues.Get("gap_threshold"), urlValues.Get("end_time"))
+	errs = append(errs, gapErrs...)
 	if len(errs) > 0 {
 		code = http.StatusBadRequest
 		output = respond.CreateFailureResponseMessage("Bad Request", "400", errs).MarshalTo(contentType)
+		return code, h, output, nil
 	}
 
 	input := InputParams{
@@ -74,6 +89,14 @@ func ListEndpointGroupTimelines(r *http.Request, cfg config.Config) (int, http.H
 		vars["group_type"],
 		vars["group_name"],
 		contentType,
+		intervals,
+		end,
+		respond.ParseStateFilter(urlValues.Get("status"), urlValues.Get("exclude_status")),
+		bucket,
+		start,
+		explain,
+		fillGaps,
+		gapThreshold,
 	}
 
 	// Call authenticateTenant to check the api key and retrieve
@@ -107,18 +130,616 @@ func ListEndpointGroupTimelines(r *http.Request, cfg config.Config) (int, http.H
 		return code, h, output, err
 	}
 
-	err = metricCollection.Find(prepareQuery(input, reportID)).All(&results)
+	errs = operationsProfiles.ValidateStateFilter(session, tenantDbConfig.Db, input.report, input.states)
+
+	// Buckets and explanations order the states by their severity in the operations profile
+	order := respond.StateOrder{}
+	if input.bucket > 0 || input.explain {
+		var orderErrs []respond.ErrorResponse
+		order, orderErrs = operationsProfiles.GetStateOrder(session, tenantDbConfig.Db, input.report)
+		errs = append(errs, orderErrs...)
+	}
+
+	// Gaps are filled with the missing state of the operations profile
+	missing := ""
+	if input.fillGaps {
+		var gapErrs []respond.ErrorResponse
+		missing, input.gapThreshold, gapErrs = operationsProfiles.GetGapFilling(session, tenantDbConfig.Db, input.report, input.gapThreshold)
+		errs = append(errs, gapErrs...)
+	}
+
+	if len(errs) > 0 {
+		code = http.StatusBadRequest
+		output = respond.CreateFailureResponseMessage("Bad Request", "400", errs).MarshalTo(contentType)
+		return code, h, output, nil
+	}
+
+	err = metricCollection.Find(prepareQuery(input, reportID)).Sort("endpoint_group", "timestamp").All(&results)
 	if err != nil {
 		code = http.StatusInternalServerError
 		return code, h, output, err
 	}
 
-	output, err = createView(results, input) //Render the results into JSON/XML format
+	results = fillMissing(results, input.gapThreshold, missing, input.end)
+	results = filterStates(results, input.states)
+
+	contributors := []ContributorData{}
+	if input.explain {
+		contributors, err = queryContributors(session, tenantDbConfig.Db, input, reportID, results)
+		if err != nil {
+			code = http.StatusInternalServerError
+			return code, h, output, err
+		}
+	}
+
+	output, err = createView(results, contributors, input, order) //Render the results into JSON/XML format
 
 	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
 	return code, h, output, err
 }
 
+// ListGroupTimelines returns the status timelines of the groups of endpoint groups at the top level of the report.
+// The timelines are read from the status_groups collection when they are stored and otherwise they are derived from
+// the timelines of the member endpoint groups using the profile operation of the aggregation profile of the report
+func ListGroupTimelines(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
+
+	//STANDARD DECLARATIONS START
+
+	code := http.StatusOK
+	h := http.Header{}
+	output := []byte("List Group Timelines")
+	err := error(nil)
+	contentType := "application/xml"
+	charset := "utf-8"
+
+	//STANDARD DECLARATIONS END
+
+	contentType, err = respond.ParseAcceptHeader(r, respond.TimelineContentTypes...)
+	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
+
+	if err != nil {
+		code = http.StatusNotAcceptable
+		output, _ = respond.MarshalContent(respond.NotAcceptableContentType, contentType, "", " ")
+		return code, h, output, err
+	}
+
+	// Parse the request into the input
+	urlValues := r.URL.Query()
+	vars := mux.Vars(r)
+
+	parsedStart, parsedEnd, errs := respond.ValidateDateRange(urlValues.Get("start_time"), urlValues.Get("end_time"))
+	intervals, end, intervalErrs := respond.ValidateIntervals(urlValues.Get("intervals"), urlValues.Get("end_time"))
+	errs = append(errs, intervalErrs...)
+	bucket, start, bucketErrs := respond.ValidateBucket(urlValues.Get("bucket"), urlValues.Get("start_time"), urlValues.Get("end_time"))
+	errs = append(errs, bucketErrs...)
+	fillGaps, gapThreshold, gapErrs := respond.ValidateFillGaps(urlValues.Get("fill_gaps"), urlValues.Get("gap_threshold"), urlValues.Get("end_time"))
+	errs = append(errs, gapErrs...)
+	// Groups are not explained, their members are combined by the aggregation profile rather than by status rows
+	if urlValues.Get("explain") != "" {
+		errs = append(errs, respond.ErrorResponse{
+			Message: "Wrong explain",
+			Code:    "400",
+			Details: "Explanations are only available for the timelines of endpoint groups and services",
+		})
+	}
+	if len(errs) > 0 {
+		code = http.StatusBadRequest
+		output = respond.CreateFailureResponseMessage("Bad Request", "400", errs).MarshalTo(contentType)
+		return code, h, output, nil
+	}
+
+	input := InputParams{
+		parsedStart,
+		parsedEnd,
+		vars["report_name"],
+		vars["group_type"],
+		vars["group_name"],
+		contentType,
+		intervals,
+		end,
+		respond.ParseStateFilter(urlValues.Get("status"), urlValues.Get("exclude_status")),
+		bucket,
+		start,
+		false,
+		fillGaps,
+		gapThreshold,
+	}
+
+	// Call authenticateTenant to check the api key and retrieve
+	// the correct tenant db conf
+	tenantDbConfig, err := authentication.AuthenticateTenant(r.Header, cfg)
+	if err != nil {
+		if err.Error() == "Unauthorized" {
+			code = http.StatusUnauthorized
+			out := respond.UnauthorizedMessage
+			output = out.MarshalTo(contentType)
+			return code, h, output, err
+		}
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	session, err := mongo.OpenSession(tenantDbConfig)
+	defer mongo.CloseSession(session)
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	report := reports.MongoInterface{}
+	err = mongo.FindOne(session, tenantDbConfig.Db, "reports", bson.M{"info.name": input.report}, &report)
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	errs = operationsProfiles.ValidateStateFilter(session, tenantDbConfig.Db, input.report, input.states)
+
+	// Buckets order the states by their severity in the operations profile
+	order := respond.StateOrder{}
+	if input.bucket > 0 {
+		var orderErrs []respond.ErrorResponse
+		order, orderErrs = operationsProfiles.GetStateOrder(session, tenantDbConfig.Db, input.report)
+		errs = append(errs, orderErrs...)
+	}
+
+	// Gaps are filled with the missing state of the operations profile
+	missing := ""
+	if input.fillGaps {
+		var gapErrs []respond.ErrorResponse
+		missing, input.gapThreshold, gapErrs = operationsProfiles.GetGapFilling(session, tenantDbConfig.Db, input.report, input.gapThreshold)
+		errs = append(errs, gapErrs...)
+	}
+
+	if len(errs) > 0 {
+		code = http.StatusBadRequest
+		output = respond.CreateFailureResponseMessage("Bad Request", "400", errs).MarshalTo(contentType)
+		return code, h, output, nil
+	}
+
+	filter := bson.M{
+		"date_integer": bson.M{"$gte": input.startTime, "$lte": input.endTime},
+		"report":       report.ID,
+	}
+	if len(input.group) > 0 {
+		filter["group"] = input.group
+	}
+
+	stored := []GroupDataOutput{}
+	err = session.DB(tenantDbConfig.Db).C("status_groups").Find(filter).Sort("group", "timestamp").All(&stored)
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	results := []DataOutput{}
+	for _, row := range stored {
+		results = append(results, DataOutput{
+			Report:        row.Report,
+			Timestamp:     row.Timestamp,
+			EndpointGroup: row.Group,
+			Status:        row.Status,
+			DateInteger:   row.DateInteger,
+		})
+	}
+
+	if len(stored) == 0 {
+		results, errs, err = deriveGroupTimelines(session.DB(tenantDbConfig.Db), report, input)
+		if err != nil {
+			code = http.StatusInternalServerError
+			return code, h, output, err
+		}
+		if len(errs) > 0 {
+			code = http.StatusBadRequest
+			output = respond.CreateFailureResponseMessage("Bad Request", "400", errs).MarshalTo(contentType)
+			return code, h, output, nil
+		}
+	}
+
+	results = fillMissing(results, input.gapThreshold, missing, input.end)
+	results = filterStates(results, input.states)
+
+	output, err = createView(results, nil, input, order) //Render the results into JSON/XML format
+
+	return code, h, output, err
+}
+
+// deriveGroupTimelines combines the timelines of the endpoint groups that belong to every group of the report.
+// The members of the groups are taken from the endpoint group results of the time span
+func deriveGroupTimelines(db *mgo.Database, report reports.MongoInterface, input InputParams) ([]DataOutput, []respond.ErrorResponse, error) {
+	errs := []respond.ErrorResponse{}
+
+	profile := operationsProfiles.OpsProfile{}
+	name, err := reports.GetOperationsProfile(report)
+	if err == nil {
+		err = db.C("operations_profiles").Find(bson.M{"name": name}).One(&profile)
+	}
+	if err != nil {
+		errs = append(errs, respond.ErrorResponse{
+			Message: "Operations profile not found",
+			Code:    "400",
+			Details: fmt.Sprintf("The report %s does not define an existing operations profile", input.report),
+		})
+	}
+
+	aggregation := aggregationProfiles.MongoInterface{}
+	name, err = reports.GetAggregationProfile(report)
+	if err == nil {
+		err = db.C("aggregation_profiles").Find(bson.M{"name": name}).One(&aggregation)
+	}
+	if err != nil {
+		errs = append(errs, respond.ErrorResponse{
+			Message: "Aggregation profile not found",
+			Code:    "400",
+			Details: fmt.Sprintf("The report %s does not define an existing aggregation profile", input.report),
+		})
+	}
+
+	if len(errs) > 0 {
+		return nil, errs, nil
+	}
+
+	filter := bson.M{
+		"date":   bson.M{"$gte": input.startTime, "$lte": input.endTime},
+		"report": report.ID,
+	}
+	if len(input.group) > 0 {
+		filter["supergroup"] = input.group
+	}
+
+	members := []MemberOutput{}
+	err = db.C("endpoint_group_ar").Pipe([]bson.M{
+		{"$match": filter},
+		{"$group": bson.M{"_id": bson.M{"supergroup": "$supergroup", "name": "$name"}}},
+		{"$sort": bson.D{{"_id.supergroup", 1}, {"_id.name", 1}}},
+	}).All(&members)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := []string{}
+	for _, member := range members {
+		names = append(names, member.ID.Name)
+	}
+
+	rows := []DataOutput{}
+	err = db.C("status_endpoint_groups").Find(bson.M{
+		"date_integer":   bson.M{"$gte": input.startTime, "$lte": input.endTime},
+		"report":         report.ID,
+		"endpoint_group": bson.M{"$in": names},
+	}).Sort("endpoint_group", "timestamp").All(&rows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	timelines := map[string][]DataOutput{}
+	for _, row := range rows {
+		timelines[row.EndpointGroup] = append(timelines[row.EndpointGroup], row)
+	}
+
+	results := []DataOutput{}
+	for i := 0; i < len(members); {
+		group := []string{}
+		j := i
+		for ; j < len(members) && members[j].ID.SuperGroup == members[i].ID.SuperGroup; j++ {
+			group = append(group, members[j].ID.Name)
+		}
+		combined, err := combineTimelines(members[i].ID.SuperGroup, group, timelines, aggregation.ProfileOp, profile)
+		if err != nil {
+			errs = append(errs, respond.ErrorResponse{
+				Message: "Timelines can not be combined",
+				Code:    "400",
+				Details: err.Error(),
+			})
+			return nil, errs, nil
+		}
+		results = append(results, combined...)
+		i = j
+	}
+
+	return results, errs, nil
+}
+
+// ListEndpointGroupStats returns the outage statistics of endpoint groups over a time span
+func ListEndpointGroupStats(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
+
+	//STANDARD DECLARATIONS START
+
+	code := http.StatusOK
+	h := http.Header{}
+	output := []byte("List Endpoint Group Stats")
+	err := error(nil)
+	contentType := "application/xml"
+	charset := "utf-8"
+
+	//STANDARD DECLARATIONS END
+
+	contentType, err = respond.ParseAcceptHeader(r, respond.TimelineContentTypes...)
+	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
+
+	if err != nil {
+		code = http.StatusNotAcceptable
+		output, _ = respond.MarshalContent(respond.NotAcceptableContentType, contentType, "", " ")
+		return code, h, output, err
+	}
+
+	// Parse the request into the input
+	urlValues := r.URL.Query()
+	vars := mux.Vars(r)
+
+	parsedStart, parsedEnd, errs := respond.ValidateDateRange(urlValues.Get("start_time"), urlValues.Get("end_time"))
+	start, errStart := time.Parse(zuluForm, urlValues.Get("start_time"))
+	end, errEnd := time.Parse(zuluForm, urlValues.Get("end_time"))
+	if len(errs) == 0 && (errStart != nil || errEnd != nil || !end.After(start)) {
+		errs = append(errs, respond.ErrorResponse{
+			Message: "Wrong time span",
+			Code:    "400",
+			Details: "Please use both start_time and end_time url parameters and an end_time after the start_time",
+		})
+	}
+
+	input := InputParams{
+		parsedStart,
+		parsedEnd,
+		vars["report_name"],
+		vars["group_type"],
+		vars["group_name"],
+		contentType,
+		false,
+		end,
+		respond.ParseStateFilter(urlValues.Get("status"), urlValues.Get("exclude_status")),
+		0,
+		start,
+		false,
+		false,
+		0,
+	}
+
+	// Call authenticateTenant to check the api key and retrieve
+	// the correct tenant db conf
+	tenantDbConfig, err := authentication.AuthenticateTenant(r.Header, cfg)
+	if err != nil {
+		if err.Error() == "Unauthorized" {
+			code = http.StatusUnauthorized
+			out := respond.UnauthorizedMessage
+			output = out.MarshalTo(contentType)
+			return code, h, output, err
+		}
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	session, err := mongo.OpenSession(tenantDbConfig)
+	defer mongo.CloseSession(session)
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	// Outages are the periods spent in the requested states of the operations profile
+	profile, errProfile := operationsProfiles.GetReportProfile(session, tenantDbConfig.Db, input.report)
+	if errProfile != nil {
+		errs = append(errs, respond.ErrorResponse{
+			Message: "Operations profile not found",
+			Code:    "400",
+			Details: fmt.Sprintf("The report %s does not define an existing operations profile", input.report),
+		})
+	}
+	if errProfile == nil {
+		errs = append(errs, input.states.Validate(profile.HasState, profile.Name)...)
+	}
+
+	outageStates := map[string]bool{}
+	for _, state := range strings.Split(urlValues.Get("outage_states"), ",") {
+		if state == "" {
+			continue
+		}
+		if errProfile == nil && !profile.HasState(state) {
+			errs = append(errs, respond.ErrorResponse{
+				Message: "Unknown state",
+				Code:    "400",
+				Details: fmt.Sprintf("The state %s is not declared in the operations profile %s", state, profile.Name),
+			})
+		}
+		outageStates[state] = true
+	}
+	if len(outageStates) == 0 && errProfile == nil {
+		outageStates, err = profile.OutageStates()
+		if err != nil {
+			errs = append(errs, respond.ErrorResponse{
+				Message: "Operations profile can not rank its states",
+				Code:    "400",
+				Details: err.Error(),
+			})
+		}
+	}
+
+	if len(errs) > 0 {
+		code = http.StatusBadRequest
+		output = respond.CreateFailureResponseMessage("Bad Request", "400", errs).MarshalTo(contentType)
+		return code, h, output, nil
+	}
+
+	reportID, err := mongo.GetReportID(session, tenantDbConfig.Db, input.report)
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	results := []DataOutput{}
+	err = session.DB(tenantDbConfig.Db).C("status_endpoint_groups").
+		Find(prepareQuery(input, reportID)).Sort("endpoint_group", "timestamp").All(&results)
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	results = filterStates(results, input.states)
+
+	output, err = createStatsView(results, input, start, end, outageStates) //Render the results into JSON/XML format
+
+	return code, h, output, err
+}
+
+// ListEndpointGroupSummary returns the time endpoint group timelines spend in each state of the operations profile over a time span
+func ListEndpointGroupSummary(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
+
+	//STANDARD DECLARATIONS START
+
+	code := http.StatusOK
+	h := http.Header{}
+	output := []byte("List Endpoint Group Summary")
+	err := error(nil)
+	contentType := "application/xml"
+	charset := "utf-8"
+
+	//STANDARD DECLARATIONS END
+
+	contentType, err = respond.ParseAcceptHeader(r, respond.TimelineContentTypes...)
+	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
+
+	if err != nil {
+		code = http.StatusNotAcceptable
+		output, _ = respond.MarshalContent(respond.NotAcceptableContentType, contentType, "", " ")
+		return code, h, output, err
+	}
+
+	// Parse the request into the input
+	urlValues := r.URL.Query()
+	vars := mux.Vars(r)
+
+	parsedStart, parsedEnd, errs := respond.ValidateDateRange(urlValues.Get("start_time"), urlValues.Get("end_time"))
+	start, errStart := time.Parse(zuluForm, urlValues.Get("start_time"))
+	end, errEnd := time.Parse(zuluForm, urlValues.Get("end_time"))
+	if len(errs) == 0 && (errStart != nil || errEnd != nil || !end.After(start)) {
+		errs = append(errs, respond.ErrorResponse{
+			Message: "Wrong time span",
+			Code:    "400",
+			Details: "Please use both start_time and end_time url parameters and an end_time after the start_time",
+		})
+	}
+	fillGaps, gapThreshold, gapErrs := respond.ValidateFillGaps(urlValues.Get("fill_gaps"), urlValues.Get("gap_threshold"), urlValues.Get("end_time"))
+	errs = append(errs, gapErrs...)
+
+	input := InputParams{
+		parsedStart,
+		parsedEnd,
+		vars["report_name"],
+		vars["group_type"],
+		vars["group_name"],
+		contentType,
+		false,
+		end,
+		respond.ParseStateFilter(urlValues.Get("status"), urlValues.Get("exclude_status")),
+		0,
+		start,
+		false,
+		fillGaps,
+		gapThreshold,
+	}
+
+	// Call authenticateTenant to check the api key and retrieve
+	// the correct tenant db conf
+	tenantDbConfig, err := authentication.AuthenticateTenant(r.Header, cfg)
+	if err != nil {
+		if err.Error() == "Unauthorized" {
+			code = http.StatusUnauthorized
+			out := respond.UnauthorizedMessage
+			output = out.MarshalTo(contentType)
+			return code, h, output, err
+		}
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	session, err := mongo.OpenSession(tenantDbConfig)
+	defer mongo.CloseSession(session)
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	profile, errProfile := operationsProfiles.GetReportProfile(session, tenantDbConfig.Db, input.report)
+	if errProfile != nil {
+		errs = append(errs, respond.ErrorResponse{
+			Message: "Operations profile not found",
+			Code:    "400",
+			Details: fmt.Sprintf("The report %s does not define an existing operations profile", input.report),
+		})
+	}
+	if errProfile == nil {
+		errs = append(errs, input.states.Validate(profile.HasState, profile.Name)...)
+	}
+	if errProfile == nil && input.fillGaps {
+		var gapErrs []respond.ErrorResponse
+		_, input.gapThreshold, gapErrs = operationsProfiles.GetGapFilling(session, tenantDbConfig.Db, input.report, input.gapThreshold)
+		errs = append(errs, gapErrs...)
+	}
+
+	if len(errs) > 0 {
+		code = http.StatusBadRequest
+		output = respond.CreateFailureResponseMessage("Bad Request", "400", errs).MarshalTo(contentType)
+		return code, h, output, nil
+	}
+
+	reportID, err := mongo.GetReportID(session, tenantDbConfig.Db, input.report)
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	results := []DataOutput{}
+	err = session.DB(tenantDbConfig.Db).C("status_endpoint_groups").
+		Find(prepareQuery(input, reportID)).Sort("endpoint_group", "timestamp").All(&results)
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	results = fillMissing(results, input.gapThreshold, profile.Defaults.Missing, input.end)
+	results = filterStates(results, input.states)
+
+	output, err = createSummaryView(results, input, start, profile) //Render the results into JSON/XML format
+
+	return code, h, output, err
+}
+
+// queryContributors reads the statuses of the services, endpoints and metrics of the endpoint groups in the
+// results over the time span of the request, sorted by type, name and timestamp
+func queryContributors(session *mgo.Session, db string, input InputParams, reportID string, results []DataOutput) ([]ContributorData, error) {
+	groups := []string{}
+	for _, row := range results {
+		if len(groups) == 0 || groups[len(groups)-1] != row.EndpointGroup {
+			groups = append(groups, row.EndpointGroup)
+		}
+	}
+
+	filter := bson.M{
+		"date_integer":   bson.M{"$gte": input.startTime, "$lte": input.endTime},
+		"report":         reportID,
+		"endpoint_group": bson.M{"$in": groups},
+	}
+
+	contributors := []ContributorData{}
+	for _, source := range contributorSources {
+		statuses := []ContributorData{}
+		err := session.DB(db).C(source.collection).Find(filter).Sort("endpoint_group", "service", "host", "metric", "timestamp").All(&statuses)
+		if err != nil {
+			return nil, err
+		}
+		for i := range statuses {
+			statuses[i].Type = source.kind
+		}
+		contributors = append(contributors, statuses...)
+	}
+	return contributors, nil
+}
+
 func prepareQuery(input InputParams, reportID string) bson.M {
 	filter := bson.M{
 		"date_integer": bson.M{"$gte": input.startTime, "$lte": input.endTime},
@@ -22,16 +22,33 @@
 
 package statusEndpointGroups
 
-import "encoding/xml"
+import (
+	"encoding/xml"
+	"sort"
+	"time"
+
+	"github.com/ARGOeu/argo-web-api/app/operationsProfiles"
+	"github.com/ARGOeu/argo-web-api/respond"
+)
+
+const zuluForm = "2006-01-02T15:04:05Z"
 
 // InputParams struct holds as input all the url params of the request
 type InputParams struct {
-	startTime int // UTC time in W3C format
-	endTime   int
-	report    string
-	groupType string
-	group     string
-	format    string
+	startTime    int // UTC time in W3C format
+	endTime      int
+	report       string
+	groupType    string
+	group        string
+	format       string
+	intervals    bool
+	end          time.Time
+	states       respond.StateFilter
+	bucket       time.Duration
+	start        time.Time
+	explain      bool
+	fillGaps     bool
+	gapThreshold time.Duration
 }
 
 // DataOutput struct holds the queried data from datastore
@@ -43,24 +60,63 @@ type DataOutput struct {
 	DateInteger   string `bson:"date_integer"`
 }
 
+// GroupDataOutput struct holds the queried timelines of groups of endpoint groups from datastore
+type GroupDataOutput struct {
+	Report      string `bson:"report"`
+	Timestamp   string `bson:"timestamp"`
+	Group       string `bson:"group"`
+	Status      string `bson:"status"`
+	DateInteger string `bson:"date_integer"`
+}
+
+// MemberOutput struct holds an endpoint group and the group it belongs to as found in the results
+type MemberOutput struct {
+	ID struct {
+		SuperGroup string `bson:"supergroup"`
+		Name       string `bson:"name"`
+	} `bson:"_id"`
+}
+
+// ContributorData struct holds a status of a service, endpoint or metric of an endpoint group from datastore
+type ContributorData struct {
+	Type          string `bson:"-"`
+	Timestamp     string `bson:"timestamp"`
+	EndpointGroup string `bson:"endpoint_group"`
+	Service       string `bson:"service"`
+	Hostname      string `bson:"host"`
+	Metric        string `bson:"metric"`
+	Status        string `bson:"status"`
+}
+
+// contributorSources lists the collections below the endpoint groups that explain their statuses
+var contributorSources = []struct {
+	kind       string
+	collection string
+}{
+	{"service", "status_services"},
+	{"endpoint", "status_endpoints"},
+	{"metric", "status_metrics"},
+}
+
 // xml response related structs
 
 type rootOUT struct {
 	XMLName        xml.Name            `xml:"root" json:"-"`
 	EndpointGroups []*endpointGroupOUT `json:"groups"`
+	intervals      bool
+	buckets        bool
+	summary        bool
+	explain        bool
 }
 
 type endpointGroupOUT struct {
-	XMLName   xml.Name     `xml:"group" json:"-"`
-	Name      string       `xml:"name,attr" json:"name"`
-	GroupType string       `xml:"type,attr" json:"type"`
-	Statuses  []*statusOUT `json:"statuses"`
-}
-
-type statusOUT struct {
-	XMLName   xml.Name `xml:"status" json:"-"`
-	Timestamp string   `xml:"timestamp,attr" json:"timestamp"`
-	Value     string   `xml:"value,attr" json:"value"`
+	XMLName   xml.Name                    `xml:"group" json:"-"`
+	Name      string                      `xml:"name,attr" json:"name"`
+	GroupType string                      `xml:"type,attr" json:"type"`
+	Statuses  []*respond.TimelineStatus   `json:"statuses,omitempty"`
+	Intervals []*respond.TimelineInterval `json:"intervals,omitempty"`
+	Buckets   []*respond.TimelineBucket   `json:"buckets,omitempty"`
+	Summary   *respond.TimelineSummary    `json:"summary,omitempty"`
 }
 
 // Message struct to hold the json/xml response
@@ -68,3 +124,120 @@ type messageOUT struct {
 	XMLName xml.Name `xml:"root" json:"-"`
 	Message string   `xml:"message" json:"message"`
 }
+
+// statsRootOUT holds the outage statistics of the endpoint groups
+type statsRootOUT struct {
+	XMLName        xml.Name                 `xml:"root" json:"-"`
+	EndpointGroups []*endpointGroupStatsOUT `json:"groups"`
+}
+
+type endpointGroupStatsOUT struct {
+	XMLName   xml.Name             `xml:"group" json:"-"`
+	Name      string               `xml:"name,attr" json:"name"`
+	GroupType string               `xml:"type,attr" json:"type"`
+	Stats     *respond.OutageStats `json:"stats"`
+}
+
+type byTimestamp []DataOutput
+
+func (t byTimestamp) Len() int           { return len(t) }
+func (t byTimestamp) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+func (t byTimestamp) Less(i, j int) bool { return t[i].Timestamp < t[j].Timestamp }
+
+// combineTimelines merges the timelines of the member endpoint groups of a group into the timeline
+// of the group. Every time members change state, the states of all members are combined with an
+// operation of the operations profile and a status is added when the combined state changes.
+// Members start in the missing state until their first status
+func combineTimelines(group string, members []string, timelines map[string][]DataOutput, operation string, profile operationsProfiles.OpsProfile) ([]DataOutput, error) {
+	states := map[string]string{}
+	events := []DataOutput{}
+	for _, member := range members {
+		states[member] = profile.Defaults.Missing
+		events = append(events, timelines[member]...)
+	}
+	sort.Stable(byTimestamp(events))
+
+	combined := []DataOutput{}
+	for i, event := range events {
+		states[event.EndpointGroup] = event.Status
+		if i+1 < len(events) && events[i+1].Timestamp == event.Timestamp {
+			continue
+		}
+
+		state := states[members[0]]
+		for _, member := range members[1:] {
+			var err error
+			state, err = profile.Operate(operation, state, states[member])
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if len(combined) == 0 || combined[len(combined)-1].Status != state {
+			combined = append(combined, DataOutput{
+				Report:        event.Report,
+				Timestamp:     event.Timestamp,
+				EndpointGroup: group,
+				Status:        state,
+				DateInteger:   event.DateInteger,
+			})
+		}
+	}
+	return combined, nil
+}
+
+// timelines keys the statuses of the results by endpoint group for the shared timeline helpers
+type timelines []DataOutput
+
+func (t timelines) Len() int               { return len(t) }
+func (t timelines) Entity(i int) string    { return t[i].EndpointGroup }
+func (t timelines) Timestamp(i int) string { return t[i].Timestamp }
+func (t timelines) State(i int) string     { return t[i].Status }
+
+// contributorTimelines keys the statuses of the services, endpoints and metrics of an endpoint group
+// by contributor for the shared timeline helpers
+type contributorTimelines []ContributorData
+
+func (t contributorTimelines) Len() int { return len(t) }
+func (t contributorTimelines) Entity(i int) string {
+	return t[i].Type + "/" + t[i].Service + "/" + t[i].Hostname + "/" + t[i].Metric
+}
+func (t contributorTimelines) Timestamp(i int) string { return t[i].Timestamp }
+func (t contributorTimelines) State(i int) string     { return t[i].Status }
+
+// contributor describes the service, endpoint or metric whose timeline starts at a position of the statuses
+func (t contributorTimelines) contributor(i int) *respond.Contributor {
+	contributor := &respond.Contributor{Type: t[i].Type, Service: t[i].Service}
+	if t[i].Type != "service" {
+		contributor.Endpoint = t[i].Hostname
+	}
+	if t[i].Type == "metric" {
+		contributor.Metric = t[i].Metric
+	}
+	return contributor
+}
+
+// filterStates keeps the timelines of the endpoint groups that enter one of the requested states and drops the rest
+func filterStates(results []DataOutput, states respond.StateFilter) []DataOutput {
+	filtered := []DataOutput{}
+	for _, i := range states.Filter(timelines(results)) {
+		filtered = append(filtered, results[i])
+	}
+	return filtered
+}
+
+// fillMissing adds a status in the missing state to the timelines of the endpoint groups in the results
+// wherever a status is not followed by another one for longer than the threshold
+func fillMissing(results []DataOutput, threshold time.Duration, missing string, end time.Time) []DataOutput {
+	gaps := respond.FindGaps(timelines(results), threshold, missing, end)
+	filled := []DataOutput{}
+	for i, row := range results {
+		filled = append(filled, row)
+		if len(gaps) > 0 && gaps[0].After == i {
+			row.Timestamp, row.Status = gaps[0].Timestamp, missing
+			filled = append(filled, row)
+			gaps = gaps[1:]
+		}
+	}
+	return filled
+}
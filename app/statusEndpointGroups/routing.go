@@ -39,21 +39,44 @@ import (
 // HandleSubrouter contains the different paths to follow during subrouting
 func HandleSubrouter(s *mux.Router, confhandler *respond.ConfHandler) {
 
-	// eg. timelines/critical/SITES/mysite
+	// eg. timelines/critical/SITES/mysite/stats
+	s.Path("/{report_name}/{group_type}/{group_name}/stats").
+		Methods("GET").
+		Name("endpoint group stats").
+		Handler(confhandler.Respond(routeCheckGroup(ListEndpointGroupStats, nil)))
+
+	// eg. timelines/critical/SITES/mysite/summary
+	s.Path("/{report_name}/{group_type}/{group_name}/summary").
+		Methods("GET").
+		Name("endpoint group summary").
+		Handler(confhandler.Respond(routeCheckGroup(ListEndpointGroupSummary, nil)))
+
+	// eg. timelines/critical/SITES/mysite or timelines/critical/NGI/myngi
 	s.Path("/{report_name}/{group_type}/{group_name}").
 		Methods("GET").
 		Name("endpoint group name").
-		Handler(confhandler.Respond(routeCheckGroup))
+		Handler(confhandler.Respond(routeCheckGroup(ListEndpointGroupTimelines, ListGroupTimelines)))
 
-	// eg. timelines/critical/SITES
+	// eg. timelines/critical/SITES or timelines/critical/NGI
 	s.Path("/{report_name}/{group_type}").
 		Methods("GET").
 		Name("all endpoint groups").
-		Handler(confhandler.Respond(routeCheckGroup))
+		Handler(confhandler.Respond(routeCheckGroup(ListEndpointGroupTimelines, ListGroupTimelines)))
+
+}
+
+// handlerFunc is the signature of the handlers that are wrapped by the group checks
+type handlerFunc func(r *http.Request, cfg config.Config) (int, http.Header, []byte, error)
 
+// routeCheckGroup checks the requested group type against the report before handing the request over.
+// Endpoint group types are handled by handler and the top level group type by groupHandler when it is not nil
+func routeCheckGroup(handler handlerFunc, groupHandler handlerFunc) handlerFunc {
+	return func(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
+		return checkGroup(r, cfg, handler, groupHandler)
+	}
 }
 
-func routeCheckGroup(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
+func checkGroup(r *http.Request, cfg config.Config, handler handlerFunc, groupHandler handlerFunc) (int, http.Header, []byte, error) {
 
 	//STANDARD DECLARATIONS START
 	code := http.StatusOK
@@ -90,6 +113,10 @@ func routeCheckGroup(r *http.Request, cfg config.Config) (int, http.Header, []by
 		return code, h, output, err
 	}
 
+	if groupHandler != nil && vars["group_type"] == result.GetGroupType() && vars["group_type"] != result.GetEndpointGroupType() {
+		return groupHandler(r, cfg)
+	}
+
 	if vars["group_type"] != result.GetEndpointGroupType() {
 		message := "The report " + vars["report_name"] + " does not define endpoint group type: " + vars["group_type"]
 		output, err := createMessageOUT(message, format) //Render the response into XML or JSON
@@ -97,6 +124,6 @@ func routeCheckGroup(r *http.Request, cfg config.Config) (int, http.Header, []by
 		return code, h, output, err
 	}
 
-	return ListEndpointGroupTimelines(r, cfg)
+	return handler(r, cfg)
 
 }
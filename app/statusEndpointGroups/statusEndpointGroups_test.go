@@ -192,6 +192,73 @@ func (suite *StatusEndpointGroupsTestSuite) SetupTest() {
 				"value": "value2"},
 		}})
 
+	// seed the operations profile of the report
+	c = session.DB(suite.tenantDbConf.Db).C("operations_profiles")
+	c.Insert(bson.M{
+		"id":               "6ac7d684-1f8e-4a02-a502-720e8f11e523",
+		"name":             "profile2",
+		"available_states": []string{"OK", "WARNING", "UNKNOWN", "MISSING", "CRITICAL", "DOWNTIME"},
+		"defaults": bson.M{
+			"down":    "DOWNTIME",
+			"missing": "MISSING",
+			"unknown": "UNKNOWN",
+		},
+		"operations": []bson.M{
+			bson.M{
+				"name": "AND",
+				"truth_table": []bson.M{
+					bson.M{"a": "OK", "b": "OK", "x": "OK"},
+					bson.M{"a": "OK", "b": "WARNING", "x": "WARNING"},
+					bson.M{"a": "OK", "b": "UNKNOWN", "x": "UNKNOWN"},
+					bson.M{"a": "OK", "b": "MISSING", "x": "MISSING"},
+					bson.M{"a": "OK", "b": "CRITICAL", "x": "CRITICAL"},
+					bson.M{"a": "OK", "b": "DOWNTIME", "x": "DOWNTIME"},
+					bson.M{"a": "WARNING", "b": "WARNING", "x": "WARNING"},
+					bson.M{"a": "WARNING", "b": "UNKNOWN", "x": "UNKNOWN"},
+					bson.M{"a": "WARNING", "b": "MISSING", "x": "MISSING"},
+					bson.M{"a": "WARNING", "b": "CRITICAL", "x": "CRITICAL"},
+					bson.M{"a": "WARNING", "b": "DOWNTIME", "x": "DOWNTIME"},
+					bson.M{"a": "UNKNOWN", "b": "UNKNOWN", "x": "UNKNOWN"},
+					bson.M{"a": "UNKNOWN", "b": "MISSING", "x": "MISSING"},
+					bson.M{"a": "UNKNOWN", "b": "CRITICAL", "x": "CRITICAL"},
+					bson.M{"a": "UNKNOWN", "b": "DOWNTIME", "x": "DOWNTIME"},
+					bson.M{"a": "MISSING", "b": "MISSING", "x": "MISSING"},
+					bson.M{"a": "MISSING", "b": "CRITICAL", "x": "CRITICAL"},
+					bson.M{"a": "MISSING", "b": "DOWNTIME", "x": "DOWNTIME"},
+					bson.M{"a": "CRITICAL", "b": "CRITICAL", "x": "CRITICAL"},
+					bson.M{"a": "CRITICAL", "b": "DOWNTIME", "x": "CRITICAL"},
+					bson.M{"a": "DOWNTIME", "b": "DOWNTIME", "x": "DOWNTIME"},
+				},
+			},
+		},
+	})
+
+	// seed the aggregation profile of the report
+	c = session.DB(suite.tenantDbConf.Db).C("aggregation_profiles")
+	c.Insert(bson.M{
+		"id":                "6ac7d684-1f8e-4a02-a502-720e8f11e50q",
+		"name":              "profile3",
+		"namespace":         "test",
+		"endpoint_group":    "SITES",
+		"metric_operation":  "AND",
+		"profile_operation": "AND",
+	})
+
+	// seed the endpoint group results that hold the members of the groups
+	c = session.DB(suite.tenantDbConf.Db).C("endpoint_group_ar")
+	c.Insert(bson.M{
+		"report":     "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date":       20150501,
+		"name":       "HG-03-AUTH",
+		"supergroup": "NGI_GRNET",
+	})
+	c.Insert(bson.M{
+		"report":     "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date":       20150501,
+		"name":       "HG-02-IASA",
+		"supergroup": "NGI_GRNET",
+	})
+
 	// seed the status detailed metric data
 	c = session.DB(suite.tenantDbConf.Db).C("status_endpoint_groups")
 	c.Insert(bson.M{
@@ -215,6 +282,150 @@ func (suite *StatusEndpointGroupsTestSuite) SetupTest() {
 		"endpoint_group": "HG-03-AUTH",
 		"status":         "OK",
 	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T00:00:00Z",
+		"endpoint_group": "HG-02-IASA",
+		"status":         "OK",
+	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T03:00:00Z",
+		"endpoint_group": "HG-02-IASA",
+		"status":         "CRITICAL",
+	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T07:00:00Z",
+		"endpoint_group": "HG-02-IASA",
+		"status":         "OK",
+	})
+
+	// seed the statuses of the services, endpoints and metrics that explain the timelines
+	c = session.DB(suite.tenantDbConf.Db).C("status_services")
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T00:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"status":         "OK",
+	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T01:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"status":         "CRITICAL",
+	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T05:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"status":         "OK",
+	})
+	c = session.DB(suite.tenantDbConf.Db).C("status_endpoints")
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T00:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"host":           "cream01.afroditi.gr",
+		"status":         "OK",
+	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T02:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"host":           "cream01.afroditi.gr",
+		"status":         "CRITICAL",
+	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T04:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"host":           "cream01.afroditi.gr",
+		"status":         "OK",
+	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T00:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"host":           "cream02.afroditi.gr",
+		"status":         "OK",
+	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T03:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"host":           "cream02.afroditi.gr",
+		"status":         "WARNING",
+	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T06:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"host":           "cream02.afroditi.gr",
+		"status":         "OK",
+	})
+	c = session.DB(suite.tenantDbConf.Db).C("status_metrics")
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T00:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"host":           "cream01.afroditi.gr",
+		"metric":         "emi.cream.CREAMCE-JobSubmit",
+		"status":         "OK",
+	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T01:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"host":           "cream01.afroditi.gr",
+		"metric":         "emi.cream.CREAMCE-JobSubmit",
+		"status":         "WARNING",
+	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T02:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"host":           "cream01.afroditi.gr",
+		"metric":         "emi.cream.CREAMCE-JobSubmit",
+		"status":         "CRITICAL",
+	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T04:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"host":           "cream01.afroditi.gr",
+		"metric":         "emi.cream.CREAMCE-JobSubmit",
+		"status":         "OK",
+	})
 
 	// get dbconfiguration based on the tenant
 	// Prepare the request object
@@ -291,6 +502,23 @@ func (suite *StatusEndpointGroupsTestSuite) SetupTest() {
 		"status":         "OK",
 	})
 
+	// seed the stored group timelines
+	c = session.DB(suite.tenantDbConf.Db).C("status_groups")
+	c.Insert(bson.M{
+		"report":       "eba61a9e-22e9-4521-9e47-ecaa4a494365",
+		"date_integer": 20150501,
+		"timestamp":    "2015-05-01T00:00:00Z",
+		"group":        "EUDAT_GROUP_A",
+		"status":       "OK",
+	})
+	c.Insert(bson.M{
+		"report":       "eba61a9e-22e9-4521-9e47-ecaa4a494365",
+		"date_integer": 20150501,
+		"timestamp":    "2015-05-01T02:00:00Z",
+		"group":        "EUDAT_GROUP_A",
+		"status":       "WARNING",
+	})
+
 }
 
 func (suite *StatusEndpointGroupsTestSuite) TestListStatusEndpointGroups() {
@@ -446,6 +674,635 @@ func (suite *StatusEndpointGroupsTestSuite) TestListStatusEndpointGroups() {
 
 }
 
+func (suite *StatusEndpointGroupsTestSuite) TestListStatusEndpointGroupsCSV() {
+	respCSV := `group_type,group,timestamp,status
+SITES,HG-03-AUTH,2015-05-01T00:00:00Z,OK
+SITES,HG-03-AUTH,2015-05-01T01:00:00Z,CRITICAL
+SITES,HG-03-AUTH,2015-05-01T05:00:00Z,OK
+`
+
+	fullurl := "/api/v2/status/Report_A/SITES/HG-03-AUTH" +
+		"?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:00:00Z"
+
+	// init the response placeholder
+	response := httptest.NewRecorder()
+	// Prepare the request object
+	request, _ := http.NewRequest("GET", fullurl, strings.NewReader(""))
+	// add csv accept header
+	request.Header.Set("Accept", "text/csv")
+	// add the authentication token which is seeded in testdb
+	request.Header.Set("x-api-key", "KEY1")
+	// Serve the http request
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Check the negotiated content type
+	suite.Equal("text/csv; charset=utf-8", response.Header().Get("Content-Type"), "Content type mismatch")
+	// Compare the expected and actual csv response
+	suite.Equal(respCSV, response.Body.String(), "Response body mismatch")
+
+}
+
+func (suite *StatusEndpointGroupsTestSuite) TestListStatusEndpointGroupStats() {
+	respXML := `<root>
+ <group name="HG-03-AUTH" type="SITES">
+  <stats outages="1" mttr="14400" mtbf="68400" longest_outage="14400"></stats>
+ </group>
+</root>`
+
+	respJSON := `{
+ "groups": [
+  {
+   "name": "HG-03-AUTH",
+   "type": "SITES",
+   "stats": {
+    "outages": 1,
+    "mttr": 14400,
+    "mtbf": 68400,
+    "longest_outage": 14400
+   }
+  }
+ ]
+}`
+
+	respClippedXML := `<root>
+ <group name="HG-03-AUTH" type="SITES">
+  <stats outages="1" mttr="7200" mtbf="0" longest_outage="7200"></stats>
+ </group>
+</root>`
+
+	respNoOutagesXML := `<root>
+ <group name="HG-03-AUTH" type="SITES">
+  <stats outages="0" mttr="0" mtbf="0" longest_outage="0"></stats>
+ </group>
+</root>`
+
+	fullurl := "/api/v2/status/Report_A/SITES/HG-03-AUTH/stats" +
+		"?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:00:00Z"
+
+	// 1. XML REQUEST
+	response := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", fullurl, strings.NewReader(""))
+	request.Header.Set("Accept", "application/xml")
+	request.Header.Set("x-api-key", "KEY1")
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Compare the expected and actual xml response
+	suite.Equal(respXML, response.Body.String(), "Response body mismatch")
+
+	// 2. JSON REQUEST
+	response = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", fullurl, strings.NewReader(""))
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("x-api-key", "KEY1")
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Compare the expected and actual json response
+	suite.Equal(respJSON, response.Body.String(), "Response body mismatch")
+
+	// 3. Outages still open at the edges of the time span are clipped
+	response = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/api/v2/status/Report_A/SITES/HG-03-AUTH/stats"+
+		"?start_time=2015-05-01T02:00:00Z&end_time=2015-05-01T04:00:00Z", strings.NewReader(""))
+	request.Header.Set("Accept", "application/xml")
+	request.Header.Set("x-api-key", "KEY1")
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Compare the expected and actual xml response
+	suite.Equal(respClippedXML, response.Body.String(), "Response body mismatch")
+
+	// 4. Outages are measured on the requested states of the operations profile
+	response = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", fullurl+"&outage_states=DOWNTIME,UNKNOWN", strings.NewReader(""))
+	request.Header.Set("Accept", "application/xml")
+	request.Header.Set("x-api-key", "KEY1")
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Compare the expected and actual xml response
+	suite.Equal(respNoOutagesXML, response.Body.String(), "Response body mismatch")
+
+	// 5. By default the down state and the failure states of the operations profile are outages
+	session, _ := mongo.OpenSession(suite.tenantDbConf)
+	defer mongo.CloseSession(session)
+	session.DB(suite.tenantDbConf.Db).C("status_endpoint_groups").Insert(
+		bson.M{"report": "eba61a9e-22e9-4521-9e47-ecaa4a494364", "date_integer": 20150501,
+			"timestamp": "2015-05-01T10:00:00Z", "endpoint_group": "HG-03-AUTH", "status": "DOWNTIME"},
+		bson.M{"report": "eba61a9e-22e9-4521-9e47-ecaa4a494364", "date_integer": 20150501,
+			"timestamp": "2015-05-01T12:00:00Z", "endpoint_group": "HG-03-AUTH", "status": "OK"})
+
+	response = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", fullurl, strings.NewReader(""))
+	request.Header.Set("Accept", "application/xml")
+	request.Header.Set("x-api-key", "KEY1")
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Compare the expected and actual xml response
+	suite.Equal(`<root>
+ <group name="HG-03-AUTH" type="SITES">
+  <stats outages="2" mttr="10800" mtbf="30600" longest_outage="14400"></stats>
+ </group>
+</root>`, response.Body.String(), "Response body mismatch")
+
+	// 6. States that the operations profile does not declare are rejected
+	response = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", fullurl+"&outage_states=BROKEN", strings.NewReader(""))
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("x-api-key", "KEY1")
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 400 bad request code
+	suite.Equal(400, response.Code, "Incorrect HTTP response code")
+	suite.Contains(response.Body.String(), "The state BROKEN is not declared in the operations profile profile2", "Response body mismatch")
+
+	// 7. Statistics need a complete time span
+	response = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/api/v2/status/Report_A/SITES/HG-03-AUTH/stats?start_time=2015-05-01T00:00:00Z", strings.NewReader(""))
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("x-api-key", "KEY1")
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 400 bad request code
+	suite.Equal(400, response.Code, "Incorrect HTTP response code")
+	suite.Contains(response.Body.String(), "Wrong time span", "Response body mismatch")
+
+}
+
+func (suite *StatusEndpointGroupsTestSuite) TestListStatusGroups() {
+	respXML1 := `<root>
+ <group name="NGI_GRNET" type="NGI">
+  <status timestamp="2015-05-01T00:00:00Z" value="OK"></status>
+  <status timestamp="2015-05-01T01:00:00Z" value="CRITICAL"></status>
+  <status timestamp="2015-05-01T07:00:00Z" value="OK"></status>
+ </group>
+</root>`
+
+	respJSON2 := `{
+ "groups": [
+  {
+   "name": "EUDAT_GROUP_A",
+   "type": "EUDAT_GROUPS",
+   "statuses": [
+    {
+     "timestamp": "2015-05-01T00:00:00Z",
+     "value": "OK"
+    },
+    {
+     "timestamp": "2015-05-01T02:00:00Z",
+     "value": "WARNING"
+    }
+   ]
+  }
+ ]
+}`
+
+	// 1. Timelines of groups are derived from the timelines of their endpoint groups
+	response := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/v2/status/Report_A/NGI/NGI_GRNET"+
+		"?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:00:00Z", strings.NewReader(""))
+	request.Header.Set("Accept", "application/xml")
+	request.Header.Set("x-api-key", "KEY1")
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Compare the expected and actual xml response
+	suite.Equal(respXML1, response.Body.String(), "Response body mismatch")
+
+	// 2. All groups of the top level group type
+	response = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/api/v2/status/Report_A/NGI"+
+		"?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:00:00Z", strings.NewReader(""))
+	request.Header.Set("Accept", "application/xml")
+	request.Header.Set("x-api-key", "KEY1")
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Compare the expected and actual xml response
+	suite.Equal(respXML1, response.Body.String(), "Response body mismatch")
+
+	// 3. Stored timelines of groups are returned as they are
+	response = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/api/v2/status/Report_B/EUDAT_GROUPS/EUDAT_GROUP_A"+
+		"?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:00:00Z", strings.NewReader(""))
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("x-api-key", "KEY2")
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Compare the expected and actual json response
+	suite.Equal(respJSON2, response.Body.String(), "Response body mismatch")
+
+	// 4. Timelines can not be derived without the profiles of the report
+	response = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/api/v2/status/Report_B/EUDAT_GROUPS/EUDAT_GROUP_B"+
+		"?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:00:00Z", strings.NewReader(""))
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("x-api-key", "KEY2")
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 400 bad request code
+	suite.Equal(400, response.Code, "Incorrect HTTP response code")
+	suite.Contains(response.Body.String(), "The report Report_B does not define an existing operations profile", "Response body mismatch")
+
+	// 5. Gaps in the timelines of groups are filled with the missing state
+	response = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/api/v2/status/Report_A/NGI/NGI_GRNET"+
+		"?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:00:00Z&fill_gaps=true&gap_threshold=4h", strings.NewReader(""))
+	request.Header.Set("Accept", "application/xml")
+	request.Header.Set("x-api-key", "KEY1")
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Compare the expected and actual xml response
+	suite.Equal(`<root>
+ <group name="NGI_GRNET" type="NGI">
+  <status timestamp="2015-05-01T00:00:00Z" value="OK"></status>
+  <status timestamp="2015-05-01T01:00:00Z" value="CRITICAL"></status>
+  <status timestamp="2015-05-01T05:00:00Z" value="MISSING"></status>
+  <status timestamp="2015-05-01T07:00:00Z" value="OK"></status>
+  <status timestamp="2015-05-01T11:00:00Z" value="MISSING"></status>
+ </group>
+</root>`, response.Body.String(), "Response body mismatch")
+
+	// 6. Timelines of groups are not explained
+	response = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/api/v2/status/Report_A/NGI/NGI_GRNET"+
+		"?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:00:00Z&explain=true", strings.NewReader(""))
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("x-api-key", "KEY1")
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 400 bad request code
+	suite.Equal(400, response.Code, "Incorrect HTTP response code")
+	suite.Contains(response.Body.String(), "Explanations are only available for the timelines of endpoint groups and services", "Response body mismatch")
+
+}
+
+// TestListStatusEndpointGroupsIntervals tests that the timelines are rendered as intervals clipped at the end_time
+func (suite *StatusEndpointGroupsTestSuite) TestListStatusEndpointGroupsIntervals() {
+	respXML := `<root>
+ <group name="HG-03-AUTH" type="SITES">
+  <interval from="2015-05-01T00:00:00Z" to="2015-05-01T01:00:00Z" duration_seconds="3600" value="OK" previous_value=""></interval>
+  <interval from="2015-05-01T01:00:00Z" to="2015-05-01T05:00:00Z" duration_seconds="14400" value="CRITICAL" previous_value="OK"></interval>
+  <interval from="2015-05-01T05:00:00Z" to="2015-05-01T23:00:00Z" duration_seconds="64800" value="OK" previous_value="CRITICAL"></interval>
+ </group>
+</root>`
+
+	respCSV := `group_type,group,from,to,duration_seconds,status,previous_status
+SITES,HG-03-AUTH,2015-05-01T00:00:00Z,2015-05-01T01:00:00Z,3600,OK,
+SITES,HG-03-AUTH,2015-05-01T01:00:00Z,2015-05-01T05:00:00Z,14400,CRITICAL,OK
+SITES,HG-03-AUTH,2015-05-01T05:00:00Z,2015-05-01T23:00:00Z,64800,OK,CRITICAL
+`
+
+	fullurl := "/api/v2/status/Report_A/SITES/HG-03-AUTH" +
+		"?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:00:00Z&intervals=true"
+
+	// init the response placeholder
+	response := httptest.NewRecorder()
+	// Prepare the request object
+	request, _ := http.NewRequest("GET", fullurl, strings.NewReader(""))
+	// add xml accept header
+	request.Header.Set("Accept", "application/xml")
+	// add the authentication token which is seeded in testdb
+	request.Header.Set("x-api-key", "KEY1")
+	// Serve the http request
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Compare the expected and actual xml response
+	suite.Equal(respXML, response.Body.String(), "Response body mismatch")
+
+	// init the response placeholder
+	response = httptest.NewRecorder()
+	// Prepare the request object
+	request, _ = http.NewRequest("GET", fullurl, strings.NewReader(""))
+	// add csv accept header
+	request.Header.Set("Accept", "text/csv")
+	// add the authentication token which is seeded in testdb
+	request.Header.Set("x-api-key", "KEY1")
+	// Serve the http request
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Compare the expected and actual csv response
+	suite.Equal(respCSV, response.Body.String(), "Response body mismatch")
+
+	// Intervals can not be clipped without an end_time
+	response = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/api/v2/status/Report_A/SITES/HG-03-AUTH?start_time=2015-05-01T00:00:00Z&intervals=true", strings.NewReader(""))
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("x-api-key", "KEY1")
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 400 bad request code
+	suite.Equal(400, response.Code, "Incorrect HTTP response code")
+	suite.Contains(response.Body.String(), "Please use the end_time url parameter to set the end of the last interval", "Response body mismatch")
+
+}
+
+// TestListStatusEndpointGroupsSummary tests the time spent in each state of the operations profile
+func (suite *StatusEndpointGroupsTestSuite) TestListStatusEndpointGroupsSummary() {
+	respXML := `<root>
+ <group name="HG-03-AUTH" type="SITES">
+  <summary total_seconds="82800">
+   <state name="OK" seconds="68400" percentage="82.6086956521739"></state>
+   <state name="WARNING" seconds="0" percentage="0"></state>
+   <state name="UNKNOWN" seconds="0" percentage="0"></state>
+   <state name="MISSING" seconds="0" percentage="0"></state>
+   <state name="CRITICAL" seconds="14400" percentage="17.391304347826086"></state>
+   <state name="DOWNTIME" seconds="0" percentage="0"></state>
+  </summary>
+ </group>
+</root>`
+
+	respCSV := `group_type,group,total_seconds,state,seconds,percentage
+SITES,HG-03-AUTH,82800,OK,68400,82.6086956521739
+SITES,HG-03-AUTH,82800,WARNING,0,0
+SITES,HG-03-AUTH,82800,UNKNOWN,0,0
+SITES,HG-03-AUTH,82800,MISSING,0,0
+SITES,HG-03-AUTH,82800,CRITICAL,14400,17.391304347826086
+SITES,HG-03-AUTH,82800,DOWNTIME,0,0
+`
+
+	fullurl := "/api/v2/status/Report_A/SITES/HG-03-AUTH/summary" +
+		"?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:00:00Z"
+
+	// init the response placeholder
+	response := httptest.NewRecorder()
+	// Prepare the request object
+	request, _ := http.NewRequest("GET", fullurl, strings.NewReader(""))
+	// add xml accept header
+	request.Header.Set("Accept", "application/xml")
+	// add the authentication token which is seeded in testdb
+	request.Header.Set("x-api-key", "KEY1")
+	// Serve the http request
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Compare the expected and actual xml response
+	suite.Equal(respXML, response.Body.String(), "Response body mismatch")
+
+	// init the response placeholder
+	response = httptest.NewRecorder()
+	// Prepare the request object
+	request, _ = http.NewRequest("GET", fullurl, strings.NewReader(""))
+	// add csv accept header
+	request.Header.Set("Accept", "text/csv")
+	// add the authentication token which is seeded in testdb
+	request.Header.Set("x-api-key", "KEY1")
+	// Serve the http request
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Compare the expected and actual csv response
+	suite.Equal(respCSV, response.Body.String(), "Response body mismatch")
+
+	// The summary needs both ends of the time span
+	response = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/api/v2/status/Report_A/SITES/HG-03-AUTH/summary?start_time=2015-05-01T00:00:00Z", strings.NewReader(""))
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("x-api-key", "KEY1")
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 400 bad request code
+	suite.Equal(400, response.Code, "Incorrect HTTP response code")
+	suite.Contains(response.Body.String(), "Please use both start_time and end_time url parameters and an end_time after the start_time", "Response body mismatch")
+
+}
+
+// TestListStatusEndpointGroupsStates tests that only the timelines entering the requested states are kept
+func (suite *StatusEndpointGroupsTestSuite) TestListStatusEndpointGroupsStates() {
+
+	// The endpoint group enters the CRITICAL state so its whole timeline is kept
+	respCSV := `group_type,group,from,to,duration_seconds,status,previous_status
+SITES,HG-03-AUTH,2015-05-01T00:00:00Z,2015-05-01T01:00:00Z,3600,OK,
+SITES,HG-03-AUTH,2015-05-01T01:00:00Z,2015-05-01T05:00:00Z,14400,CRITICAL,OK
+SITES,HG-03-AUTH,2015-05-01T05:00:00Z,2015-05-01T23:00:00Z,64800,OK,CRITICAL
+`
+
+	fullurl := "/api/v2/status/Report_A/SITES/HG-03-AUTH" +
+		"?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:00:00Z&intervals=true&"
+
+	for query, expected := range map[string]string{
+		"status=CRITICAL,WARNING":    respCSV,
+		"exclude_status=OK":          respCSV,
+		"status=WARNING":             "group_type,group,from,to,duration_seconds,status,previous_status\n",
+		"exclude_status=OK,CRITICAL": "group_type,group,from,to,duration_seconds,status,previous_status\n",
+	} {
+		// init the response placeholder
+		response := httptest.NewRecorder()
+		// Prepare the request object
+		request, _ := http.NewRequest("GET", fullurl+query, strings.NewReader(""))
+		// add csv accept header
+		request.Header.Set("Accept", "text/csv")
+		// add the authentication token which is seeded in testdb
+		request.Header.Set("x-api-key", "KEY1")
+		// Serve the http request
+		suite.router.ServeHTTP(response, request)
+		// Check that we must have a 200 ok code
+		suite.Equal(200, response.Code, "Internal Server Error")
+		// Compare the expected and actual csv response
+		suite.Equal(expected, response.Body.String(), "Response body mismatch")
+	}
+
+	// The states must be declared in the operations profile
+	response := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", fullurl+"status=BROKEN", strings.NewReader(""))
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("x-api-key", "KEY1")
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 400 bad request code
+	suite.Equal(400, response.Code, "Incorrect HTTP response code")
+	suite.Contains(response.Body.String(), "The state BROKEN is not declared in the operations profile profile2", "Response body mismatch")
+
+}
+
+// TestListStatusEndpointGroupsBuckets tests the timelines collapsed into buckets of fixed size
+func (suite *StatusEndpointGroupsTestSuite) TestListStatusEndpointGroupsBuckets() {
+
+	// The endpoint group is CRITICAL for four of the first six hours
+	respCSV := `group_type,group,from,to,dominant,worst
+SITES,HG-03-AUTH,2015-05-01T00:00:00Z,2015-05-01T06:00:00Z,CRITICAL,CRITICAL
+SITES,HG-03-AUTH,2015-05-01T06:00:00Z,2015-05-01T12:00:00Z,OK,OK
+SITES,HG-03-AUTH,2015-05-01T12:00:00Z,2015-05-01T18:00:00Z,OK,OK
+SITES,HG-03-AUTH,2015-05-01T18:00:00Z,2015-05-01T23:00:00Z,OK,OK
+`
+
+	fullurl := "/api/v2/status/Report_A/SITES/HG-03-AUTH" +
+		"?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:00:00Z&bucket=6h"
+
+	// init the response placeholder
+	response := httptest.NewRecorder()
+	// Prepare the request object
+	request, _ := http.NewRequest("GET", fullurl, strings.NewReader(""))
+	// add csv accept header
+	request.Header.Set("Accept", "text/csv")
+	// add the authentication token which is seeded in testdb
+	request.Header.Set("x-api-key", "KEY1")
+	// Serve the http request
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Compare the expected and actual csv response
+	suite.Equal(respCSV, response.Body.String(), "Response body mismatch")
+
+	for query, expected := range map[string]string{
+		"?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:00:00Z&bucket=2h": "2h is not accepted as bucket, please use one of 1h, 6h or 1d",
+		"?start_time=2015-05-01T00:00:00Z&bucket=1h":                               "Please use both start_time and end_time url parameters and an end_time after the start_time to lay out the buckets",
+	} {
+		response = httptest.NewRecorder()
+		request, _ = http.NewRequest("GET", "/api/v2/status/Report_A/SITES/HG-03-AUTH"+query, strings.NewReader(""))
+		request.Header.Set("Accept", "application/json")
+		request.Header.Set("x-api-key", "KEY1")
+		suite.router.ServeHTTP(response, request)
+		// Check that we must have a 400 bad request code
+		suite.Equal(400, response.Code, "Incorrect HTTP response code")
+		suite.Contains(response.Body.String(), expected, "Response body mismatch")
+	}
+
+	// The states are ranked by the AND operation of the operations profile, which must combine all of them
+	session, _ := mongo.OpenSession(suite.tenantDbConf)
+	defer mongo.CloseSession(session)
+	session.DB(suite.tenantDbConf.Db).C("operations_profiles").Update(
+		bson.M{"name": "profile2"},
+		bson.M{"$pull": bson.M{"operations.0.truth_table": bson.M{"a": "CRITICAL", "b": "DOWNTIME"}}})
+
+	response = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", fullurl, strings.NewReader(""))
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("x-api-key", "KEY1")
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 400 bad request code
+	suite.Equal(400, response.Code, "Incorrect HTTP response code")
+	suite.Contains(response.Body.String(), "Operations profile can not rank its states", "Response body mismatch")
+
+}
+
+// TestListStatusEndpointGroupsExplain tests that the non OK intervals are explained by the timelines below them
+func (suite *StatusEndpointGroupsTestSuite) TestListStatusEndpointGroupsExplain() {
+
+	respXML := `<root>
+ <group name="HG-03-AUTH" type="SITES">
+  <interval from="2015-05-01T00:00:00Z" to="2015-05-01T01:00:00Z" duration_seconds="3600" value="OK" previous_value=""></interval>
+  <interval from="2015-05-01T01:00:00Z" to="2015-05-01T05:00:00Z" duration_seconds="14400" value="CRITICAL" previous_value="OK">
+   <contributor type="service" service="CREAM-CE" status="CRITICAL"></contributor>
+   <contributor type="endpoint" service="CREAM-CE" endpoint="cream01.afroditi.gr" status="CRITICAL"></contributor>
+   <contributor type="metric" service="CREAM-CE" endpoint="cream01.afroditi.gr" metric="emi.cream.CREAMCE-JobSubmit" status="CRITICAL"></contributor>
+   <contributor type="endpoint" service="CREAM-CE" endpoint="cream02.afroditi.gr" status="WARNING"></contributor>
+  </interval>
+  <interval from="2015-05-01T05:00:00Z" to="2015-05-01T23:00:00Z" duration_seconds="64800" value="OK" previous_value="CRITICAL"></interval>
+ </group>
+</root>`
+
+	respCSV := `group_type,group,from,to,duration_seconds,status,previous_status,contributor_type,contributor_service,contributor_endpoint,contributor_metric,contributor_status
+SITES,HG-03-AUTH,2015-05-01T00:00:00Z,2015-05-01T01:00:00Z,3600,OK,,,,,,
+SITES,HG-03-AUTH,2015-05-01T01:00:00Z,2015-05-01T05:00:00Z,14400,CRITICAL,OK,service,CREAM-CE,,,CRITICAL
+SITES,HG-03-AUTH,2015-05-01T01:00:00Z,2015-05-01T05:00:00Z,14400,CRITICAL,OK,endpoint,CREAM-CE,cream01.afroditi.gr,,CRITICAL
+SITES,HG-03-AUTH,2015-05-01T01:00:00Z,2015-05-01T05:00:00Z,14400,CRITICAL,OK,metric,CREAM-CE,cream01.afroditi.gr,emi.cream.CREAMCE-JobSubmit,CRITICAL
+SITES,HG-03-AUTH,2015-05-01T01:00:00Z,2015-05-01T05:00:00Z,14400,CRITICAL,OK,endpoint,CREAM-CE,cream02.afroditi.gr,,WARNING
+SITES,HG-03-AUTH,2015-05-01T05:00:00Z,2015-05-01T23:00:00Z,64800,OK,CRITICAL,,,,,
+`
+
+	fullurl := "/api/v2/status/Report_A/SITES/HG-03-AUTH" +
+		"?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:00:00Z&explain=true"
+
+	// init the response placeholder
+	response := httptest.NewRecorder()
+	// Prepare the request object
+	request, _ := http.NewRequest("GET", fullurl, strings.NewReader(""))
+	// add xml accept header
+	request.Header.Set("Accept", "application/xml")
+	// add the authentication token which is seeded in testdb
+	request.Header.Set("x-api-key", "KEY1")
+	// Serve the http request
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Compare the expected and actual xml response
+	suite.Equal(respXML, response.Body.String(), "Response body mismatch")
+
+	// init the response placeholder
+	response = httptest.NewRecorder()
+	// Prepare the request object
+	request, _ = http.NewRequest("GET", fullurl, strings.NewReader(""))
+	// add csv accept header
+	request.Header.Set("Accept", "text/csv")
+	// add the authentication token which is seeded in testdb
+	request.Header.Set("x-api-key", "KEY1")
+	// Serve the http request
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Compare the expected and actual csv response
+	suite.Equal(respCSV, response.Body.String(), "Response body mismatch")
+
+	// Explanations are attached to intervals and not to buckets
+	response = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", fullurl+"&bucket=1h", strings.NewReader(""))
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("x-api-key", "KEY1")
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 400 bad request code
+	suite.Equal(400, response.Code, "Incorrect HTTP response code")
+	suite.Contains(response.Body.String(), "Explanations are attached to intervals and can not be combined with buckets", "Response body mismatch")
+
+}
+
+// TestListStatusEndpointGroupsGaps tests that long gaps between statuses are filled with the missing state
+func (suite *StatusEndpointGroupsTestSuite) TestListStatusEndpointGroupsGaps() {
+
+	// Statuses that last longer than three hours turn missing
+	respCSV := `group_type,group,timestamp,status
+SITES,HG-03-AUTH,2015-05-01T00:00:00Z,OK
+SITES,HG-03-AUTH,2015-05-01T01:00:00Z,CRITICAL
+SITES,HG-03-AUTH,2015-05-01T04:00:00Z,MISSING
+SITES,HG-03-AUTH,2015-05-01T05:00:00Z,OK
+SITES,HG-03-AUTH,2015-05-01T08:00:00Z,MISSING
+`
+
+	fullurl := "/api/v2/status/Report_A/SITES/HG-03-AUTH" +
+		"?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:00:00Z&fill_gaps=true&gap_threshold=3h"
+
+	// init the response placeholder
+	response := httptest.NewRecorder()
+	// Prepare the request object
+	request, _ := http.NewRequest("GET", fullurl, strings.NewReader(""))
+	// add csv accept header
+	request.Header.Set("Accept", "text/csv")
+	// add the authentication token which is seeded in testdb
+	request.Header.Set("x-api-key", "KEY1")
+	// Serve the http request
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Compare the expected and actual csv response
+	suite.Equal(respCSV, response.Body.String(), "Response body mismatch")
+
+	for query, expected := range map[string]string{
+		"&fill_gaps=true&gap_threshold=-1h": "-1h is not accepted as gap_threshold, please provide a positive duration such as 30m or 2h",
+		"&fill_gaps=maybe":                  "maybe is not accepted as fill_gaps parameter, please provide either true or false",
+		"&fill_gaps=true":                   "Please use the gap_threshold url parameter or set a valid gap_threshold in the report Report_A",
+	} {
+		response = httptest.NewRecorder()
+		request, _ = http.NewRequest("GET", "/api/v2/status/Report_A/SITES/HG-03-AUTH?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:00:00Z"+query, strings.NewReader(""))
+		request.Header.Set("Accept", "application/json")
+		request.Header.Set("x-api-key", "KEY1")
+		suite.router.ServeHTTP(response, request)
+		// Check that we must have a 400 bad request code
+		suite.Equal(400, response.Code, "Incorrect HTTP response code")
+		suite.Contains(response.Body.String(), expected, "Response body mismatch")
+	}
+
+	// The last gap of a timeline lasts until the end_time
+	response = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/api/v2/status/Report_A/SITES/HG-03-AUTH?start_time=2015-05-01T00:00:00Z&fill_gaps=true&gap_threshold=3h", strings.NewReader(""))
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("x-api-key", "KEY1")
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 400 bad request code
+	suite.Equal(400, response.Code, "Incorrect HTTP response code")
+	suite.Contains(response.Body.String(), "Please use the end_time url parameter to set the end of the last gap", "Response body mismatch")
+
+}
+
 // This function is actually called in the end of all tests
 // and clears the test environment.
 // Mainly it's purpose is to drop the testdb
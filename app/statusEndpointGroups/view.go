@@ -25,27 +25,84 @@ package statusEndpointGroups
 import (
 	"encoding/json"
 	"encoding/xml"
+	"fmt"
 	"strings"
+	"time"
 
+	"github.com/ARGOeu/argo-web-api/app/operationsProfiles"
 	"github.com/ARGOeu/argo-web-api/respond"
 )
 
-func createView(results []DataOutput, input InputParams) ([]byte, error) {
+// createView renders the timelines of the results as statuses, or as intervals or buckets when requested.
+// Explained intervals carry the contributors found in the statuses of the services, endpoints and metrics.
+// The states of the buckets and the contributors are ranked by their severity in the operations profile
+func createView(results []DataOutput, contributors []ContributorData, input InputParams, order respond.StateOrder) ([]byte, error) {
 
 	output := []byte("reponse output")
 	err := error(nil)
 
-	docRoot := &rootOUT{}
+	docRoot := &rootOUT{
+		intervals: (input.intervals || input.explain) && input.bucket == 0,
+		buckets:   input.bucket > 0,
+		explain:   input.explain,
+	}
 
 	if len(results) == 0 {
 		if strings.EqualFold(input.format, "application/json") {
 			output, err = json.MarshalIndent(docRoot, " ", "  ")
+		} else if strings.EqualFold(input.format, "text/csv") {
+			output, err = respond.MarshalCSV(docRoot)
 		} else {
 			output, err = xml.MarshalIndent(docRoot, " ", "  ")
 		}
 		return output, err
 	}
 
+	fillTimelines(docRoot, results, input)
+
+	if input.bucket > 0 {
+		for _, timeline := range docRoot.EndpointGroups {
+			timeline.Buckets = respond.CreateBuckets(timeline.Statuses, input.start, input.end, input.bucket, order)
+			timeline.Statuses = nil
+		}
+	} else if docRoot.intervals {
+		statuses := map[string][]ContributorData{}
+		for _, row := range contributors {
+			statuses[row.EndpointGroup] = append(statuses[row.EndpointGroup], row)
+		}
+		for _, timeline := range docRoot.EndpointGroups {
+			timeline.Intervals = respond.CreateIntervals(timeline.Statuses, input.end)
+			if input.explain {
+				lower := contributorTimelines(statuses[timeline.Name])
+				respond.ExplainIntervals(timeline.Intervals, lower, lower.contributor, input.end, order)
+			}
+			timeline.Statuses = nil
+		}
+	}
+
+	output, err = respond.MarshalContent(docRoot, input.format, "", " ")
+	return output, err
+
+}
+
+// createSummaryView renders the time every endpoint group timeline in the results spends in each state of the
+// operations profile between start and the end of the time span
+func createSummaryView(results []DataOutput, input InputParams, start time.Time, profile operationsProfiles.OpsProfile) ([]byte, error) {
+
+	docRoot := &rootOUT{summary: true}
+	fillTimelines(docRoot, results, input)
+
+	for _, timeline := range docRoot.EndpointGroups {
+		timeline.Summary = respond.ComputeSummary(timeline.Statuses, start, input.end, profile.AvailStates, profile.Defaults.Missing)
+		timeline.Statuses = nil
+	}
+
+	return respond.MarshalContent(docRoot, input.format, "", " ")
+}
+
+// fillTimelines adds the statuses of the results to the timelines of the document
+func fillTimelines(docRoot *rootOUT, results []DataOutput, input InputParams) {
+
 	prevEndpointGroup := ""
 
 	var ppEndpointGroup *endpointGroupOUT
@@ -61,16 +118,30 @@ func createView(results []DataOutput, input InputParams) ([]byte, error) {
 			ppEndpointGroup = endpointGroup
 		}
 
-		status := &statusOUT{}
+		status := &respond.TimelineStatus{}
 		status.Timestamp = row.Timestamp
 		status.Value = row.Status
 		ppEndpointGroup.Statuses = append(ppEndpointGroup.Statuses, status)
 
 	}
+}
 
-	output, err = respond.MarshalContent(docRoot, input.format, "", " ")
-	return output, err
+// createStatsView renders the outage statistics of every endpoint group in the results
+func createStatsView(results []DataOutput, input InputParams, start time.Time, end time.Time, outageStates map[string]bool) ([]byte, error) {
+
+	docRoot := &statsRootOUT{}
+
+	for i := 0; i < len(results); {
+		statuses, j := respond.NextTimeline(timelines(results), i)
+		docRoot.EndpointGroups = append(docRoot.EndpointGroups, &endpointGroupStatsOUT{
+			Name:      results[i].EndpointGroup,
+			GroupType: input.groupType,
+			Stats:     respond.ComputeStats(statuses, start, end, outageStates),
+		})
+		i = j
+	}
 
+	return respond.MarshalContent(docRoot, input.format, "", " ")
 }
 
 func createMessageOUT(message string, format string) ([]byte, error) {
@@ -83,3 +154,71 @@ func createMessageOUT(message string, format string) ([]byte, error) {
 	output, err = respond.MarshalContent(docRoot, format, "", " ")
 	return output, err
 }
+
+// CSVRecords flattens the timelines into one record per status, interval, contributor of an interval, bucket or state summary of every endpoint group
+func (docRoot *rootOUT) CSVRecords() [][]string {
+	if docRoot.summary {
+		records := [][]string{{"group_type", "group", "total_seconds", "state", "seconds", "percentage"}}
+		for _, group := range docRoot.EndpointGroups {
+			for _, state := range group.Summary.States {
+				records = append(records, []string{group.GroupType, group.Name, fmt.Sprint(group.Summary.TotalSeconds),
+					state.Name, fmt.Sprint(state.Seconds), fmt.Sprint(state.Percentage)})
+			}
+		}
+		return records
+	}
+	if docRoot.buckets {
+		records := [][]string{{"group_type", "group", "from", "to", "dominant", "worst"}}
+		for _, group := range docRoot.EndpointGroups {
+			for _, bucket := range group.Buckets {
+				records = append(records, []string{group.GroupType, group.Name,
+					bucket.From, bucket.To, bucket.Dominant, bucket.Worst})
+			}
+		}
+		return records
+	}
+	if docRoot.explain {
+		records := [][]string{{"group_type", "group", "from", "to", "duration_seconds", "status", "previous_status",
+			"contributor_type", "contributor_service", "contributor_endpoint", "contributor_metric", "contributor_status"}}
+		for _, group := range docRoot.EndpointGroups {
+			for _, interval := range group.Intervals {
+				record := []string{group.GroupType, group.Name, interval.From, interval.To,
+					fmt.Sprint(interval.Duration), interval.Value, interval.PreviousValue}
+				records = append(records, respond.ExplainRecords(record, interval)...)
+			}
+		}
+		return records
+	}
+	if docRoot.intervals {
+		records := [][]string{{"group_type", "group", "from", "to", "duration_seconds", "status", "previous_status"}}
+		for _, group := range docRoot.EndpointGroups {
+			for _, interval := range group.Intervals {
+				records = append(records, []string{group.GroupType, group.Name, interval.From, interval.To,
+					fmt.Sprint(interval.Duration), interval.Value, interval.PreviousValue})
+			}
+		}
+		return records
+	}
+	records := [][]string{{"group_type", "group", "timestamp", "status"}}
+	for _, group := range docRoot.EndpointGroups {
+		for _, status := range group.Statuses {
+			records = append(records, []string{group.GroupType, group.Name, status.Timestamp, status.Value})
+		}
+	}
+	return records
+}
+
+// CSVRecords renders the message as a single csv record
+func (docRoot *messageOUT) CSVRecords() [][]string {
+	return [][]string{{"message"}, {docRoot.Message}}
+}
+
+// CSVRecords flattens the statistics into one record per endpoint group
+func (docRoot *statsRootOUT) CSVRecords() [][]string {
+	records := [][]string{{"group_type", "group", "outages", "mttr", "mtbf", "longest_outage"}}
+	for _, group := range docRoot.EndpointGroups {
+		records = append(records, []string{group.GroupType, group.Name, fmt.Sprint(group.Stats.Outages),
+			fmt.Sprint(group.Stats.MTTR), fmt.Sprint(group.Stats.MTBF), fmt.Sprint(group.Stats.LongestOutage)})
+	}
+	return records
+}
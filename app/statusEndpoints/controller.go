@@ -25,9 +25,11 @@ package statusEndpoints
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/github.com/gorilla/mux"
 	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2/bson"
+	"github.com/ARGOeu/argo-web-api/app/operationsProfiles"
 	"github.com/ARGOeu/argo-web-api/respond"
 	"github.com/ARGOeu/argo-web-api/utils/authentication"
 	"github.com/ARGOeu/argo-web-api/utils/config"
@@ -48,7 +50,7 @@ func ListEndpointTimelines(r *http.Request, cfg config.Config) (int, http.Header
 
 	//STANDARD DECLARATIONS END
 
-	contentType, err = respond.ParseAcceptHeader(r)
+	contentType, err = respond.ParseAcceptHeader(r, respond.TimelineContentTypes...)
 	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
 
 	if err != nil {
@@ -62,9 +64,16 @@ func ListEndpointTimelines(r *http.Request, cfg config.Config) (int, http.Header
 	vars := mux.Vars(r)
 
 	parsedStart, parsedEnd, errs := respond.ValidateDateRange(urlValues.Get("start_time"), urlValues.Get("end_time"))
+	intervals, end, intervalErrs := respond.ValidateIntervals(urlValues.Get("intervals"), urlValues.Get("end_time"))
+	errs = append(errs, intervalErrs...)
+	bucket, start, bucketErrs := respond.ValidateBucket(urlValues.Get("bucket"), urlValues.Get("start_time"), urlValues.Get("end_time"))
+	errs = append(errs, bucketErrs...)
+	fillGaps, gapThreshold, gapErrs := respond.ValidateFillGaps(urlValues.Get("fill_gaps"), urlValues.Get("gap_threshold"), urlValues.Get("end_time"))
+	errs = append(errs, gapErrs...)
 	if len(errs) > 0 {
 		code = http.StatusBadRequest
 		output = respond.CreateFailureResponseMessage("Bad Request", "400", errs).MarshalTo(contentType)
+		return code, h, output, nil
 	}
 
 	input := InputParams{
@@ -76,6 +85,13 @@ func ListEndpointTimelines(r *http.Request, cfg config.Config) (int, http.Header
 		vars["service_name"],
 		vars["endpoint_name"],
 		contentType,
+		intervals,
+		end,
+		respond.ParseStateFilter(urlValues.Get("status"), urlValues.Get("exclude_status")),
+		bucket,
+		start,
+		fillGaps,
+		gapThreshold,
 	}
 
 	// Call authenticateTenant to check the api key and retrieve
@@ -105,18 +121,171 @@ func ListEndpointTimelines(r *http.Request, cfg config.Config) (int, http.Header
 		return code, h, output, err
 	}
 
-	err = metricCollection.Find(prepareQuery(input, reportID)).All(&results)
+	errs = operationsProfiles.ValidateStateFilter(session, tenantDbConfig.Db, input.report, input.states)
+
+	// Buckets order the states by their severity in the operations profile
+	order := respond.StateOrder{}
+	if input.bucket > 0 {
+		var orderErrs []respond.ErrorResponse
+		order, orderErrs = operationsProfiles.GetStateOrder(session, tenantDbConfig.Db, input.report)
+		errs = append(errs, orderErrs...)
+	}
+
+	// Gaps are filled with the missing state of the operations profile
+	missing := ""
+	if input.fillGaps {
+		var gapErrs []respond.ErrorResponse
+		missing, input.gapThreshold, gapErrs = operationsProfiles.GetGapFilling(session, tenantDbConfig.Db, input.report, input.gapThreshold)
+		errs = append(errs, gapErrs...)
+	}
+
+	if len(errs) > 0 {
+		code = http.StatusBadRequest
+		output = respond.CreateFailureResponseMessage("Bad Request", "400", errs).MarshalTo(contentType)
+		return code, h, output, nil
+	}
+
+	err = metricCollection.Find(prepareQuery(input, reportID)).Sort("endpoint_group", "service", "host", "timestamp").All(&results)
 	if err != nil {
 		code = http.StatusInternalServerError
 		return code, h, output, err
 	}
 
-	output, err = createView(results, input) //Render the results into XML format
+	results = fillMissing(results, input.gapThreshold, missing, input.end)
+	results = filterStates(results, input.states)
+
+	output, err = createView(results, input, order) //Render the results into XML format
 
 	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
 	return code, h, output, err
 }
 
+// ListEndpointSummary returns the time endpoint timelines spend in each state of the operations profile over a time span
+func ListEndpointSummary(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
+
+	//STANDARD DECLARATIONS START
+
+	code := http.StatusOK
+	h := http.Header{}
+	output := []byte("List Endpoint Summary")
+	err := error(nil)
+	contentType := "application/xml"
+	charset := "utf-8"
+
+	//STANDARD DECLARATIONS END
+
+	contentType, err = respond.ParseAcceptHeader(r, respond.TimelineContentTypes...)
+	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
+
+	if err != nil {
+		code = http.StatusNotAcceptable
+		output, _ = respond.MarshalContent(respond.NotAcceptableContentType, contentType, "", " ")
+		return code, h, output, err
+	}
+
+	// Parse the request into the input
+	urlValues := r.URL.Query()
+	vars := mux.Vars(r)
+
+	parsedStart, parsedEnd, errs := respond.ValidateDateRange(urlValues.Get("start_time"), urlValues.Get("end_time"))
+	start, errStart := time.Parse(zuluForm, urlValues.Get("start_time"))
+	end, errEnd := time.Parse(zuluForm, urlValues.Get("end_time"))
+	if len(errs) == 0 && (errStart != nil || errEnd != nil || !end.After(start)) {
+		errs = append(errs, respond.ErrorResponse{
+			Message: "Wrong time span",
+			Code:    "400",
+			Details: "Please use both start_time and end_time url parameters and an end_time after the start_time",
+		})
+	}
+	fillGaps, gapThreshold, gapErrs := respond.ValidateFillGaps(urlValues.Get("fill_gaps"), urlValues.Get("gap_threshold"), urlValues.Get("end_time"))
+	errs = append(errs, gapErrs...)
+
+	input := InputParams{
+		parsedStart,
+		parsedEnd,
+		vars["report_name"],
+		vars["group_type"],
+		vars["group_name"],
+		vars["service_name"],
+		vars["endpoint_name"],
+		contentType,
+		false,
+		end,
+		respond.ParseStateFilter(urlValues.Get("status"), urlValues.Get("exclude_status")),
+		0,
+		start,
+		fillGaps,
+		gapThreshold,
+	}
+
+	// Call authenticateTenant to check the api key and retrieve
+	// the correct tenant db conf
+	tenantDbConfig, err := authentication.AuthenticateTenant(r.Header, cfg)
+	if err != nil {
+		if err.Error() == "Unauthorized" {
+			code = http.StatusUnauthorized
+			out := respond.UnauthorizedMessage
+			output = out.MarshalTo(contentType)
+			return code, h, output, err
+		}
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	session, err := mongo.OpenSession(tenantDbConfig)
+	defer mongo.CloseSession(session)
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	profile, errProfile := operationsProfiles.GetReportProfile(session, tenantDbConfig.Db, input.report)
+	if errProfile != nil {
+		errs = append(errs, respond.ErrorResponse{
+			Message: "Operations profile not found",
+			Code:    "400",
+			Details: fmt.Sprintf("The report %s does not define an existing operations profile", input.report),
+		})
+	}
+	if errProfile == nil {
+		errs = append(errs, input.states.Validate(profile.HasState, profile.Name)...)
+	}
+	if errProfile == nil && input.fillGaps {
+		var gapErrs []respond.ErrorResponse
+		_, input.gapThreshold, gapErrs = operationsProfiles.GetGapFilling(session, tenantDbConfig.Db, input.report, input.gapThreshold)
+		errs = append(errs, gapErrs...)
+	}
+
+	if len(errs) > 0 {
+		code = http.StatusBadRequest
+		output = respond.CreateFailureResponseMessage("Bad Request", "400", errs).MarshalTo(contentType)
+		return code, h, output, nil
+	}
+
+	reportID, err := mongo.GetReportID(session, tenantDbConfig.Db, input.report)
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	results := []DataOutput{}
+	err = session.DB(tenantDbConfig.Db).C("status_endpoints").
+		Find(prepareQuery(input, reportID)).Sort("endpoint_group", "service", "host", "timestamp").All(&results)
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	results = fillMissing(results, input.gapThreshold, profile.Defaults.Missing, input.end)
+	results = filterStates(results, input.states)
+
+	output, err = createSummaryView(results, input, start, profile) //Render the results into JSON/XML format
+
+	return code, h, output, err
+}
+
 func prepareQuery(input InputParams, reportID string) bson.M {
 
 	// prepare the match filter
@@ -22,18 +22,32 @@
 
 package statusEndpoints
 
-import "encoding/xml"
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/ARGOeu/argo-web-api/respond"
+)
+
+const zuluForm = "2006-01-02T15:04:05Z"
 
 // InputParams struct holds as input all the url params of the request
 type InputParams struct {
-	startTime int // UTC time in W3C format
-	endTime   int
-	report    string
-	groupType string
-	group     string
-	service   string
-	hostname  string
-	format    string
+	startTime    int // UTC time in W3C format
+	endTime      int
+	report       string
+	groupType    string
+	group        string
+	service      string
+	hostname     string
+	format       string
+	intervals    bool
+	end          time.Time
+	states       respond.StateFilter
+	bucket       time.Duration
+	start        time.Time
+	fillGaps     bool
+	gapThreshold time.Duration
 }
 
 // DataOutput struct holds the queried data from datastore
@@ -52,6 +66,9 @@ type DataOutput struct {
 type rootOUT struct {
 	XMLName        xml.Name            `xml:"root" json:"-"`
 	EndpointGroups []*endpointGroupOUT `json:"groups"`
+	intervals      bool
+	buckets        bool
+	summary        bool
 }
 
 type endpointGroupOUT struct {
@@ -69,15 +86,12 @@ type serviceOUT struct {
 }
 
 type endpointOUT struct {
-	XMLName  xml.Name     `xml:"endpoint" json:"-"`
-	Name     string       `xml:"name,attr" json:"name"`
-	Statuses []*statusOUT `json:"statuses"`
-}
-
-type statusOUT struct {
-	XMLName   xml.Name `xml:"status" json:"-"`
-	Timestamp string   `xml:"timestamp,attr" json:"timestamp"`
-	Value     string   `xml:"value,attr" json:"value"`
+	XMLName   xml.Name                    `xml:"endpoint" json:"-"`
+	Name      string                      `xml:"name,attr" json:"name"`
+	Statuses  []*respond.TimelineStatus   `json:"statuses,omitempty"`
+	Intervals []*respond.TimelineInterval `json:"intervals,omitempty"`
+	Buckets   []*respond.TimelineBucket   `json:"buckets,omitempty"`
+	Summary   *respond.TimelineSummary    `json:"summary,omitempty"`
 }
 
 // Message struct to hold the json/xml response
@@ -85,3 +99,38 @@ type messageOUT struct {
 	XMLName xml.Name `xml:"root" json:"-"`
 	Message string   `xml:"message" json:"message"`
 }
+
+// timelines keys the statuses of the results by endpoint for the shared timeline helpers
+type timelines []DataOutput
+
+func (t timelines) Len() int { return len(t) }
+func (t timelines) Entity(i int) string {
+	return t[i].EndpointGroup + "/" + t[i].Service + "/" + t[i].Hostname
+}
+func (t timelines) Timestamp(i int) string { return t[i].Timestamp }
+func (t timelines) State(i int) string     { return t[i].Status }
+
+// filterStates keeps the timelines of the endpoints that enter one of the requested states and drops the rest
+func filterStates(results []DataOutput, states respond.StateFilter) []DataOutput {
+	filtered := []DataOutput{}
+	for _, i := range states.Filter(timelines(results)) {
+		filtered = append(filtered, results[i])
+	}
+	return filtered
+}
+
+// fillMissing adds a status in the missing state to the timelines of the endpoints in the results
+// wherever a status is not followed by another one for longer than the threshold
+func fillMissing(results []DataOutput, threshold time.Duration, missing string, end time.Time) []DataOutput {
+	gaps := respond.FindGaps(timelines(results), threshold, missing, end)
+	filled := []DataOutput{}
+	for i, row := range results {
+		filled = append(filled, row)
+		if len(gaps) > 0 && gaps[0].After == i {
+			row.Timestamp, row.Status = gaps[0].Timestamp, missing
+			filled = append(filled, row)
+			gaps = gaps[1:]
+		}
+	}
+	return filled
+}
@@ -191,6 +191,48 @@ func (suite *StatusEndpointsTestSuite) SetupTest() {
 				"name":  "name2",
 				"value": "value2"},
 		}})
+
+	// seed the operations profile of the report
+	c = session.DB(suite.tenantDbConf.Db).C("operations_profiles")
+	c.Insert(bson.M{
+		"id":               "6ac7d684-1f8e-4a02-a502-720e8f11e523",
+		"name":             "profile2",
+		"available_states": []string{"OK", "WARNING", "UNKNOWN", "MISSING", "CRITICAL", "DOWNTIME"},
+		"defaults": bson.M{
+			"down":    "DOWNTIME",
+			"missing": "MISSING",
+			"unknown": "UNKNOWN",
+		},
+		"operations": []bson.M{
+			bson.M{
+				"name": "AND",
+				"truth_table": []bson.M{
+					bson.M{"a": "OK", "b": "OK", "x": "OK"},
+					bson.M{"a": "OK", "b": "WARNING", "x": "WARNING"},
+					bson.M{"a": "OK", "b": "UNKNOWN", "x": "UNKNOWN"},
+					bson.M{"a": "OK", "b": "MISSING", "x": "MISSING"},
+					bson.M{"a": "OK", "b": "CRITICAL", "x": "CRITICAL"},
+					bson.M{"a": "OK", "b": "DOWNTIME", "x": "DOWNTIME"},
+					bson.M{"a": "WARNING", "b": "WARNING", "x": "WARNING"},
+					bson.M{"a": "WARNING", "b": "UNKNOWN", "x": "UNKNOWN"},
+					bson.M{"a": "WARNING", "b": "MISSING", "x": "MISSING"},
+					bson.M{"a": "WARNING", "b": "CRITICAL", "x": "CRITICAL"},
+					bson.M{"a": "WARNING", "b": "DOWNTIME", "x": "DOWNTIME"},
+					bson.M{"a": "UNKNOWN", "b": "UNKNOWN", "x": "UNKNOWN"},
+					bson.M{"a": "UNKNOWN", "b": "MISSING", "x": "MISSING"},
+					bson.M{"a": "UNKNOWN", "b": "CRITICAL", "x": "CRITICAL"},
+					bson.M{"a": "UNKNOWN", "b": "DOWNTIME", "x": "DOWNTIME"},
+					bson.M{"a": "MISSING", "b": "MISSING", "x": "MISSING"},
+					bson.M{"a": "MISSING", "b": "CRITICAL", "x": "CRITICAL"},
+					bson.M{"a": "MISSING", "b": "DOWNTIME", "x": "DOWNTIME"},
+					bson.M{"a": "CRITICAL", "b": "CRITICAL", "x": "CRITICAL"},
+					bson.M{"a": "CRITICAL", "b": "DOWNTIME", "x": "CRITICAL"},
+					bson.M{"a": "DOWNTIME", "b": "DOWNTIME", "x": "DOWNTIME"},
+				},
+			},
+		},
+	})
+
 	// seed the status detailed metric data
 	c = session.DB(suite.tenantDbConf.Db).C("status_endpoints")
 	c.Insert(bson.M{
@@ -476,6 +518,313 @@ func (suite *StatusEndpointsTestSuite) TestListStatusEndpoints() {
 
 }
 
+// TestListStatusEndpointsCSV tests that the timelines are flattened into csv records
+func (suite *StatusEndpointsTestSuite) TestListStatusEndpointsCSV() {
+	respCSV := `group_type,group,service,endpoint,timestamp,status
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,2015-05-01T00:00:00Z,OK
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,2015-05-01T01:00:00Z,CRITICAL
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,2015-05-01T05:00:00Z,OK
+`
+
+	fullurl := "/api/v2/status/Report_A/SITES/HG-03-AUTH/services/CREAM-CE/endpoints/cream01.afroditi.gr" +
+		"?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:00:00Z"
+
+	// init the response placeholder
+	response := httptest.NewRecorder()
+	// Prepare the request object
+	request, _ := http.NewRequest("GET", fullurl, strings.NewReader(""))
+	// add csv accept header
+	request.Header.Set("Accept", "text/csv")
+	// add the authentication token which is seeded in testdb
+	request.Header.Set("x-api-key", "KEY1")
+	// Serve the http request
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Check the negotiated content type
+	suite.Equal("text/csv; charset=utf-8", response.Header().Get("Content-Type"), "Content type mismatch")
+	// Compare the expected and actual csv response
+	suite.Equal(respCSV, response.Body.String(), "Response body mismatch")
+
+}
+
+// TestListStatusEndpointsIntervals tests that the timelines are rendered as intervals clipped at the end_time
+func (suite *StatusEndpointsTestSuite) TestListStatusEndpointsIntervals() {
+	respXML := `<root>
+ <group name="HG-03-AUTH" type="SITES">
+  <group name="CREAM-CE" type="service">
+   <endpoint name="cream01.afroditi.gr">
+    <interval from="2015-05-01T00:00:00Z" to="2015-05-01T01:00:00Z" duration_seconds="3600" value="OK" previous_value=""></interval>
+    <interval from="2015-05-01T01:00:00Z" to="2015-05-01T05:00:00Z" duration_seconds="14400" value="CRITICAL" previous_value="OK"></interval>
+    <interval from="2015-05-01T05:00:00Z" to="2015-05-01T23:00:00Z" duration_seconds="64800" value="OK" previous_value="CRITICAL"></interval>
+   </endpoint>
+  </group>
+ </group>
+</root>`
+
+	respCSV := `group_type,group,service,endpoint,from,to,duration_seconds,status,previous_status
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,2015-05-01T00:00:00Z,2015-05-01T01:00:00Z,3600,OK,
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,2015-05-01T01:00:00Z,2015-05-01T05:00:00Z,14400,CRITICAL,OK
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,2015-05-01T05:00:00Z,2015-05-01T23:00:00Z,64800,OK,CRITICAL
+`
+
+	fullurl := "/api/v2/status/Report_A/SITES/HG-03-AUTH/services/CREAM-CE/endpoints/cream01.afroditi.gr" +
+		"?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:00:00Z&intervals=true"
+
+	// init the response placeholder
+	response := httptest.NewRecorder()
+	// Prepare the request object
+	request, _ := http.NewRequest("GET", fullurl, strings.NewReader(""))
+	// add xml accept header
+	request.Header.Set("Accept", "application/xml")
+	// add the authentication token which is seeded in testdb
+	request.Header.Set("x-api-key", "KEY1")
+	// Serve the http request
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Compare the expected and actual xml response
+	suite.Equal(respXML, response.Body.String(), "Response body mismatch")
+
+	// init the response placeholder
+	response = httptest.NewRecorder()
+	// Prepare the request object
+	request, _ = http.NewRequest("GET", fullurl, strings.NewReader(""))
+	// add csv accept header
+	request.Header.Set("Accept", "text/csv")
+	// add the authentication token which is seeded in testdb
+	request.Header.Set("x-api-key", "KEY1")
+	// Serve the http request
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Compare the expected and actual csv response
+	suite.Equal(respCSV, response.Body.String(), "Response body mismatch")
+
+	// Intervals can not be clipped without an end_time
+	response = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/api/v2/status/Report_A/SITES/HG-03-AUTH/services/CREAM-CE/endpoints/cream01.afroditi.gr?start_time=2015-05-01T00:00:00Z&intervals=true", strings.NewReader(""))
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("x-api-key", "KEY1")
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 400 bad request code
+	suite.Equal(400, response.Code, "Incorrect HTTP response code")
+	suite.Contains(response.Body.String(), "Please use the end_time url parameter to set the end of the last interval", "Response body mismatch")
+
+}
+
+// TestListStatusEndpointsSummary tests the time spent in each state of the operations profile
+func (suite *StatusEndpointsTestSuite) TestListStatusEndpointsSummary() {
+	respXML := `<root>
+ <group name="HG-03-AUTH" type="SITES">
+  <group name="CREAM-CE" type="service">
+   <endpoint name="cream01.afroditi.gr">
+    <summary total_seconds="82800">
+     <state name="OK" seconds="68400" percentage="82.6086956521739"></state>
+     <state name="WARNING" seconds="0" percentage="0"></state>
+     <state name="UNKNOWN" seconds="0" percentage="0"></state>
+     <state name="MISSING" seconds="0" percentage="0"></state>
+     <state name="CRITICAL" seconds="14400" percentage="17.391304347826086"></state>
+     <state name="DOWNTIME" seconds="0" percentage="0"></state>
+    </summary>
+   </endpoint>
+  </group>
+ </group>
+</root>`
+
+	respCSV := `group_type,group,service,endpoint,total_seconds,state,seconds,percentage
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,82800,OK,68400,82.6086956521739
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,82800,WARNING,0,0
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,82800,UNKNOWN,0,0
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,82800,MISSING,0,0
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,82800,CRITICAL,14400,17.391304347826086
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,82800,DOWNTIME,0,0
+`
+
+	fullurl := "/api/v2/status/Report_A/SITES/HG-03-AUTH/services/CREAM-CE/endpoints/cream01.afroditi.gr/summary" +
+		"?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:00:00Z"
+
+	// init the response placeholder
+	response := httptest.NewRecorder()
+	// Prepare the request object
+	request, _ := http.NewRequest("GET", fullurl, strings.NewReader(""))
+	// add xml accept header
+	request.Header.Set("Accept", "application/xml")
+	// add the authentication token which is seeded in testdb
+	request.Header.Set("x-api-key", "KEY1")
+	// Serve the http request
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Compare the expected and actual xml response
+	suite.Equal(respXML, response.Body.String(), "Response body mismatch")
+
+	// init the response placeholder
+	response = httptest.NewRecorder()
+	// Prepare the request object
+	request, _ = http.NewRequest("GET", fullurl, strings.NewReader(""))
+	// add csv accept header
+	request.Header.Set("Accept", "text/csv")
+	// add the authentication token which is seeded in testdb
+	request.Header.Set("x-api-key", "KEY1")
+	// Serve the http request
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Compare the expected and actual csv response
+	suite.Equal(respCSV, response.Body.String(), "Response body mismatch")
+
+	// The summary needs both ends of the time span
+	response = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/api/v2/status/Report_A/SITES/HG-03-AUTH/services/CREAM-CE/endpoints/cream01.afroditi.gr/summary?start_time=2015-05-01T00:00:00Z", strings.NewReader(""))
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("x-api-key", "KEY1")
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 400 bad request code
+	suite.Equal(400, response.Code, "Incorrect HTTP response code")
+	suite.Contains(response.Body.String(), "Please use both start_time and end_time url parameters and an end_time after the start_time", "Response body mismatch")
+
+}
+
+// TestListStatusEndpointsStates tests that only the timelines entering the requested states are kept
+func (suite *StatusEndpointsTestSuite) TestListStatusEndpointsStates() {
+
+	// The endpoint enters the CRITICAL state so its whole timeline is kept
+	respCSV := `group_type,group,service,endpoint,from,to,duration_seconds,status,previous_status
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,2015-05-01T00:00:00Z,2015-05-01T01:00:00Z,3600,OK,
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,2015-05-01T01:00:00Z,2015-05-01T05:00:00Z,14400,CRITICAL,OK
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,2015-05-01T05:00:00Z,2015-05-01T23:00:00Z,64800,OK,CRITICAL
+`
+
+	fullurl := "/api/v2/status/Report_A/SITES/HG-03-AUTH/services/CREAM-CE/endpoints/cream01.afroditi.gr" +
+		"?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:00:00Z&intervals=true&"
+
+	for query, expected := range map[string]string{
+		"status=CRITICAL,WARNING":    respCSV,
+		"exclude_status=OK":          respCSV,
+		"status=WARNING":             "group_type,group,service,endpoint,from,to,duration_seconds,status,previous_status\n",
+		"exclude_status=OK,CRITICAL": "group_type,group,service,endpoint,from,to,duration_seconds,status,previous_status\n",
+	} {
+		// init the response placeholder
+		response := httptest.NewRecorder()
+		// Prepare the request object
+		request, _ := http.NewRequest("GET", fullurl+query, strings.NewReader(""))
+		// add csv accept header
+		request.Header.Set("Accept", "text/csv")
+		// add the authentication token which is seeded in testdb
+		request.Header.Set("x-api-key", "KEY1")
+		// Serve the http request
+		suite.router.ServeHTTP(response, request)
+		// Check that we must have a 200 ok code
+		suite.Equal(200, response.Code, "Internal Server Error")
+		// Compare the expected and actual csv response
+		suite.Equal(expected, response.Body.String(), "Response body mismatch")
+	}
+
+	// The states must be declared in the operations profile
+	response := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", fullurl+"status=BROKEN", strings.NewReader(""))
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("x-api-key", "KEY1")
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 400 bad request code
+	suite.Equal(400, response.Code, "Incorrect HTTP response code")
+	suite.Contains(response.Body.String(), "The state BROKEN is not declared in the operations profile profile2", "Response body mismatch")
+
+}
+
+// TestListStatusEndpointsBuckets tests the timelines collapsed into buckets of fixed size
+func (suite *StatusEndpointsTestSuite) TestListStatusEndpointsBuckets() {
+
+	// The endpoint is CRITICAL for four of the first six hours
+	respCSV := `group_type,group,service,endpoint,from,to,dominant,worst
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,2015-05-01T00:00:00Z,2015-05-01T06:00:00Z,CRITICAL,CRITICAL
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,2015-05-01T06:00:00Z,2015-05-01T12:00:00Z,OK,OK
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,2015-05-01T12:00:00Z,2015-05-01T18:00:00Z,OK,OK
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,2015-05-01T18:00:00Z,2015-05-01T23:00:00Z,OK,OK
+`
+
+	fullurl := "/api/v2/status/Report_A/SITES/HG-03-AUTH/services/CREAM-CE/endpoints/cream01.afroditi.gr" +
+		"?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:00:00Z&bucket=6h"
+
+	// init the response placeholder
+	response := httptest.NewRecorder()
+	// Prepare the request object
+	request, _ := http.NewRequest("GET", fullurl, strings.NewReader(""))
+	// add csv accept header
+	request.Header.Set("Accept", "text/csv")
+	// add the authentication token which is seeded in testdb
+	request.Header.Set("x-api-key", "KEY1")
+	// Serve the http request
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Compare the expected and actual csv response
+	suite.Equal(respCSV, response.Body.String(), "Response body mismatch")
+
+	for query, expected := range map[string]string{
+		"?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:00:00Z&bucket=2h": "2h is not accepted as bucket, please use one of 1h, 6h or 1d",
+		"?start_time=2015-05-01T00:00:00Z&bucket=1h":                               "Please use both start_time and end_time url parameters and an end_time after the start_time to lay out the buckets",
+	} {
+		response = httptest.NewRecorder()
+		request, _ = http.NewRequest("GET", "/api/v2/status/Report_A/SITES/HG-03-AUTH/services/CREAM-CE/endpoints/cream01.afroditi.gr"+query, strings.NewReader(""))
+		request.Header.Set("Accept", "application/json")
+		request.Header.Set("x-api-key", "KEY1")
+		suite.router.ServeHTTP(response, request)
+		// Check that we must have a 400 bad request code
+		suite.Equal(400, response.Code, "Incorrect HTTP response code")
+		suite.Contains(response.Body.String(), expected, "Response body mismatch")
+	}
+
+}
+
+// TestListStatusEndpointsGaps tests that long gaps between statuses are filled with the missing state
+func (suite *StatusEndpointsTestSuite) TestListStatusEndpointsGaps() {
+
+	// Statuses that last longer than three hours turn missing
+	respCSV := `group_type,group,service,endpoint,timestamp,status
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,2015-05-01T00:00:00Z,OK
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,2015-05-01T01:00:00Z,CRITICAL
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,2015-05-01T04:00:00Z,MISSING
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,2015-05-01T05:00:00Z,OK
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,2015-05-01T08:00:00Z,MISSING
+`
+
+	fullurl := "/api/v2/status/Report_A/SITES/HG-03-AUTH/services/CREAM-CE/endpoints/cream01.afroditi.gr" +
+		"?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:00:00Z&fill_gaps=true&gap_threshold=3h"
+
+	// init the response placeholder
+	response := httptest.NewRecorder()
+	// Prepare the request object
+	request, _ := http.NewRequest("GET", fullurl, strings.NewReader(""))
+	// add csv accept header
+	request.Header.Set("Accept", "text/csv")
+	// add the authentication token which is seeded in testdb
+	request.Header.Set("x-api-key", "KEY1")
+	// Serve the http request
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Compare the expected and actual csv response
+	suite.Equal(respCSV, response.Body.String(), "Response body mismatch")
+
+	for query, expected := range map[string]string{
+		"&fill_gaps=true&gap_threshold=-1h": "-1h is not accepted as gap_threshold, please provide a positive duration such as 30m or 2h",
+		"&fill_gaps=maybe":                  "maybe is not accepted as fill_gaps parameter, please provide either true or false",
+		"&fill_gaps=true":                   "Please use the gap_threshold url parameter or set a valid gap_threshold in the report Report_A",
+	} {
+		response = httptest.NewRecorder()
+		request, _ = http.NewRequest("GET", "/api/v2/status/Report_A/SITES/HG-03-AUTH/services/CREAM-CE/endpoints/cream01.afroditi.gr?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:00:00Z"+query, strings.NewReader(""))
+		request.Header.Set("Accept", "application/json")
+		request.Header.Set("x-api-key", "KEY1")
+		suite.router.ServeHTTP(response, request)
+		// Check that we must have a 400 bad request code
+		suite.Equal(400, response.Code, "Incorrect HTTP response code")
+		suite.Contains(response.Body.String(), expected, "Response body mismatch")
+	}
+
+}
+
 // This function is actually called in the end of all tests
 // and clears the test environment.
 // Mainly it's purpose is to drop the testdb
@@ -25,27 +25,71 @@ package statusEndpoints
 import (
 	"encoding/json"
 	"encoding/xml"
+	"fmt"
 	"strings"
+	"time"
 
+	"github.com/ARGOeu/argo-web-api/app/operationsProfiles"
 	"github.com/ARGOeu/argo-web-api/respond"
 )
 
-func createView(results []DataOutput, input InputParams) ([]byte, error) {
+// createView renders the timelines of the results as statuses, or as intervals or buckets when requested.
+// The states of the buckets are ranked by their severity in the operations profile
+func createView(results []DataOutput, input InputParams, order respond.StateOrder) ([]byte, error) {
 
 	output := []byte("reponse output")
 	err := error(nil)
 
-	docRoot := &rootOUT{}
+	docRoot := &rootOUT{intervals: input.intervals && input.bucket == 0, buckets: input.bucket > 0}
 
 	if len(results) == 0 {
 		if strings.EqualFold(input.format, "application/json") {
 			output, err = json.MarshalIndent(docRoot, " ", "  ")
+		} else if strings.EqualFold(input.format, "text/csv") {
+			output, err = respond.MarshalCSV(docRoot)
 		} else {
 			output, err = xml.MarshalIndent(docRoot, " ", "  ")
 		}
 		return output, err
 	}
 
+	fillTimelines(docRoot, results, input)
+
+	if input.bucket > 0 {
+		for _, timeline := range docRoot.endpoints() {
+			timeline.Buckets = respond.CreateBuckets(timeline.Statuses, input.start, input.end, input.bucket, order)
+			timeline.Statuses = nil
+		}
+	} else if input.intervals {
+		for _, timeline := range docRoot.endpoints() {
+			timeline.Intervals = respond.CreateIntervals(timeline.Statuses, input.end)
+			timeline.Statuses = nil
+		}
+	}
+
+	output, err = respond.MarshalContent(docRoot, input.format, "", " ")
+	return output, err
+
+}
+
+// createSummaryView renders the time every endpoint timeline in the results spends in each state of the
+// operations profile between start and the end of the time span
+func createSummaryView(results []DataOutput, input InputParams, start time.Time, profile operationsProfiles.OpsProfile) ([]byte, error) {
+
+	docRoot := &rootOUT{summary: true}
+	fillTimelines(docRoot, results, input)
+
+	for _, timeline := range docRoot.endpoints() {
+		timeline.Summary = respond.ComputeSummary(timeline.Statuses, start, input.end, profile.AvailStates, profile.Defaults.Missing)
+		timeline.Statuses = nil
+	}
+
+	return respond.MarshalContent(docRoot, input.format, "", " ")
+}
+
+// fillTimelines adds the statuses of the results to the timelines of the document
+func fillTimelines(docRoot *rootOUT, results []DataOutput, input InputParams) {
+
 	prevHostname := ""
 	prevEndpointGroup := ""
 	prevService := ""
@@ -83,16 +127,23 @@ func createView(results []DataOutput, input InputParams) ([]byte, error) {
 			ppHost = host
 		}
 
-		status := &statusOUT{}
+		status := &respond.TimelineStatus{}
 		status.Timestamp = row.Timestamp
 		status.Value = row.Status
 		ppHost.Statuses = append(ppHost.Statuses, status)
 
 	}
+}
 
-	output, err = respond.MarshalContent(docRoot, input.format, "", " ")
-	return output, err
-
+// endpoints collects the endpoints of all services of all endpoint groups
+func (docRoot *rootOUT) endpoints() []*endpointOUT {
+	endpoints := []*endpointOUT{}
+	for _, endpointGroup := range docRoot.EndpointGroups {
+		for _, service := range endpointGroup.Services {
+			endpoints = append(endpoints, service.Endpoints...)
+		}
+	}
+	return endpoints
 }
 
 func createMessageOUT(message string, format string) ([]byte, error) {
@@ -106,3 +157,66 @@ func createMessageOUT(message string, format string) ([]byte, error) {
 	output, err = respond.MarshalContent(docRoot, format, "", " ")
 	return output, err
 }
+
+// CSVRecords flattens the timelines into one record per status, interval, bucket or state summary of every endpoint
+func (docRoot *rootOUT) CSVRecords() [][]string {
+	if docRoot.summary {
+		records := [][]string{{"group_type", "group", "service", "endpoint", "total_seconds", "state", "seconds", "percentage"}}
+		for _, group := range docRoot.EndpointGroups {
+			for _, service := range group.Services {
+				for _, endpoint := range service.Endpoints {
+					for _, state := range endpoint.Summary.States {
+						records = append(records, []string{group.GroupType, group.Name, service.Name, endpoint.Name,
+							fmt.Sprint(endpoint.Summary.TotalSeconds), state.Name, fmt.Sprint(state.Seconds), fmt.Sprint(state.Percentage)})
+					}
+				}
+			}
+		}
+		return records
+	}
+	if docRoot.buckets {
+		records := [][]string{{"group_type", "group", "service", "endpoint", "from", "to", "dominant", "worst"}}
+		for _, group := range docRoot.EndpointGroups {
+			for _, service := range group.Services {
+				for _, endpoint := range service.Endpoints {
+					for _, bucket := range endpoint.Buckets {
+						records = append(records, []string{group.GroupType, group.Name, service.Name, endpoint.Name,
+							bucket.From, bucket.To, bucket.Dominant, bucket.Worst})
+					}
+				}
+			}
+		}
+		return records
+	}
+	if docRoot.intervals {
+		records := [][]string{{"group_type", "group", "service", "endpoint", "from", "to", "duration_seconds", "status", "previous_status"}}
+		for _, group := range docRoot.EndpointGroups {
+			for _, service := range group.Services {
+				for _, endpoint := range service.Endpoints {
+					for _, interval := range endpoint.Intervals {
+						records = append(records, []string{group.GroupType, group.Name, service.Name, endpoint.Name,
+							interval.From, interval.To, fmt.Sprint(interval.Duration), interval.Value, interval.PreviousValue})
+					}
+				}
+			}
+		}
+		return records
+	}
+	records := [][]string{{"group_type", "group", "service", "endpoint", "timestamp", "status"}}
+	for _, group := range docRoot.EndpointGroups {
+		for _, service := range group.Services {
+			for _, endpoint := range service.Endpoints {
+				for _, status := range endpoint.Statuses {
+					records = append(records, []string{group.GroupType, group.Name, service.Name, endpoint.Name,
+						status.Timestamp, status.Value})
+				}
+			}
+		}
+	}
+	return records
+}
+
+// CSVRecords renders the message as a single csv record
+func (docRoot *messageOUT) CSVRecords() [][]string {
+	return [][]string{{"message"}, {docRoot.Message}}
+}
@@ -0,0 +1,252 @@
+/*
+ * Copyright (c) 2015 GRNET S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the
+ * License. You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an "AS
+ * IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language
+ * governing permissions and limitations under the License.
+ *
+ * The views and conclusions contained in the software and
+ * documentation are those of the authors and should not be
+ * interpreted as representing official policies, either expressed
+ * or implied, of GRNET S.A.
+ *
+ */
+
+package statusEvents
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/github.com/gorilla/mux"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2/bson"
+	"github.com/ARGOeu/argo-web-api/app/operationsProfiles"
+	"github.com/ARGOeu/argo-web-api/respond"
+	"github.com/ARGOeu/argo-web-api/utils/authentication"
+	"github.com/ARGOeu/argo-web-api/utils/config"
+	"github.com/ARGOeu/argo-web-api/utils/logging"
+	"github.com/ARGOeu/argo-web-api/utils/mongo"
+)
+
+// pollInterval is the time between two successive polls of the status collections
+var pollInterval = 5 * time.Second
+
+// StreamEvents returns a handler that keeps the connection open and pushes the new state changes
+// of a report as server-sent events, until the client goes away
+func StreamEvents(cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		// The events are always streamed, the Accept header only picks the format of the errors
+		contentType, err := respond.ParseAcceptHeader(r, eventContentTypes...)
+		if err != nil {
+			output, _ := respond.MarshalContent(respond.NotAcceptableContentType, contentType, "", " ")
+			w.Header().Set("Content-Type", fmt.Sprintf("%s; charset=utf-8", contentType))
+			w.WriteHeader(http.StatusNotAcceptable)
+			w.Write(output)
+			return
+		}
+		if contentType == "text/event-stream" {
+			contentType = "application/json"
+		}
+
+		code, output, stream := openStream(r, cfg, contentType)
+		if code != http.StatusOK {
+			w.Header().Set("Content-Type", fmt.Sprintf("%s; charset=utf-8", contentType))
+			w.WriteHeader(code)
+			w.Write(output)
+			return
+		}
+		defer mongo.CloseSession(stream.session)
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			events, err := stream.poll()
+			if err != nil {
+				logging.HandleError(err)
+				return
+			}
+
+			for _, event := range events {
+				if err := writeEvent(w, event); err != nil {
+					return
+				}
+			}
+			if len(events) == 0 {
+				// keep idle connections from being closed by proxies
+				fmt.Fprint(w, ": keep-alive\n\n")
+			}
+			flusher.Flush()
+
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}
+}
+
+// openStream validates the request, authenticates the tenant and resolves the report before any
+// event is streamed. On failure it returns the status code and the message to answer with, marshaled to contentType
+func openStream(r *http.Request, cfg config.Config, contentType string) (int, []byte, *eventStream) {
+
+	// Parse the request into the input
+	urlValues := r.URL.Query()
+	vars := mux.Vars(r)
+
+	input := InputParams{
+		report:  vars["report_name"],
+		group:   urlValues.Get("group"),
+		service: urlValues.Get("service"),
+		state:   urlValues.Get("state"),
+		after:   bson.NewObjectIdWithTime(time.Now()),
+	}
+
+	// A reconnecting client resumes after the last event it received
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		if !bson.IsObjectIdHex(lastEventID) {
+			errs := []respond.ErrorResponse{{
+				Message: "Last-Event-ID parsing error",
+				Code:    "400",
+				Details: fmt.Sprintf("%s is not the id of an event, please use the id of the last event received", lastEventID),
+			}}
+			return http.StatusBadRequest, respond.CreateFailureResponseMessage("Bad Request", "400", errs).MarshalTo(contentType), nil
+		}
+		input.after = bson.ObjectIdHex(lastEventID)
+	}
+
+	// Call authenticateTenant to check the api key and retrieve
+	// the correct tenant db conf
+	tenantDbConfig, err := authentication.AuthenticateTenant(r.Header, cfg)
+	if err != nil {
+		if err.Error() == "Unauthorized" {
+			out := respond.UnauthorizedMessage
+			return http.StatusUnauthorized, out.MarshalTo(contentType), nil
+		}
+		logging.HandleError(err)
+		return http.StatusInternalServerError, nil, nil
+	}
+
+	session, err := mongo.OpenSession(tenantDbConfig)
+	if err != nil {
+		logging.HandleError(err)
+		return http.StatusInternalServerError, nil, nil
+	}
+
+	reportID, err := mongo.GetReportID(session, tenantDbConfig.Db, input.report)
+	if err != nil {
+		mongo.CloseSession(session)
+		message := "The report with the name " + input.report + " does not exist"
+		output, _ := createMessageOUT(message, contentType)
+		return http.StatusBadRequest, output, nil
+	}
+
+	if input.state != "" {
+		profile, err := operationsProfiles.GetReportProfile(session, tenantDbConfig.Db, input.report)
+		if err != nil || !profile.HasState(input.state) {
+			mongo.CloseSession(session)
+			errs := []respond.ErrorResponse{{
+				Message: "Unknown state",
+				Code:    "400",
+				Details: fmt.Sprintf("The state %s is not declared in the operations profile of the report %s", input.state, input.report),
+			}}
+			return http.StatusBadRequest, respond.CreateFailureResponseMessage("Bad Request", "400", errs).MarshalTo(contentType), nil
+		}
+	}
+
+	stream := &eventStream{
+		session:  session,
+		db:       tenantDbConfig.Db,
+		reportID: reportID,
+		input:    input,
+		last:     map[string]string{},
+	}
+	return http.StatusOK, nil, stream
+}
+
+// poll returns the state changes stored since the previous poll that pass the state filter
+// and moves the stream past them
+func (stream *eventStream) poll() ([]DataOutput, error) {
+	stream.session.Refresh()
+	events, err := queryEvents(stream.session, stream.db, stream.reportID, stream.input)
+	if err != nil {
+		return nil, err
+	}
+
+	// the state filter applies after the state changes are found, so that
+	// returning to a filtered state after another state is still reported
+	filtered := []DataOutput{}
+	for _, event := range stateChanges(events, stream.last) {
+		if stream.input.state == "" || event.Status == stream.input.state {
+			filtered = append(filtered, event)
+		}
+	}
+	if len(events) > 0 {
+		stream.input.after = events[len(events)-1].ID
+	}
+	return filtered, nil
+}
+
+// queryEvents collects the statuses stored after input.after in every status collection that
+// the filters apply to and returns them in the order they were stored
+func queryEvents(session *mgo.Session, db string, reportID string, input InputParams) ([]DataOutput, error) {
+	events := []DataOutput{}
+	for _, src := range sources {
+		// endpoint groups have no service to filter on
+		if input.service != "" && src.kind == "endpoint_group" {
+			continue
+		}
+
+		results := []DataOutput{}
+		err := session.DB(db).C(src.collection).Find(prepareQuery(input, reportID)).Sort("_id").All(&results)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range results {
+			result.Kind = src.kind
+			events = append(events, result)
+		}
+	}
+	sort.Stable(byID(events))
+	return events, nil
+}
+
+// prepareQuery matches the statuses of the report stored after input.after that pass the group and service filters.
+// The statuses are paged by their ids rather than their timestamps, since late results are stored with past timestamps
+func prepareQuery(input InputParams, reportID string) bson.M {
+	query := bson.M{
+		"report": reportID,
+		"_id":    bson.M{"$gt": input.after},
+	}
+	if input.group != "" {
+		query["endpoint_group"] = input.group
+	}
+	if input.service != "" {
+		query["service"] = input.service
+	}
+	return query
+}
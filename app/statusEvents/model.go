@@ -0,0 +1,119 @@
+/*
+ * Copyright (c) 2015 GRNET S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the
+ * License. You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an "AS
+ * IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language
+ * governing permissions and limitations under the License.
+ *
+ * The views and conclusions contained in the software and
+ * documentation are those of the authors and should not be
+ * interpreted as representing official policies, either expressed
+ * or implied, of GRNET S.A.
+ *
+ */
+
+package statusEvents
+
+import (
+	"encoding/xml"
+
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2/bson"
+)
+
+// eventContentTypes are the content types a client may accept: the events are streamed and
+// the errors are marshaled to json or xml
+var eventContentTypes = []string{
+	"application/xml",
+	"application/json",
+	"text/event-stream",
+}
+
+// InputParams struct holds as input all the url params of the request
+type InputParams struct {
+	report  string
+	group   string
+	service string
+	state   string
+	after   bson.ObjectId
+}
+
+// eventStream holds the state of a client connection between two polls: the id of the last
+// status polled is kept in input.after and the last state of everything pushed in last
+type eventStream struct {
+	session  *mgo.Session
+	db       string
+	reportID string
+	input    InputParams
+	last     map[string]string
+}
+
+// source couples a kind of event with the status collection it is tailed from
+type source struct {
+	kind       string
+	collection string
+}
+
+// sources lists the status collections tailed for events, from the top level groups down to the metrics
+var sources = []source{
+	{"endpoint_group", "status_endpoint_groups"},
+	{"service", "status_services"},
+	{"endpoint", "status_endpoints"},
+	{"metric", "status_metrics"},
+}
+
+// DataOutput struct holds a status of any of the status collections as queried from datastore
+type DataOutput struct {
+	ID            bson.ObjectId `bson:"_id" json:"-"`
+	Kind          string        `bson:"-" json:"type"`
+	Timestamp     string        `bson:"timestamp" json:"timestamp"`
+	EndpointGroup string        `bson:"endpoint_group" json:"endpoint_group"`
+	Service       string        `bson:"service" json:"service,omitempty"`
+	Hostname      string        `bson:"host" json:"endpoint,omitempty"`
+	Metric        string        `bson:"metric" json:"metric,omitempty"`
+	Status        string        `bson:"status" json:"status"`
+	PrevStatus    string        `bson:"previous_state" json:"previous_status,omitempty"`
+}
+
+// key identifies the endpoint group, service, endpoint or metric the status belongs to
+func (event DataOutput) key() string {
+	return event.Kind + "/" + event.EndpointGroup + "/" + event.Service + "/" + event.Hostname + "/" + event.Metric
+}
+
+// Message struct to hold the json/xml response
+type messageOUT struct {
+	XMLName xml.Name `xml:"root" json:"-"`
+	Message string   `xml:"message" json:"message"`
+}
+
+type byID []DataOutput
+
+func (t byID) Len() int           { return len(t) }
+func (t byID) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+func (t byID) Less(i, j int) bool { return t[i].ID < t[j].ID }
+
+// stateChanges drops the statuses that leave the state of their endpoint group, service, endpoint
+// or metric unchanged, either according to the previous state stored along with them or to the
+// last state already pushed to the client
+func stateChanges(events []DataOutput, last map[string]string) []DataOutput {
+	changes := []DataOutput{}
+	for _, event := range events {
+		if event.PrevStatus != "" && event.PrevStatus == event.Status {
+			continue
+		}
+		if state, seen := last[event.key()]; seen && state == event.Status {
+			continue
+		}
+		last[event.key()] = event.Status
+		changes = append(changes, event)
+	}
+	return changes
+}
@@ -0,0 +1,41 @@
+/*
+ * Copyright (c) 2015 GRNET S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the
+ * License. You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an "AS
+ * IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language
+ * governing permissions and limitations under the License.
+ *
+ * The views and conclusions contained in the software and
+ * documentation are those of the authors and should not be
+ * interpreted as representing official policies, either expressed
+ * or implied, of GRNET S.A.
+ *
+ */
+
+package statusEvents
+
+import (
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/github.com/gorilla/mux"
+	"github.com/ARGOeu/argo-web-api/respond"
+)
+
+// HandleSubrouter contains the different paths to follow during subrouting
+func HandleSubrouter(s *mux.Router, confhandler *respond.ConfHandler) {
+
+	// Events are streamed as they are found, so the handler writes to the
+	// connection directly instead of going through confhandler.Respond
+	// eg. status/critical/events?group=HG-03-AUTH&state=CRITICAL
+	s.Path("/{report_name}/events").
+		Methods("GET").
+		Name("status events").
+		Handler(StreamEvents(confhandler.Config))
+
+}
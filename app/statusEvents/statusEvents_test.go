@@ -0,0 +1,417 @@
+/*
+ * Copyright (c) 2015 GRNET S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the
+ * License. You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an "AS
+ * IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language
+ * governing permissions and limitations under the License.
+ *
+ * The views and conclusions contained in the software and
+ * documentation are those of the authors and should not be
+ * interpreted as representing official policies, either expressed
+ * or implied, of GRNET S.A.
+ *
+ */
+
+package statusEvents
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/github.com/gorilla/mux"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/github.com/stretchr/testify/suite"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/gcfg.v1"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2/bson"
+	"github.com/ARGOeu/argo-web-api/respond"
+	"github.com/ARGOeu/argo-web-api/utils/authentication"
+	"github.com/ARGOeu/argo-web-api/utils/config"
+	"github.com/ARGOeu/argo-web-api/utils/mongo"
+)
+
+// This is a util. suite struct used in tests (see pkg "testify")
+type StatusEventsTestSuite struct {
+	suite.Suite
+	cfg          config.Config
+	router       *mux.Router
+	confHandler  respond.ConfHandler
+	tenantDbConf config.MongoConfig
+}
+
+// Setup the Test Environment
+// This function runs before any test and setups the environment
+// A test configuration object is instantiated using a reference
+// to testdb: argotest_events. Also the testdb is seeded with a tenant,
+// a report, an operations profile and statuses of every level
+func (suite *StatusEventsTestSuite) SetupTest() {
+
+	const testConfig = `
+    [server]
+    bindip = ""
+    port = 8080
+    maxprocs = 4
+    cache = false
+    lrucache = 700000000
+    gzip = true
+    [mongodb]
+    host = "127.0.0.1"
+    port = 27017
+    db = "argotest_events"
+`
+
+	_ = gcfg.ReadStringInto(&suite.cfg, testConfig)
+
+	// Create router and confhandler for test
+	suite.confHandler = respond.ConfHandler{suite.cfg}
+	suite.router = mux.NewRouter().StrictSlash(true).PathPrefix("/api/v2/status").Subrouter()
+	HandleSubrouter(suite.router, &suite.confHandler)
+
+	// Connect to mongo testdb
+	session, _ := mongo.OpenSession(suite.cfg.MongoDB)
+
+	// Add authentication token to mongo testdb
+	seedAuth := bson.M{"api_key": "S3CR3T"}
+	_ = mongo.Insert(session, suite.cfg.MongoDB.Db, "authentication", seedAuth)
+
+	// seed mongo
+	session, err := mgo.Dial(suite.cfg.MongoDB.Host)
+	if err != nil {
+		panic(err)
+	}
+	defer session.Close()
+
+	// seed a tenant to use
+	c := session.DB(suite.cfg.MongoDB.Db).C("tenants")
+	c.Insert(bson.M{
+		"id": "6ac7d684-1f8e-4a02-a502-720e8f11e50c",
+		"info": bson.M{
+			"name":    "GUARDIANS",
+			"email":   "email@something2",
+			"website": "www.gotg.com",
+			"created": "2015-10-20 02:08:04",
+			"updated": "2015-10-20 02:08:04"},
+		"db_conf": []bson.M{
+			bson.M{
+				"store":    "main",
+				"server":   "localhost",
+				"port":     27017,
+				"database": "argotest_events_egi",
+				"username": "",
+				"password": ""},
+		},
+		"users": []bson.M{
+			bson.M{
+				"name":    "egi_user",
+				"email":   "egi_user@email.com",
+				"api_key": "KEY1"},
+		}})
+
+	// get dbconfiguration based on the tenant
+	// Prepare the request object
+	request, _ := http.NewRequest("GET", "", strings.NewReader(""))
+	// add the content-type header to application/json
+	request.Header.Set("Content-Type", "application/json")
+	// add the authentication token which is seeded in testdb
+	request.Header.Set("x-api-key", "KEY1")
+	// authenticate user's api key and find corresponding tenant
+	suite.tenantDbConf, err = authentication.AuthenticateTenant(request.Header, suite.cfg)
+
+	// Now seed the report DEFINITIONS
+	c = session.DB(suite.tenantDbConf.Db).C("reports")
+	c.Insert(bson.M{
+		"id": "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"info": bson.M{
+			"name":        "Report_A",
+			"description": "report aaaaa",
+			"created":     "2015-9-10 13:43:00",
+			"updated":     "2015-10-11 13:43:00",
+		},
+		"topology_schema": bson.M{
+			"group": bson.M{
+				"type": "NGI",
+				"group": bson.M{
+					"type": "SITES",
+				},
+			},
+		},
+		"profiles": []bson.M{
+			bson.M{
+				"id":   "6ac7d684-1f8e-4a02-a502-720e8f11e50b",
+				"type": "metric",
+				"name": "profile1"},
+			bson.M{
+				"id":   "6ac7d684-1f8e-4a02-a502-720e8f11e523",
+				"type": "operations",
+				"name": "profile2"},
+			bson.M{
+				"id":   "6ac7d684-1f8e-4a02-a502-720e8f11e50q",
+				"type": "aggregation",
+				"name": "profile3"},
+		},
+		"filter_tags": []bson.M{
+			bson.M{
+				"name":  "name1",
+				"value": "value1"},
+		}})
+
+	c = session.DB(suite.tenantDbConf.Db).C("operations_profiles")
+	c.Insert(bson.M{
+		"id":               "6ac7d684-1f8e-4a02-a502-720e8f11e523",
+		"name":             "profile2",
+		"available_states": []string{"OK", "WARNING", "UNKNOWN", "MISSING", "CRITICAL", "DOWNTIME"},
+		"defaults": bson.M{
+			"down":    "DOWNTIME",
+			"missing": "MISSING",
+			"unknown": "UNKNOWN",
+		},
+	})
+
+	// seed the endpoint group statuses, the ids give the order the statuses were stored in
+	c = session.DB(suite.tenantDbConf.Db).C("status_endpoint_groups")
+	c.Insert(bson.M{
+		"_id":            bson.ObjectIdHex("5543c2000000000000000001"),
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T00:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"status":         "OK",
+	})
+	c.Insert(bson.M{
+		"_id":            bson.ObjectIdHex("5543c2000000000000000002"),
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T01:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"status":         "CRITICAL",
+	})
+	c.Insert(bson.M{
+		"_id":            bson.ObjectIdHex("5543c2000000000000000003"),
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T01:00:00Z",
+		"endpoint_group": "HG-02-IASA",
+		"status":         "OK",
+	})
+
+	// seed the service statuses
+	c = session.DB(suite.tenantDbConf.Db).C("status_services")
+	c.Insert(bson.M{
+		"_id":            bson.ObjectIdHex("5543c2000000000000000004"),
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T01:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"status":         "CRITICAL",
+	})
+	c.Insert(bson.M{
+		"_id":            bson.ObjectIdHex("5543c2000000000000000005"),
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T02:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"status":         "CRITICAL",
+	})
+
+	// seed the endpoint statuses
+	c = session.DB(suite.tenantDbConf.Db).C("status_endpoints")
+	c.Insert(bson.M{
+		"_id":            bson.ObjectIdHex("5543c2000000000000000006"),
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T01:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"host":           "cream01.afroditi.gr",
+		"status":         "CRITICAL",
+	})
+
+	// seed the metric statuses, the last one repeats the state of its metric
+	c = session.DB(suite.tenantDbConf.Db).C("status_metrics")
+	c.Insert(bson.M{
+		"_id":                bson.ObjectIdHex("5543c2000000000000000007"),
+		"report":             "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":       20150501,
+		"timestamp":          "2015-05-01T01:00:00Z",
+		"endpoint_group":     "HG-03-AUTH",
+		"service":            "CREAM-CE",
+		"host":               "cream01.afroditi.gr",
+		"metric":             "emi.cream.CREAMCE-JobSubmit",
+		"status":             "CRITICAL",
+		"previous_state":     "OK",
+		"previous_timestamp": "2015-05-01T00:00:00Z",
+	})
+	c.Insert(bson.M{
+		"_id":                bson.ObjectIdHex("5543c2000000000000000008"),
+		"report":             "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":       20150501,
+		"timestamp":          "2015-05-01T03:00:00Z",
+		"endpoint_group":     "HG-03-AUTH",
+		"service":            "CREAM-CE",
+		"host":               "cream01.afroditi.gr",
+		"metric":             "emi.cream.CREAMCE-JobSubmit",
+		"status":             "CRITICAL",
+		"previous_state":     "CRITICAL",
+		"previous_timestamp": "2015-05-01T01:00:00Z",
+	})
+}
+
+// stream requests the events of a url resuming after lastEventID and returns the
+// response recorded until the request is cancelled
+func (suite *StatusEventsTestSuite) stream(url string, accept string, lastEventID string) *httptest.ResponseRecorder {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	request, _ := http.NewRequest("GET", url, strings.NewReader(""))
+	request = request.WithContext(ctx)
+	request.Header.Set("Accept", accept)
+	request.Header.Set("x-api-key", "KEY1")
+	if lastEventID != "" {
+		request.Header.Set("Last-Event-ID", lastEventID)
+	}
+	response := httptest.NewRecorder()
+	suite.router.ServeHTTP(response, request)
+	return response
+}
+
+func (suite *StatusEventsTestSuite) TestStreamEvents() {
+
+	pollInterval = 10 * time.Millisecond
+
+	response := suite.stream("/api/v2/status/Report_A/events", "text/event-stream", "5543c2000000000000000001")
+
+	expected := `id: 5543c2000000000000000002
+event: endpoint_group
+data: {"type":"endpoint_group","timestamp":"2015-05-01T01:00:00Z","endpoint_group":"HG-03-AUTH","status":"CRITICAL"}
+
+id: 5543c2000000000000000003
+event: endpoint_group
+data: {"type":"endpoint_group","timestamp":"2015-05-01T01:00:00Z","endpoint_group":"HG-02-IASA","status":"OK"}
+
+id: 5543c2000000000000000004
+event: service
+data: {"type":"service","timestamp":"2015-05-01T01:00:00Z","endpoint_group":"HG-03-AUTH","service":"CREAM-CE","status":"CRITICAL"}
+
+id: 5543c2000000000000000006
+event: endpoint
+data: {"type":"endpoint","timestamp":"2015-05-01T01:00:00Z","endpoint_group":"HG-03-AUTH","service":"CREAM-CE","endpoint":"cream01.afroditi.gr","status":"CRITICAL"}
+
+id: 5543c2000000000000000007
+event: metric
+data: {"type":"metric","timestamp":"2015-05-01T01:00:00Z","endpoint_group":"HG-03-AUTH","service":"CREAM-CE","endpoint":"cream01.afroditi.gr","metric":"emi.cream.CREAMCE-JobSubmit","status":"CRITICAL","previous_status":"OK"}
+
+`
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	suite.Equal("text/event-stream; charset=utf-8", response.Header().Get("Content-Type"), "Content type mismatch")
+	// The repeated statuses are not pushed and the idle polls only keep the connection alive
+	suite.Equal(expected, strings.Replace(response.Body.String(), ": keep-alive\n\n", "", -1), "Response body mismatch")
+
+	response = suite.stream("/api/v2/status/Report_A/events?service=CREAM-CE&state=CRITICAL", "text/event-stream", "5543c2000000000000000001")
+
+	expected = `id: 5543c2000000000000000004
+event: service
+data: {"type":"service","timestamp":"2015-05-01T01:00:00Z","endpoint_group":"HG-03-AUTH","service":"CREAM-CE","status":"CRITICAL"}
+
+id: 5543c2000000000000000006
+event: endpoint
+data: {"type":"endpoint","timestamp":"2015-05-01T01:00:00Z","endpoint_group":"HG-03-AUTH","service":"CREAM-CE","endpoint":"cream01.afroditi.gr","status":"CRITICAL"}
+
+id: 5543c2000000000000000007
+event: metric
+data: {"type":"metric","timestamp":"2015-05-01T01:00:00Z","endpoint_group":"HG-03-AUTH","service":"CREAM-CE","endpoint":"cream01.afroditi.gr","metric":"emi.cream.CREAMCE-JobSubmit","status":"CRITICAL","previous_status":"OK"}
+
+`
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	suite.Equal(expected, strings.Replace(response.Body.String(), ": keep-alive\n\n", "", -1), "Response body mismatch")
+
+	// Resuming after the last event pushes nothing new
+	response = suite.stream("/api/v2/status/Report_A/events?group=HG-03-AUTH", "text/event-stream", "5543c2000000000000000008")
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	suite.Equal("", strings.Replace(response.Body.String(), ": keep-alive\n\n", "", -1), "Response body mismatch")
+
+	// A late status stored with a past timestamp is still pushed
+	session, _ := mongo.OpenSession(suite.tenantDbConf)
+	defer mongo.CloseSession(session)
+	session.DB(suite.tenantDbConf.Db).C("status_endpoints").Insert(bson.M{
+		"_id":            bson.ObjectIdHex("5543c2000000000000000009"),
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T00:30:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"host":           "cream02.afroditi.gr",
+		"status":         "WARNING",
+	})
+
+	response = suite.stream("/api/v2/status/Report_A/events?group=HG-03-AUTH", "text/event-stream", "5543c2000000000000000008")
+
+	expected = `id: 5543c2000000000000000009
+event: endpoint
+data: {"type":"endpoint","timestamp":"2015-05-01T00:30:00Z","endpoint_group":"HG-03-AUTH","service":"CREAM-CE","endpoint":"cream02.afroditi.gr","status":"WARNING"}
+
+`
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	suite.Equal(expected, strings.Replace(response.Body.String(), ": keep-alive\n\n", "", -1), "Response body mismatch")
+}
+
+func (suite *StatusEventsTestSuite) TestStreamEventsErrors() {
+
+	for url, expected := range map[string]string{
+		"/api/v2/status/Report_A/events?state=BROKEN": "The state BROKEN is not declared in the operations profile of the report Report_A",
+		"/api/v2/status/Report_X/events":              "The report with the name Report_X does not exist",
+	} {
+		response := suite.stream(url, "text/event-stream", "")
+		// Check that we must have a 400 bad request code
+		suite.Equal(400, response.Code, "Incorrect HTTP response code")
+		suite.Equal("application/json; charset=utf-8", response.Header().Get("Content-Type"), "Content type mismatch")
+		suite.Contains(response.Body.String(), expected, "Response body mismatch")
+	}
+
+	response := suite.stream("/api/v2/status/Report_A/events", "text/event-stream", "2015-05-01T00:00:00Z")
+	suite.Equal(400, response.Code, "Incorrect HTTP response code")
+	suite.Contains(response.Body.String(), "2015-05-01T00:00:00Z is not the id of an event, please use the id of the last event received", "Response body mismatch")
+
+	// The errors are marshaled to xml when the client accepts it
+	response = suite.stream("/api/v2/status/Report_X/events", "text/event-stream, application/xml", "")
+
+	expected := `<root>
+ <message>The report with the name Report_X does not exist</message>
+</root>`
+	suite.Equal(400, response.Code, "Incorrect HTTP response code")
+	suite.Equal("application/xml; charset=utf-8", response.Header().Get("Content-Type"), "Content type mismatch")
+	suite.Equal(expected, response.Body.String(), "Response body mismatch")
+
+	response = suite.stream("/api/v2/status/Report_A/events", "text/html", "")
+	suite.Equal(406, response.Code, "Incorrect HTTP response code")
+	suite.Contains(response.Body.String(), "Not Acceptable Content Type", "Response body mismatch")
+}
+
+// This function is actually called in the end of all tests
+// and clears the test environment.
+// Mainly it's purpose is to drop the testdb
+func (suite *StatusEventsTestSuite) TearDownTest() {
+
+	session, _ := mongo.OpenSession(suite.cfg.MongoDB)
+
+	session.DB("argotest_events").DropDatabase()
+	session.DB("argotest_events_egi").DropDatabase()
+}
+
+// This is the first function called when go test is issued
+func TestStatusEventsSuite(t *testing.T) {
+	suite.Run(t, new(StatusEventsTestSuite))
+}
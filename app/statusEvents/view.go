@@ -0,0 +1,54 @@
+/*
+ * Copyright (c) 2015 GRNET S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the
+ * License. You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an "AS
+ * IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language
+ * governing permissions and limitations under the License.
+ *
+ * The views and conclusions contained in the software and
+ * documentation are those of the authors and should not be
+ * interpreted as representing official policies, either expressed
+ * or implied, of GRNET S.A.
+ *
+ */
+
+package statusEvents
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ARGOeu/argo-web-api/respond"
+)
+
+// writeEvent renders a state change as a server-sent event whose id is the id of the stored status,
+// so that a reconnecting client resumes after it through the Last-Event-ID header
+func writeEvent(w io.Writer, event DataOutput) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID.Hex(), event.Kind, data)
+	return err
+}
+
+func createMessageOUT(message string, format string) ([]byte, error) {
+
+	output := []byte("message placeholder")
+	err := error(nil)
+	docRoot := &messageOUT{}
+
+	docRoot.Message = message
+
+	output, err = respond.MarshalContent(docRoot, format, "", " ")
+	return output, err
+}
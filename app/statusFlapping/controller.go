@@ -0,0 +1,203 @@
+/*
+ * Copyright (c) 2015 GRNET S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the
+ * License. You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an "AS
+ * IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language
+ * governing permissions and limitations under the License.
+ *
+ * The views and conclusions contained in the software and
+ * documentation are those of the authors and should not be
+ * interpreted as representing official policies, either expressed
+ * or implied, of GRNET S.A.
+ *
+ */
+
+package statusFlapping
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/github.com/gorilla/mux"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2/bson"
+	"github.com/ARGOeu/argo-web-api/app/reports"
+	"github.com/ARGOeu/argo-web-api/respond"
+	"github.com/ARGOeu/argo-web-api/utils/authentication"
+	"github.com/ARGOeu/argo-web-api/utils/config"
+	"github.com/ARGOeu/argo-web-api/utils/mongo"
+)
+
+// ListFlapping returns the endpoints and metrics of a report whose state changed more times than a threshold within a time window
+func ListFlapping(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
+
+	//STANDARD DECLARATIONS START
+
+	code := http.StatusOK
+	h := http.Header{}
+	output := []byte("List Flapping")
+	err := error(nil)
+	contentType := "application/xml"
+	charset := "utf-8"
+
+	//STANDARD DECLARATIONS END
+
+	contentType, err = respond.ParseAcceptHeader(r, respond.TimelineContentTypes...)
+	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
+
+	if err != nil {
+		code = http.StatusNotAcceptable
+		output, _ = respond.MarshalContent(respond.NotAcceptableContentType, contentType, "", " ")
+		return code, h, output, err
+	}
+
+	// Parse the request into the input
+	urlValues := r.URL.Query()
+	vars := mux.Vars(r)
+
+	input := InputParams{
+		report:    vars["report_name"],
+		threshold: 3,
+		format:    contentType,
+	}
+
+	errs := []respond.ErrorResponse{}
+
+	window := 24 * time.Hour
+	if urlValues.Get("window") != "" {
+		window, err = time.ParseDuration(urlValues.Get("window"))
+		if err != nil || window <= 0 {
+			errs = append(errs, respond.ErrorResponse{
+				Message: "Wrong window",
+				Code:    "400",
+				Details: fmt.Sprintf("%s is not accepted as window, please provide a positive duration like 24h", urlValues.Get("window")),
+			})
+		}
+	}
+
+	if urlValues.Get("threshold") != "" {
+		input.threshold, err = strconv.Atoi(urlValues.Get("threshold"))
+		if err != nil || input.threshold < 0 {
+			errs = append(errs, respond.ErrorResponse{
+				Message: "Wrong threshold",
+				Code:    "400",
+				Details: fmt.Sprintf("%s is not accepted as threshold, please provide a non negative number of state changes", urlValues.Get("threshold")),
+			})
+		}
+	}
+
+	// The window ends at end_time, by default at the time of the request
+	end := time.Now().UTC()
+	if urlValues.Get("end_time") != "" {
+		end, err = time.Parse(zuluForm, urlValues.Get("end_time"))
+		if err != nil {
+			errs = append(errs, respond.ErrorResponse{
+				Message: "end_time parsing error",
+				Code:    "400",
+				Details: fmt.Sprintf("Error parsing date string %s please use zulu format like %s", urlValues.Get("end_time"), zuluForm),
+			})
+		}
+	}
+	start := end.Add(-window)
+	input.start = start.Format(zuluForm)
+	input.end = end.Format(zuluForm)
+	input.startTime, _ = strconv.Atoi(start.Format(ymdForm))
+	input.endTime, _ = strconv.Atoi(end.Format(ymdForm))
+
+	if len(errs) > 0 {
+		code = http.StatusBadRequest
+		output = respond.CreateFailureResponseMessage("Bad Request", "400", errs).MarshalTo(contentType)
+		return code, h, output, nil
+	}
+
+	// Call authenticateTenant to check the api key and retrieve
+	// the correct tenant db conf
+	tenantDbConfig, err := authentication.AuthenticateTenant(r.Header, cfg)
+	if err != nil {
+		if err.Error() == "Unauthorized" {
+			code = http.StatusUnauthorized
+			out := respond.UnauthorizedMessage
+			output = out.MarshalTo(contentType)
+			return code, h, output, err
+		}
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	session, err := mongo.OpenSession(tenantDbConfig)
+	defer mongo.CloseSession(session)
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	report := reports.MongoInterface{}
+	err = mongo.FindOne(session, tenantDbConfig.Db, "reports", bson.M{"info.name": input.report}, &report)
+	if err != nil {
+		code = http.StatusBadRequest
+		message := "The report with the name " + input.report + " does not exist"
+		output, err := createMessageOUT(message, contentType) //Render the response into XML or JSON
+		return code, h, output, err
+	}
+	input.groupType = report.GetEndpointGroupType()
+
+	// Endpoints and metrics change state on their own timelines
+	endpoints := []TransitionOutput{}
+	err = session.DB(tenantDbConfig.Db).C("status_endpoints").
+		Pipe(prepareQuery(input, report.ID, "endpoint_group", "service", "host")).All(&endpoints)
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	metrics := []TransitionOutput{}
+	err = session.DB(tenantDbConfig.Db).C("status_metrics").
+		Pipe(prepareQuery(input, report.ID, "endpoint_group", "service", "host", "metric")).All(&metrics)
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	output, err = createView(countChanges(endpoints, metrics), input) //Render the results into JSON/XML format
+
+	return code, h, output, err
+}
+
+// prepareQuery keeps the statuses of the window that changed the state of their entity, sorted by the
+// fields that identify the entity and by timestamp
+func prepareQuery(input InputParams, reportID string, keys ...string) []bson.M {
+	sort := bson.D{}
+	for _, key := range append(keys, "timestamp") {
+		sort = append(sort, bson.DocElem{Name: key, Value: 1})
+	}
+
+	return []bson.M{
+		{"$match": bson.M{
+			"report":       reportID,
+			"date_integer": bson.M{"$gte": input.startTime, "$lte": input.endTime},
+			"timestamp":    bson.M{"$gt": input.start, "$lte": input.end},
+		}},
+		{"$project": bson.M{
+			"timestamp":      1,
+			"endpoint_group": 1,
+			"service":        1,
+			"host":           1,
+			"metric":         1,
+			"status":         1,
+			"previous_state": 1,
+			"changed":        bson.M{"$ne": []string{"$status", "$previous_state"}},
+		}},
+		{"$match": bson.M{"changed": true}},
+		{"$sort": sort},
+	}
+}
@@ -0,0 +1,163 @@
+/*
+ * Copyright (c) 2015 GRNET S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the
+ * License. You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an "AS
+ * IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language
+ * governing permissions and limitations under the License.
+ *
+ * The views and conclusions contained in the software and
+ * documentation are those of the authors and should not be
+ * interpreted as representing official policies, either expressed
+ * or implied, of GRNET S.A.
+ *
+ */
+
+package statusFlapping
+
+import (
+	"encoding/xml"
+	"sort"
+)
+
+const zuluForm = "2006-01-02T15:04:05Z"
+const ymdForm = "20060102"
+
+// InputParams struct holds as input all the url params of the request
+type InputParams struct {
+	startTime int
+	endTime   int
+	start     string
+	end       string
+	report    string
+	groupType string
+	threshold int
+	format    string
+}
+
+// TransitionOutput struct holds a state change of a metric as queried from datastore
+type TransitionOutput struct {
+	Timestamp     string `bson:"timestamp"`
+	EndpointGroup string `bson:"endpoint_group"`
+	Service       string `bson:"service"`
+	Hostname      string `bson:"host"`
+	Metric        string `bson:"metric"`
+	Status        string `bson:"status"`
+	PrevStatus    string `bson:"previous_state"`
+}
+
+// Flapping holds the number of state changes of an endpoint or of a metric, when Metric is set,
+// along with the states involved in the order they were first seen. Endpoints carry their metrics
+type Flapping struct {
+	EndpointGroup string
+	Service       string
+	Hostname      string
+	Metric        string
+	Changes       int
+	States        []string
+	Metrics       []*Flapping
+}
+
+// json/xml response related structs
+
+type rootOUT struct {
+	XMLName        xml.Name            `xml:"root" json:"-"`
+	EndpointGroups []*endpointGroupOUT `json:"groups"`
+}
+
+type endpointGroupOUT struct {
+	XMLName   xml.Name      `xml:"group" json:"-"`
+	Name      string        `xml:"name,attr" json:"name"`
+	GroupType string        `xml:"type,attr" json:"type"`
+	Services  []*serviceOUT `json:"services"`
+}
+
+type serviceOUT struct {
+	XMLName   xml.Name       `xml:"group" json:"-"`
+	Name      string         `xml:"name,attr" json:"name"`
+	GroupType string         `xml:"type,attr" json:"type"`
+	Endpoints []*endpointOUT `json:"endpoints"`
+}
+
+type endpointOUT struct {
+	XMLName xml.Name     `xml:"endpoint" json:"-"`
+	Name    string       `xml:"name,attr" json:"name"`
+	Changes int          `xml:"changes,attr" json:"changes"`
+	States  []string     `xml:"state" json:"states"`
+	Metrics []*metricOUT `json:"metrics,omitempty"`
+}
+
+type metricOUT struct {
+	XMLName xml.Name `xml:"metric" json:"-"`
+	Name    string   `xml:"name,attr" json:"name"`
+	Changes int      `xml:"changes,attr" json:"changes"`
+	States  []string `xml:"state" json:"states"`
+}
+
+// Message struct to hold the json/xml response
+type messageOUT struct {
+	XMLName xml.Name `xml:"root" json:"-"`
+	Message string   `xml:"message" json:"message"`
+}
+
+// countChanges counts the state changes of every endpoint and of every metric, each sorted by entity and
+// timestamp. An endpoint counts the changes of its own timeline, not the changes of its metrics. The endpoints
+// are returned sorted, each one with its metrics, including the endpoints whose metrics changed but they did not
+func countChanges(endpointRows []TransitionOutput, metricRows []TransitionOutput) []*Flapping {
+	endpoints := map[string]*Flapping{}
+	keys := []string{}
+	endpoint := func(row TransitionOutput) *Flapping {
+		key := row.EndpointGroup + "\x00" + row.Service + "\x00" + row.Hostname
+		if _, ok := endpoints[key]; !ok {
+			endpoints[key] = &Flapping{EndpointGroup: row.EndpointGroup, Service: row.Service, Hostname: row.Hostname}
+			keys = append(keys, key)
+		}
+		return endpoints[key]
+	}
+
+	for _, row := range endpointRows {
+		endpoint(row).addChange(row)
+	}
+
+	var metric *Flapping
+	for _, row := range metricRows {
+		if metric == nil || metric.EndpointGroup != row.EndpointGroup || metric.Service != row.Service ||
+			metric.Hostname != row.Hostname || metric.Metric != row.Metric {
+			metric = &Flapping{EndpointGroup: row.EndpointGroup, Service: row.Service, Hostname: row.Hostname, Metric: row.Metric}
+			parent := endpoint(row)
+			parent.Metrics = append(parent.Metrics, metric)
+		}
+		metric.addChange(row)
+	}
+
+	// the keys join the fields of the endpoints with a separator that sorts before any other character,
+	// so they sort the way the datastore sorts the endpoints
+	sort.Strings(keys)
+	counts := []*Flapping{}
+	for _, key := range keys {
+		counts = append(counts, endpoints[key])
+	}
+	return counts
+}
+
+func (count *Flapping) addChange(row TransitionOutput) {
+	count.Changes++
+	count.addState(row.PrevStatus)
+	count.addState(row.Status)
+}
+
+func (count *Flapping) addState(state string) {
+	for _, seen := range count.States {
+		if seen == state {
+			return
+		}
+	}
+	count.States = append(count.States, state)
+}
@@ -0,0 +1,473 @@
+/*
+ * Copyright (c) 2015 GRNET S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the
+ * License. You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an "AS
+ * IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language
+ * governing permissions and limitations under the License.
+ *
+ * The views and conclusions contained in the software and
+ * documentation are those of the authors and should not be
+ * interpreted as representing official policies, either expressed
+ * or implied, of GRNET S.A.
+ *
+ */
+
+package statusFlapping
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/github.com/gorilla/mux"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/github.com/stretchr/testify/suite"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/gcfg.v1"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2/bson"
+	"github.com/ARGOeu/argo-web-api/respond"
+	"github.com/ARGOeu/argo-web-api/utils/authentication"
+	"github.com/ARGOeu/argo-web-api/utils/config"
+	"github.com/ARGOeu/argo-web-api/utils/mongo"
+)
+
+// This is a util. suite struct used in tests (see pkg "testify")
+type StatusFlappingTestSuite struct {
+	suite.Suite
+	cfg          config.Config
+	router       *mux.Router
+	confHandler  respond.ConfHandler
+	tenantDbConf config.MongoConfig
+}
+
+// Setup the Test Environment
+// This function runs before any test and setups the environment
+// A test configuration object is instantiated using a reference
+// to testdb: argotest_flapping. Also the testdb is seeded with a tenant,
+// a report and the state changes of metrics
+func (suite *StatusFlappingTestSuite) SetupTest() {
+
+	const testConfig = `
+    [server]
+    bindip = ""
+    port = 8080
+    maxprocs = 4
+    cache = false
+    lrucache = 700000000
+    gzip = true
+    [mongodb]
+    host = "127.0.0.1"
+    port = 27017
+    db = "argotest_flapping"
+`
+
+	_ = gcfg.ReadStringInto(&suite.cfg, testConfig)
+
+	// Create router and confhandler for test
+	suite.confHandler = respond.ConfHandler{suite.cfg}
+	suite.router = mux.NewRouter().StrictSlash(true).PathPrefix("/api/v2/status").Subrouter()
+	HandleSubrouter(suite.router, &suite.confHandler)
+
+	// Connect to mongo testdb
+	session, _ := mongo.OpenSession(suite.cfg.MongoDB)
+
+	// Add authentication token to mongo testdb
+	seedAuth := bson.M{"api_key": "S3CR3T"}
+	_ = mongo.Insert(session, suite.cfg.MongoDB.Db, "authentication", seedAuth)
+
+	// seed mongo
+	session, err := mgo.Dial(suite.cfg.MongoDB.Host)
+	if err != nil {
+		panic(err)
+	}
+	defer session.Close()
+
+	// seed a tenant to use
+	c := session.DB(suite.cfg.MongoDB.Db).C("tenants")
+	c.Insert(bson.M{
+		"id": "6ac7d684-1f8e-4a02-a502-720e8f11e50c",
+		"info": bson.M{
+			"name":    "GUARDIANS",
+			"email":   "email@something2",
+			"website": "www.gotg.com",
+			"created": "2015-10-20 02:08:04",
+			"updated": "2015-10-20 02:08:04"},
+		"db_conf": []bson.M{
+			bson.M{
+				"store":    "main",
+				"server":   "localhost",
+				"port":     27017,
+				"database": "argotest_flapping_egi",
+				"username": "",
+				"password": ""},
+		},
+		"users": []bson.M{
+			bson.M{
+				"name":    "egi_user",
+				"email":   "egi_user@email.com",
+				"api_key": "KEY1"},
+		}})
+
+	// get dbconfiguration based on the tenant
+	// Prepare the request object
+	request, _ := http.NewRequest("GET", "", strings.NewReader(""))
+	// add the content-type header to application/json
+	request.Header.Set("Content-Type", "application/json")
+	// add the authentication token which is seeded in testdb
+	request.Header.Set("x-api-key", "KEY1")
+	// authenticate user's api key and find corresponding tenant
+	suite.tenantDbConf, err = authentication.AuthenticateTenant(request.Header, suite.cfg)
+
+	// Now seed the report DEFINITIONS
+	c = session.DB(suite.tenantDbConf.Db).C("reports")
+	c.Insert(bson.M{
+		"id": "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"info": bson.M{
+			"name":        "Report_A",
+			"description": "report aaaaa",
+			"created":     "2015-9-10 13:43:00",
+			"updated":     "2015-10-11 13:43:00",
+		},
+		"topology_schema": bson.M{
+			"group": bson.M{
+				"type": "NGI",
+				"group": bson.M{
+					"type": "SITES",
+				},
+			},
+		},
+		"profiles": []bson.M{
+			bson.M{
+				"id":   "6ac7d684-1f8e-4a02-a502-720e8f11e50b",
+				"type": "metric",
+				"name": "profile1"},
+			bson.M{
+				"id":   "6ac7d684-1f8e-4a02-a502-720e8f11e523",
+				"type": "operations",
+				"name": "profile2"},
+			bson.M{
+				"id":   "6ac7d684-1f8e-4a02-a502-720e8f11e50q",
+				"type": "aggregation",
+				"name": "profile3"},
+		},
+		"filter_tags": []bson.M{
+			bson.M{
+				"name":  "name1",
+				"value": "value1"},
+		}})
+
+	// seed the endpoint statuses, the first one changes the state of the endpoint before the window
+	c = session.DB(suite.tenantDbConf.Db).C("status_endpoints")
+	c.Insert(bson.M{
+		"report":             "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":       20150430,
+		"timestamp":          "2015-04-30T10:00:00Z",
+		"endpoint_group":     "HG-03-AUTH",
+		"service":            "CREAM-CE",
+		"host":               "cream01.afroditi.gr",
+		"status":             "OK",
+		"previous_state":     "CRITICAL",
+		"previous_timestamp": "2015-04-30T09:00:00Z",
+	})
+	c.Insert(bson.M{
+		"report":             "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":       20150501,
+		"timestamp":          "2015-05-01T00:00:00Z",
+		"endpoint_group":     "HG-03-AUTH",
+		"service":            "CREAM-CE",
+		"host":               "cream01.afroditi.gr",
+		"status":             "OK",
+		"previous_state":     "OK",
+		"previous_timestamp": "2015-04-30T10:00:00Z",
+	})
+	c.Insert(bson.M{
+		"report":             "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":       20150501,
+		"timestamp":          "2015-05-01T01:00:00Z",
+		"endpoint_group":     "HG-03-AUTH",
+		"service":            "CREAM-CE",
+		"host":               "cream01.afroditi.gr",
+		"status":             "CRITICAL",
+		"previous_state":     "OK",
+		"previous_timestamp": "2015-05-01T00:00:00Z",
+	})
+	c.Insert(bson.M{
+		"report":             "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":       20150501,
+		"timestamp":          "2015-05-01T02:00:00Z",
+		"endpoint_group":     "HG-03-AUTH",
+		"service":            "CREAM-CE",
+		"host":               "cream01.afroditi.gr",
+		"status":             "OK",
+		"previous_state":     "CRITICAL",
+		"previous_timestamp": "2015-05-01T01:00:00Z",
+	})
+	c.Insert(bson.M{
+		"report":             "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":       20150501,
+		"timestamp":          "2015-05-01T03:00:00Z",
+		"endpoint_group":     "HG-03-AUTH",
+		"service":            "CREAM-CE",
+		"host":               "cream01.afroditi.gr",
+		"status":             "CRITICAL",
+		"previous_state":     "OK",
+		"previous_timestamp": "2015-05-01T02:00:00Z",
+	})
+	c.Insert(bson.M{
+		"report":             "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":       20150501,
+		"timestamp":          "2015-05-01T04:00:00Z",
+		"endpoint_group":     "HG-03-AUTH",
+		"service":            "CREAM-CE",
+		"host":               "cream01.afroditi.gr",
+		"status":             "OK",
+		"previous_state":     "CRITICAL",
+		"previous_timestamp": "2015-05-01T03:00:00Z",
+	})
+
+	// seed the metric statuses, the first one changes the state of a metric before the window
+	c = session.DB(suite.tenantDbConf.Db).C("status_metrics")
+	c.Insert(bson.M{
+		"report":             "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":       20150430,
+		"timestamp":          "2015-04-30T10:00:00Z",
+		"endpoint_group":     "HG-03-AUTH",
+		"service":            "CREAM-CE",
+		"host":               "cream01.afroditi.gr",
+		"metric":             "emi.cream.CREAMCE-JobSubmit",
+		"status":             "OK",
+		"previous_state":     "CRITICAL",
+		"previous_timestamp": "2015-04-30T09:00:00Z",
+	})
+	c.Insert(bson.M{
+		"report":             "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":       20150501,
+		"timestamp":          "2015-05-01T00:00:00Z",
+		"endpoint_group":     "HG-03-AUTH",
+		"service":            "CREAM-CE",
+		"host":               "cream01.afroditi.gr",
+		"metric":             "emi.cream.CREAMCE-JobSubmit",
+		"status":             "OK",
+		"previous_state":     "OK",
+		"previous_timestamp": "2015-04-30T10:00:00Z",
+	})
+	c.Insert(bson.M{
+		"report":             "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":       20150501,
+		"timestamp":          "2015-05-01T01:00:00Z",
+		"endpoint_group":     "HG-03-AUTH",
+		"service":            "CREAM-CE",
+		"host":               "cream01.afroditi.gr",
+		"metric":             "emi.cream.CREAMCE-JobSubmit",
+		"status":             "CRITICAL",
+		"previous_state":     "OK",
+		"previous_timestamp": "2015-05-01T00:00:00Z",
+	})
+	c.Insert(bson.M{
+		"report":             "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":       20150501,
+		"timestamp":          "2015-05-01T02:00:00Z",
+		"endpoint_group":     "HG-03-AUTH",
+		"service":            "CREAM-CE",
+		"host":               "cream01.afroditi.gr",
+		"metric":             "emi.cream.CREAMCE-JobSubmit",
+		"status":             "OK",
+		"previous_state":     "CRITICAL",
+		"previous_timestamp": "2015-05-01T01:00:00Z",
+	})
+	c.Insert(bson.M{
+		"report":             "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":       20150501,
+		"timestamp":          "2015-05-01T03:00:00Z",
+		"endpoint_group":     "HG-03-AUTH",
+		"service":            "CREAM-CE",
+		"host":               "cream01.afroditi.gr",
+		"metric":             "emi.cream.CREAMCE-JobSubmit",
+		"status":             "CRITICAL",
+		"previous_state":     "OK",
+		"previous_timestamp": "2015-05-01T02:00:00Z",
+	})
+	c.Insert(bson.M{
+		"report":             "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":       20150501,
+		"timestamp":          "2015-05-01T04:00:00Z",
+		"endpoint_group":     "HG-03-AUTH",
+		"service":            "CREAM-CE",
+		"host":               "cream01.afroditi.gr",
+		"metric":             "emi.cream.CREAMCE-JobSubmit",
+		"status":             "OK",
+		"previous_state":     "CRITICAL",
+		"previous_timestamp": "2015-05-01T03:00:00Z",
+	})
+	c.Insert(bson.M{
+		"report":             "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":       20150501,
+		"timestamp":          "2015-05-01T02:00:00Z",
+		"endpoint_group":     "HG-03-AUTH",
+		"service":            "CREAM-CE",
+		"host":               "cream01.afroditi.gr",
+		"metric":             "emi.cream.CREAMCE-AllowedSubmission",
+		"status":             "WARNING",
+		"previous_state":     "OK",
+		"previous_timestamp": "2015-05-01T00:00:00Z",
+	})
+	c.Insert(bson.M{
+		"report":             "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":       20150501,
+		"timestamp":          "2015-05-01T04:00:00Z",
+		"endpoint_group":     "HG-03-AUTH",
+		"service":            "CREAM-CE",
+		"host":               "cream02.afroditi.gr",
+		"metric":             "emi.cream.CREAMCE-JobSubmit",
+		"status":             "CRITICAL",
+		"previous_state":     "OK",
+		"previous_timestamp": "2015-05-01T00:00:00Z",
+	})
+}
+
+func (suite *StatusFlappingTestSuite) TestListFlapping() {
+
+	respXML := `<root>
+ <group name="HG-03-AUTH" type="SITES">
+  <group name="CREAM-CE" type="service">
+   <endpoint name="cream01.afroditi.gr" changes="4">
+    <state>OK</state>
+    <state>CRITICAL</state>
+    <metric name="emi.cream.CREAMCE-JobSubmit" changes="4">
+     <state>OK</state>
+     <state>CRITICAL</state>
+    </metric>
+   </endpoint>
+  </group>
+ </group>
+</root>`
+
+	respJSON := `{
+ "groups": [
+  {
+   "name": "HG-03-AUTH",
+   "type": "SITES",
+   "services": [
+    {
+     "name": "CREAM-CE",
+     "type": "service",
+     "endpoints": [
+      {
+       "name": "cream01.afroditi.gr",
+       "changes": 4,
+       "states": [
+        "OK",
+        "CRITICAL"
+       ],
+       "metrics": [
+        {
+         "name": "emi.cream.CREAMCE-JobSubmit",
+         "changes": 4,
+         "states": [
+          "OK",
+          "CRITICAL"
+         ]
+        }
+       ]
+      }
+     ]
+    }
+   ]
+  }
+ ]
+}`
+
+	respCSV := `group_type,group,service,endpoint,metric,changes,states
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,,4,OK;CRITICAL
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,emi.cream.CREAMCE-JobSubmit,4,OK;CRITICAL
+`
+
+	fullurl := "/api/v2/status/Report_A/flapping?window=24h&threshold=3&end_time=2015-05-01T23:00:00Z"
+
+	for accept, expected := range map[string]string{
+		"application/xml":  respXML,
+		"application/json": respJSON,
+		"text/csv":         respCSV,
+	} {
+		response := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", fullurl, strings.NewReader(""))
+		request.Header.Set("Accept", accept)
+		request.Header.Set("x-api-key", "KEY1")
+		suite.router.ServeHTTP(response, request)
+		// Check that we must have a 200 ok code
+		suite.Equal(200, response.Code, "Incorrect HTTP response code")
+		// Compare the expected and actual response
+		suite.Equal(expected, response.Body.String(), "Response body mismatch")
+	}
+
+	// A wider window includes the change of the day before
+	response := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/v2/status/Report_A/flapping?window=48h&threshold=4&end_time=2015-05-01T23:00:00Z", strings.NewReader(""))
+	request.Header.Set("Accept", "text/csv")
+	request.Header.Set("x-api-key", "KEY1")
+	suite.router.ServeHTTP(response, request)
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	suite.Equal(`group_type,group,service,endpoint,metric,changes,states
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,,5,CRITICAL;OK
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,emi.cream.CREAMCE-JobSubmit,5,CRITICAL;OK
+`, response.Body.String(), "Response body mismatch")
+
+	// Endpoints whose metrics are flapping are listed even when they are not flapping themselves
+	response = httptest.NewRecorder()
+	request, _ = http.NewRequest("GET", "/api/v2/status/Report_A/flapping?window=24h&threshold=0&end_time=2015-05-01T23:00:00Z", strings.NewReader(""))
+	request.Header.Set("Accept", "text/csv")
+	request.Header.Set("x-api-key", "KEY1")
+	suite.router.ServeHTTP(response, request)
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	suite.Equal(`group_type,group,service,endpoint,metric,changes,states
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,,4,OK;CRITICAL
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,emi.cream.CREAMCE-AllowedSubmission,1,OK;WARNING
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,emi.cream.CREAMCE-JobSubmit,4,OK;CRITICAL
+SITES,HG-03-AUTH,CREAM-CE,cream02.afroditi.gr,,0,
+SITES,HG-03-AUTH,CREAM-CE,cream02.afroditi.gr,emi.cream.CREAMCE-JobSubmit,1,OK;CRITICAL
+`, response.Body.String(), "Response body mismatch")
+}
+
+func (suite *StatusFlappingTestSuite) TestListFlappingErrors() {
+
+	for url, expected := range map[string]string{
+		"/api/v2/status/Report_A/flapping?window=1day":       "1day is not accepted as window, please provide a positive duration like 24h",
+		"/api/v2/status/Report_A/flapping?window=-24h":       "-24h is not accepted as window, please provide a positive duration like 24h",
+		"/api/v2/status/Report_A/flapping?threshold=many":    "many is not accepted as threshold, please provide a non negative number of state changes",
+		"/api/v2/status/Report_A/flapping?end_time=tomorrow": "Error parsing date string tomorrow please use zulu format like 2006-01-02T15:04:05Z",
+		"/api/v2/status/Report_X/flapping":                   "The report with the name Report_X does not exist",
+	} {
+		response := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", url, strings.NewReader(""))
+		request.Header.Set("Accept", "application/json")
+		request.Header.Set("x-api-key", "KEY1")
+		suite.router.ServeHTTP(response, request)
+		// Check that we must have a 400 bad request code
+		suite.Equal(400, response.Code, "Incorrect HTTP response code")
+		suite.Contains(response.Body.String(), expected, "Response body mismatch")
+	}
+}
+
+// This function is actually called in the end of all tests
+// and clears the test environment.
+// Mainly it's purpose is to drop the testdb
+func (suite *StatusFlappingTestSuite) TearDownTest() {
+
+	session, _ := mongo.OpenSession(suite.cfg.MongoDB)
+
+	session.DB("argotest_flapping").DropDatabase()
+	session.DB("argotest_flapping_egi").DropDatabase()
+}
+
+// This is the first function called when go test is issued
+func TestStatusFlappingSuite(t *testing.T) {
+	suite.Run(t, new(StatusFlappingTestSuite))
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2015 GRNET S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the
+ * License. You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an "AS
+ * IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language
+ * governing permissions and limitations under the License.
+ *
+ * The views and conclusions contained in the software and
+ * documentation are those of the authors and should not be
+ * interpreted as representing official policies, either expressed
+ * or implied, of GRNET S.A.
+ *
+ */
+
+package statusFlapping
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ARGOeu/argo-web-api/respond"
+)
+
+func createView(counts []*Flapping, input InputParams) ([]byte, error) {
+
+	docRoot := &rootOUT{}
+
+	var ppEndpointGroup *endpointGroupOUT
+	var ppService *serviceOUT
+
+	for _, count := range counts {
+
+		// an endpoint is listed when it is flapping itself or when any of its metrics is flapping
+		metrics := []*metricOUT{}
+		for _, metric := range count.Metrics {
+			if metric.Changes > input.threshold {
+				metrics = append(metrics, &metricOUT{
+					Name:    metric.Metric,
+					Changes: metric.Changes,
+					States:  metric.States,
+				})
+			}
+		}
+		if count.Changes <= input.threshold && len(metrics) == 0 {
+			continue
+		}
+
+		if ppEndpointGroup == nil || ppEndpointGroup.Name != count.EndpointGroup {
+			ppEndpointGroup = &endpointGroupOUT{
+				Name:      count.EndpointGroup,
+				GroupType: input.groupType,
+			}
+			docRoot.EndpointGroups = append(docRoot.EndpointGroups, ppEndpointGroup)
+			ppService = nil
+		}
+
+		if ppService == nil || ppService.Name != count.Service {
+			ppService = &serviceOUT{
+				Name:      count.Service,
+				GroupType: "service",
+			}
+			ppEndpointGroup.Services = append(ppEndpointGroup.Services, ppService)
+		}
+
+		ppService.Endpoints = append(ppService.Endpoints, &endpointOUT{
+			Name:    count.Hostname,
+			Changes: count.Changes,
+			States:  count.States,
+			Metrics: metrics,
+		})
+	}
+
+	return respond.MarshalContent(docRoot, input.format, "", " ")
+}
+
+func createMessageOUT(message string, format string) ([]byte, error) {
+
+	output := []byte("message placeholder")
+	err := error(nil)
+	docRoot := &messageOUT{}
+
+	docRoot.Message = message
+
+	output, err = respond.MarshalContent(docRoot, format, "", " ")
+	return output, err
+}
+
+// CSVRecords flattens the flapping endpoints and metrics into one record each, the states are separated by semicolons
+func (docRoot *rootOUT) CSVRecords() [][]string {
+	records := [][]string{{"group_type", "group", "service", "endpoint", "metric", "changes", "states"}}
+	for _, group := range docRoot.EndpointGroups {
+		for _, service := range group.Services {
+			for _, endpoint := range service.Endpoints {
+				records = append(records, []string{group.GroupType, group.Name, service.Name, endpoint.Name, "",
+					fmt.Sprint(endpoint.Changes), strings.Join(endpoint.States, ";")})
+				for _, metric := range endpoint.Metrics {
+					records = append(records, []string{group.GroupType, group.Name, service.Name, endpoint.Name, metric.Name,
+						fmt.Sprint(metric.Changes), strings.Join(metric.States, ";")})
+				}
+			}
+		}
+	}
+	return records
+}
+
+// CSVRecords renders the message as a single csv record
+func (docRoot *messageOUT) CSVRecords() [][]string {
+	return [][]string{{"message"}, {docRoot.Message}}
+}
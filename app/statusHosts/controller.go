@@ -0,0 +1,155 @@
+/*
+ * Copyright (c) 2015 GRNET S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the
+ * License. You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an "AS
+ * IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language
+ * governing permissions and limitations under the License.
+ *
+ * The views and conclusions contained in the software and
+ * documentation are those of the authors and should not be
+ * interpreted as representing official policies, either expressed
+ * or implied, of GRNET S.A.
+ *
+ */
+
+package statusHosts
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/github.com/gorilla/mux"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2/bson"
+	"github.com/ARGOeu/argo-web-api/app/reports"
+	"github.com/ARGOeu/argo-web-api/respond"
+	"github.com/ARGOeu/argo-web-api/utils/authentication"
+	"github.com/ARGOeu/argo-web-api/utils/config"
+	"github.com/ARGOeu/argo-web-api/utils/mongo"
+)
+
+// ListHostTimelines returns the endpoint timelines of a host for every endpoint group and service it
+// appears in, together with the timelines of its metrics
+func ListHostTimelines(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
+
+	//STANDARD DECLARATIONS START
+
+	code := http.StatusOK
+	h := http.Header{}
+	output := []byte("List Host Timelines")
+	err := error(nil)
+	contentType := "application/xml"
+	charset := "utf-8"
+
+	//STANDARD DECLARATIONS END
+
+	contentType, err = respond.ParseAcceptHeader(r, respond.TimelineContentTypes...)
+	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
+
+	if err != nil {
+		code = http.StatusNotAcceptable
+		output, _ = respond.MarshalContent(respond.NotAcceptableContentType, contentType, "", " ")
+		return code, h, output, err
+	}
+
+	// Parse the request into the input
+	urlValues := r.URL.Query()
+	vars := mux.Vars(r)
+
+	parsedStart, parsedEnd, errs := respond.ValidateDateRange(urlValues.Get("start_time"), urlValues.Get("end_time"))
+	intervals, end, intervalErrs := respond.ValidateIntervals(urlValues.Get("intervals"), urlValues.Get("end_time"))
+	errs = append(errs, intervalErrs...)
+	// Host timelines mix endpoints and metrics, they are only returned as statuses or intervals
+	for _, param := range []string{"status", "exclude_status", "bucket", "explain", "fill_gaps", "gap_threshold"} {
+		if urlValues.Get(param) != "" {
+			errs = append(errs, respond.ErrorResponse{
+				Message: "Unsupported parameter",
+				Code:    "400",
+				Details: fmt.Sprintf("The %s url parameter is not supported by the host status timelines", param),
+			})
+		}
+	}
+	if len(errs) > 0 {
+		code = http.StatusBadRequest
+		output = respond.CreateFailureResponseMessage("Bad Request", "400", errs).MarshalTo(contentType)
+		return code, h, output, nil
+	}
+
+	input := InputParams{
+		startTime: parsedStart,
+		endTime:   parsedEnd,
+		report:    vars["report_name"],
+		hostname:  vars["hostname"],
+		format:    contentType,
+		intervals: intervals,
+		end:       end,
+	}
+
+	// Call authenticateTenant to check the api key and retrieve
+	// the correct tenant db conf
+	tenantDbConfig, err := authentication.AuthenticateTenant(r.Header, cfg)
+	if err != nil {
+		if err.Error() == "Unauthorized" {
+			code = http.StatusUnauthorized
+			out := respond.UnauthorizedMessage
+			output = out.MarshalTo(contentType)
+			return code, h, output, err
+		}
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	session, err := mongo.OpenSession(tenantDbConfig)
+	defer mongo.CloseSession(session)
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	report := reports.MongoInterface{}
+	err = mongo.FindOne(session, tenantDbConfig.Db, "reports", bson.M{"info.name": input.report}, &report)
+	if err != nil {
+		code = http.StatusBadRequest
+		message := "The report with the name " + input.report + " does not exist"
+		output, err := createMessageOUT(message, contentType) //Render the response into XML or JSON
+		return code, h, output, err
+	}
+	input.groupType = report.GetEndpointGroupType()
+
+	endpoints := []EndpointOutput{}
+	err = session.DB(tenantDbConfig.Db).C("status_endpoints").
+		Find(prepareQuery(input, report.ID)).Sort("endpoint_group", "service", "timestamp").All(&endpoints)
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	metrics := []MetricOutput{}
+	err = session.DB(tenantDbConfig.Db).C("status_metrics").
+		Find(prepareQuery(input, report.ID)).Sort("endpoint_group", "service", "metric", "timestamp").All(&metrics)
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	output, err = createView(endpoints, metrics, input) //Render the results into JSON/XML format
+
+	return code, h, output, err
+}
+
+// prepareQuery matches the statuses of a host in any endpoint group and service of the report
+func prepareQuery(input InputParams, reportID string) bson.M {
+	return bson.M{
+		"report":       reportID,
+		"date_integer": bson.M{"$gte": input.startTime, "$lte": input.endTime},
+		"host":         input.hostname,
+	}
+}
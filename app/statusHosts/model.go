@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2015 GRNET S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the
+ * License. You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an "AS
+ * IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language
+ * governing permissions and limitations under the License.
+ *
+ * The views and conclusions contained in the software and
+ * documentation are those of the authors and should not be
+ * interpreted as representing official policies, either expressed
+ * or implied, of GRNET S.A.
+ *
+ */
+
+package statusHosts
+
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/ARGOeu/argo-web-api/respond"
+)
+
+const zuluForm = "2006-01-02T15:04:05Z"
+
+// InputParams struct holds as input all the url params of the request
+type InputParams struct {
+	startTime int // UTC time in W3C format
+	endTime   int
+	report    string
+	groupType string
+	hostname  string
+	format    string
+	intervals bool
+	end       time.Time
+}
+
+// EndpointOutput struct holds a status of the endpoint timelines as queried from datastore
+type EndpointOutput struct {
+	Timestamp     string `bson:"timestamp"`
+	EndpointGroup string `bson:"endpoint_group"`
+	Service       string `bson:"service"`
+	Hostname      string `bson:"host"`
+	Status        string `bson:"status"`
+}
+
+// MetricOutput struct holds a status of the metric timelines as queried from datastore
+type MetricOutput struct {
+	Timestamp     string `bson:"timestamp"`
+	EndpointGroup string `bson:"endpoint_group"`
+	Service       string `bson:"service"`
+	Hostname      string `bson:"host"`
+	Metric        string `bson:"metric"`
+	Status        string `bson:"status"`
+	PrevTimestamp string `bson:"previous_timestamp"`
+	PrevStatus    string `bson:"previous_state"`
+}
+
+// json/xml response related structs
+type rootOUT struct {
+	XMLName        xml.Name            `xml:"root" json:"-"`
+	EndpointGroups []*endpointGroupOUT `json:"groups"`
+	intervals      bool
+}
+
+type endpointGroupOUT struct {
+	XMLName   xml.Name      `xml:"group" json:"-"`
+	Name      string        `xml:"name,attr" json:"name"`
+	GroupType string        `xml:"type,attr" json:"type"`
+	Services  []*serviceOUT `json:"services"`
+}
+
+type serviceOUT struct {
+	XMLName   xml.Name       `xml:"group" json:"-"`
+	Name      string         `xml:"name,attr" json:"name"`
+	GroupType string         `xml:"type,attr" json:"type"`
+	Endpoints []*endpointOUT `json:"endpoints"`
+}
+
+type endpointOUT struct {
+	XMLName   xml.Name                    `xml:"endpoint" json:"-"`
+	Name      string                      `xml:"name,attr" json:"name"`
+	Statuses  []*respond.TimelineStatus   `json:"statuses,omitempty"`
+	Intervals []*respond.TimelineInterval `json:"intervals,omitempty"`
+	Metrics   []*metricOUT                `json:"metrics,omitempty"`
+}
+
+type metricOUT struct {
+	XMLName   xml.Name                    `xml:"metric" json:"-"`
+	Name      string                      `xml:"name,attr" json:"name"`
+	Statuses  []*respond.TimelineStatus   `json:"statuses,omitempty"`
+	Intervals []*respond.TimelineInterval `json:"intervals,omitempty"`
+}
+
+// Message struct to hold the json/xml response
+type messageOUT struct {
+	XMLName xml.Name `xml:"root" json:"-"`
+	Message string   `xml:"message" json:"message"`
+}
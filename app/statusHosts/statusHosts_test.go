@@ -0,0 +1,408 @@
+/*
+ * Copyright (c) 2015 GRNET S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the
+ * License. You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an "AS
+ * IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language
+ * governing permissions and limitations under the License.
+ *
+ * The views and conclusions contained in the software and
+ * documentation are those of the authors and should not be
+ * interpreted as representing official policies, either expressed
+ * or implied, of GRNET S.A.
+ *
+ */
+
+package statusHosts
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/github.com/gorilla/mux"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/github.com/stretchr/testify/suite"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/gcfg.v1"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2/bson"
+	"github.com/ARGOeu/argo-web-api/respond"
+	"github.com/ARGOeu/argo-web-api/utils/authentication"
+	"github.com/ARGOeu/argo-web-api/utils/config"
+	"github.com/ARGOeu/argo-web-api/utils/mongo"
+)
+
+// This is a util. suite struct used in tests (see pkg "testify")
+type StatusHostsTestSuite struct {
+	suite.Suite
+	cfg          config.Config
+	router       *mux.Router
+	confHandler  respond.ConfHandler
+	tenantDbConf config.MongoConfig
+}
+
+// Setup the Test Environment
+// This function runs before any test and setups the environment
+// A test configuration object is instantiated using a reference
+// to testdb: argotest_hosts. Also the testdb is seeded with a tenant,
+// a report and the endpoint and metric statuses of hosts in several groups and services
+func (suite *StatusHostsTestSuite) SetupTest() {
+
+	const testConfig = `
+    [server]
+    bindip = ""
+    port = 8080
+    maxprocs = 4
+    cache = false
+    lrucache = 700000000
+    gzip = true
+    [mongodb]
+    host = "127.0.0.1"
+    port = 27017
+    db = "argotest_hosts"
+`
+
+	_ = gcfg.ReadStringInto(&suite.cfg, testConfig)
+
+	// Create router and confhandler for test
+	suite.confHandler = respond.ConfHandler{suite.cfg}
+	suite.router = mux.NewRouter().StrictSlash(true).PathPrefix("/api/v2/status").Subrouter()
+	HandleSubrouter(suite.router, &suite.confHandler)
+
+	// Connect to mongo testdb
+	session, _ := mongo.OpenSession(suite.cfg.MongoDB)
+
+	// Add authentication token to mongo testdb
+	seedAuth := bson.M{"api_key": "S3CR3T"}
+	_ = mongo.Insert(session, suite.cfg.MongoDB.Db, "authentication", seedAuth)
+
+	// seed mongo
+	session, err := mgo.Dial(suite.cfg.MongoDB.Host)
+	if err != nil {
+		panic(err)
+	}
+	defer session.Close()
+
+	// seed a tenant to use
+	c := session.DB(suite.cfg.MongoDB.Db).C("tenants")
+	c.Insert(bson.M{
+		"id": "6ac7d684-1f8e-4a02-a502-720e8f11e50c",
+		"info": bson.M{
+			"name":    "GUARDIANS",
+			"email":   "email@something2",
+			"website": "www.gotg.com",
+			"created": "2015-10-20 02:08:04",
+			"updated": "2015-10-20 02:08:04"},
+		"db_conf": []bson.M{
+			bson.M{
+				"store":    "main",
+				"server":   "localhost",
+				"port":     27017,
+				"database": "argotest_hosts_egi",
+				"username": "",
+				"password": ""},
+		},
+		"users": []bson.M{
+			bson.M{
+				"name":    "egi_user",
+				"email":   "egi_user@email.com",
+				"api_key": "KEY1"},
+		}})
+
+	// get dbconfiguration based on the tenant
+	// Prepare the request object
+	request, _ := http.NewRequest("GET", "", strings.NewReader(""))
+	// add the content-type header to application/json
+	request.Header.Set("Content-Type", "application/json")
+	// add the authentication token which is seeded in testdb
+	request.Header.Set("x-api-key", "KEY1")
+	// authenticate user's api key and find corresponding tenant
+	suite.tenantDbConf, err = authentication.AuthenticateTenant(request.Header, suite.cfg)
+
+	// Now seed the report DEFINITIONS
+	c = session.DB(suite.tenantDbConf.Db).C("reports")
+	c.Insert(bson.M{
+		"id": "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"info": bson.M{
+			"name":        "Report_A",
+			"description": "report aaaaa",
+			"created":     "2015-9-10 13:43:00",
+			"updated":     "2015-10-11 13:43:00",
+		},
+		"topology_schema": bson.M{
+			"group": bson.M{
+				"type": "NGI",
+				"group": bson.M{
+					"type": "SITES",
+				},
+			},
+		},
+		"profiles": []bson.M{
+			bson.M{
+				"id":   "6ac7d684-1f8e-4a02-a502-720e8f11e50b",
+				"type": "metric",
+				"name": "profile1"},
+			bson.M{
+				"id":   "6ac7d684-1f8e-4a02-a502-720e8f11e523",
+				"type": "operations",
+				"name": "profile2"},
+			bson.M{
+				"id":   "6ac7d684-1f8e-4a02-a502-720e8f11e50q",
+				"type": "aggregation",
+				"name": "profile3"},
+		},
+		"filter_tags": []bson.M{
+			bson.M{
+				"name":  "name1",
+				"value": "value1"},
+		}})
+
+	// seed the endpoint statuses, the host serves two services of one site and belongs to a second site
+	c = session.DB(suite.tenantDbConf.Db).C("status_endpoints")
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T00:00:00Z",
+		"endpoint_group": "HG-02-IASA",
+		"service":        "CREAM-CE",
+		"host":           "cream01.afroditi.gr",
+		"status":         "OK",
+	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T00:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"host":           "cream01.afroditi.gr",
+		"status":         "OK",
+	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T01:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"host":           "cream01.afroditi.gr",
+		"status":         "CRITICAL",
+	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T05:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"host":           "cream01.afroditi.gr",
+		"status":         "OK",
+	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T00:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "SRMv2",
+		"host":           "cream01.afroditi.gr",
+		"status":         "OK",
+	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T00:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"host":           "cream02.afroditi.gr",
+		"status":         "OK",
+	})
+
+	// seed the metric statuses
+	c = session.DB(suite.tenantDbConf.Db).C("status_metrics")
+	c.Insert(bson.M{
+		"report":             "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":       20150501,
+		"timestamp":          "2015-05-01T00:00:00Z",
+		"endpoint_group":     "HG-03-AUTH",
+		"service":            "CREAM-CE",
+		"host":               "cream01.afroditi.gr",
+		"metric":             "emi.cream.CREAMCE-JobSubmit",
+		"status":             "OK",
+		"previous_state":     "OK",
+		"previous_timestamp": "2015-04-30T23:59:00Z",
+	})
+	c.Insert(bson.M{
+		"report":             "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":       20150501,
+		"timestamp":          "2015-05-01T01:00:00Z",
+		"endpoint_group":     "HG-03-AUTH",
+		"service":            "CREAM-CE",
+		"host":               "cream01.afroditi.gr",
+		"metric":             "emi.cream.CREAMCE-JobSubmit",
+		"status":             "CRITICAL",
+		"previous_state":     "OK",
+		"previous_timestamp": "2015-05-01T00:00:00Z",
+	})
+	c.Insert(bson.M{
+		"report":             "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":       20150501,
+		"timestamp":          "2015-05-01T05:00:00Z",
+		"endpoint_group":     "HG-03-AUTH",
+		"service":            "CREAM-CE",
+		"host":               "cream01.afroditi.gr",
+		"metric":             "emi.cream.CREAMCE-JobSubmit",
+		"status":             "OK",
+		"previous_state":     "CRITICAL",
+		"previous_timestamp": "2015-05-01T01:00:00Z",
+	})
+	c.Insert(bson.M{
+		"report":             "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":       20150501,
+		"timestamp":          "2015-05-01T00:00:00Z",
+		"endpoint_group":     "HG-03-AUTH",
+		"service":            "SRMv2",
+		"host":               "cream01.afroditi.gr",
+		"metric":             "hr.srce.SRM2-CertLifetime",
+		"status":             "OK",
+		"previous_state":     "OK",
+		"previous_timestamp": "2015-04-30T23:59:00Z",
+	})
+	c.Insert(bson.M{
+		"report":             "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":       20150501,
+		"timestamp":          "2015-05-01T00:00:00Z",
+		"endpoint_group":     "HG-03-AUTH",
+		"service":            "CREAM-CE",
+		"host":               "cream02.afroditi.gr",
+		"metric":             "emi.cream.CREAMCE-JobSubmit",
+		"status":             "OK",
+		"previous_state":     "OK",
+		"previous_timestamp": "2015-04-30T23:59:00Z",
+	})
+}
+
+// TestListHostTimelines tests the timelines of a host across the groups and services it appears in
+func (suite *StatusHostsTestSuite) TestListHostTimelines() {
+	respXML := `<root>
+ <group name="HG-02-IASA" type="SITES">
+  <group name="CREAM-CE" type="service">
+   <endpoint name="cream01.afroditi.gr">
+    <status timestamp="2015-05-01T00:00:00Z" value="OK"></status>
+   </endpoint>
+  </group>
+ </group>
+ <group name="HG-03-AUTH" type="SITES">
+  <group name="CREAM-CE" type="service">
+   <endpoint name="cream01.afroditi.gr">
+    <status timestamp="2015-05-01T00:00:00Z" value="OK"></status>
+    <status timestamp="2015-05-01T01:00:00Z" value="CRITICAL"></status>
+    <status timestamp="2015-05-01T05:00:00Z" value="OK"></status>
+    <metric name="emi.cream.CREAMCE-JobSubmit">
+     <status timestamp="2015-04-30T23:59:00Z" value="OK"></status>
+     <status timestamp="2015-05-01T00:00:00Z" value="OK"></status>
+     <status timestamp="2015-05-01T01:00:00Z" value="CRITICAL"></status>
+     <status timestamp="2015-05-01T05:00:00Z" value="OK"></status>
+    </metric>
+   </endpoint>
+  </group>
+  <group name="SRMv2" type="service">
+   <endpoint name="cream01.afroditi.gr">
+    <status timestamp="2015-05-01T00:00:00Z" value="OK"></status>
+    <metric name="hr.srce.SRM2-CertLifetime">
+     <status timestamp="2015-04-30T23:59:00Z" value="OK"></status>
+     <status timestamp="2015-05-01T00:00:00Z" value="OK"></status>
+    </metric>
+   </endpoint>
+  </group>
+ </group>
+</root>`
+
+	fullurl := "/api/v2/status/Report_A/endpoints/cream01.afroditi.gr" +
+		"?start_time=2015-05-01T00:00:00Z&end_time=2015-05-01T23:00:00Z"
+
+	// init the response placeholder
+	response := httptest.NewRecorder()
+	// Prepare the request object
+	request, _ := http.NewRequest("GET", fullurl, strings.NewReader(""))
+	// add xml accept header
+	request.Header.Set("Accept", "application/xml")
+	// add the authentication token which is seeded in testdb
+	request.Header.Set("x-api-key", "KEY1")
+	// Serve the http request
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Compare the expected and actual xml response
+	suite.Equal(respXML, response.Body.String(), "Response body mismatch")
+
+	respCSV := `group_type,group,service,endpoint,metric,from,to,duration_seconds,status,previous_status
+SITES,HG-02-IASA,CREAM-CE,cream01.afroditi.gr,,2015-05-01T00:00:00Z,2015-05-01T23:00:00Z,82800,OK,
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,,2015-05-01T00:00:00Z,2015-05-01T01:00:00Z,3600,OK,
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,,2015-05-01T01:00:00Z,2015-05-01T05:00:00Z,14400,CRITICAL,OK
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,,2015-05-01T05:00:00Z,2015-05-01T23:00:00Z,64800,OK,CRITICAL
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,emi.cream.CREAMCE-JobSubmit,2015-04-30T23:59:00Z,2015-05-01T00:00:00Z,60,OK,
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,emi.cream.CREAMCE-JobSubmit,2015-05-01T00:00:00Z,2015-05-01T01:00:00Z,3600,OK,OK
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,emi.cream.CREAMCE-JobSubmit,2015-05-01T01:00:00Z,2015-05-01T05:00:00Z,14400,CRITICAL,OK
+SITES,HG-03-AUTH,CREAM-CE,cream01.afroditi.gr,emi.cream.CREAMCE-JobSubmit,2015-05-01T05:00:00Z,2015-05-01T23:00:00Z,64800,OK,CRITICAL
+SITES,HG-03-AUTH,SRMv2,cream01.afroditi.gr,,2015-05-01T00:00:00Z,2015-05-01T23:00:00Z,82800,OK,
+SITES,HG-03-AUTH,SRMv2,cream01.afroditi.gr,hr.srce.SRM2-CertLifetime,2015-04-30T23:59:00Z,2015-05-01T00:00:00Z,60,OK,
+SITES,HG-03-AUTH,SRMv2,cream01.afroditi.gr,hr.srce.SRM2-CertLifetime,2015-05-01T00:00:00Z,2015-05-01T23:00:00Z,82800,OK,OK
+`
+
+	// init the response placeholder
+	response = httptest.NewRecorder()
+	// Prepare the request object
+	request, _ = http.NewRequest("GET", fullurl+"&intervals=true", strings.NewReader(""))
+	// add csv accept header
+	request.Header.Set("Accept", "text/csv")
+	// add the authentication token which is seeded in testdb
+	request.Header.Set("x-api-key", "KEY1")
+	// Serve the http request
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Internal Server Error")
+	// Compare the expected and actual csv response
+	suite.Equal(respCSV, response.Body.String(), "Response body mismatch")
+}
+
+func (suite *StatusHostsTestSuite) TestListHostTimelinesErrors() {
+
+	for url, expected := range map[string]string{
+		"/api/v2/status/Report_A/endpoints/cream01.afroditi.gr":                                                   "Please use start_time and/or end_time url parameters to set the prefered time span",
+		"/api/v2/status/Report_A/endpoints/cream01.afroditi.gr?start_time=2015-05-01T00:00:00Z&intervals=true":    "Please use the end_time url parameter to set the end of the last interval",
+		"/api/v2/status/Report_X/endpoints/cream01.afroditi.gr?start_time=2015-05-01T00:00:00Z":                   "The report with the name Report_X does not exist",
+		"/api/v2/status/Report_A/endpoints/cream01.afroditi.gr?start_time=2015-05-01T00:00:00Z&status=CRITICAL":   "The status url parameter is not supported by the host status timelines",
+		"/api/v2/status/Report_A/endpoints/cream01.afroditi.gr?start_time=2015-05-01T00:00:00Z&exclude_status=OK": "The exclude_status url parameter is not supported by the host status timelines",
+		"/api/v2/status/Report_A/endpoints/cream01.afroditi.gr?start_time=2015-05-01T00:00:00Z&bucket=1h":         "The bucket url parameter is not supported by the host status timelines",
+		"/api/v2/status/Report_A/endpoints/cream01.afroditi.gr?start_time=2015-05-01T00:00:00Z&fill_gaps=true":    "The fill_gaps url parameter is not supported by the host status timelines",
+	} {
+		response := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", url, strings.NewReader(""))
+		request.Header.Set("Accept", "application/json")
+		request.Header.Set("x-api-key", "KEY1")
+		suite.router.ServeHTTP(response, request)
+		// Check that we must have a 400 bad request code
+		suite.Equal(400, response.Code, "Incorrect HTTP response code")
+		suite.Contains(response.Body.String(), expected, "Response body mismatch")
+	}
+}
+
+// This function is actually called in the end of all tests
+// and clears the test environment.
+// Mainly it's purpose is to drop the testdb
+func (suite *StatusHostsTestSuite) TearDownTest() {
+
+	session, _ := mongo.OpenSession(suite.cfg.MongoDB)
+
+	session.DB("argotest_hosts").DropDatabase()
+	session.DB("argotest_hosts_egi").DropDatabase()
+}
+
+// This is the first function called when go test is issued
+func TestStatusHostsSuite(t *testing.T) {
+	suite.Run(t, new(StatusHostsTestSuite))
+}
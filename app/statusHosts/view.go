@@ -0,0 +1,172 @@
+/*
+ * Copyright (c) 2015 GRNET S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the
+ * License. You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an "AS
+ * IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language
+ * governing permissions and limitations under the License.
+ *
+ * The views and conclusions contained in the software and
+ * documentation are those of the authors and should not be
+ * interpreted as representing official policies, either expressed
+ * or implied, of GRNET S.A.
+ *
+ */
+
+package statusHosts
+
+import (
+	"fmt"
+
+	"github.com/ARGOeu/argo-web-api/respond"
+)
+
+func createView(endpoints []EndpointOutput, metrics []MetricOutput, input InputParams) ([]byte, error) {
+
+	docRoot := &rootOUT{intervals: input.intervals}
+
+	for _, row := range endpoints {
+		endpoint := docRoot.endpoint(row.EndpointGroup, row.Service, row.Hostname, input.groupType)
+		endpoint.Statuses = append(endpoint.Statuses, &respond.TimelineStatus{
+			Timestamp: row.Timestamp,
+			Value:     row.Status,
+		})
+	}
+
+	for _, row := range metrics {
+		endpoint := docRoot.endpoint(row.EndpointGroup, row.Service, row.Hostname, input.groupType)
+		// metric statuses are sorted by endpoint group, service and metric so a new metric starts
+		// whenever the last metric of the endpoint is a different one
+		if len(endpoint.Metrics) == 0 || endpoint.Metrics[len(endpoint.Metrics)-1].Name != row.Metric {
+			//Add the prev status as the firstone
+			metric := &metricOUT{Name: row.Metric}
+			metric.Statuses = append(metric.Statuses, &respond.TimelineStatus{
+				Timestamp: row.PrevTimestamp,
+				Value:     row.PrevStatus,
+			})
+			endpoint.Metrics = append(endpoint.Metrics, metric)
+		}
+		ppMetric := endpoint.Metrics[len(endpoint.Metrics)-1]
+		ppMetric.Statuses = append(ppMetric.Statuses, &respond.TimelineStatus{
+			Timestamp: row.Timestamp,
+			Value:     row.Status,
+		})
+	}
+
+	if input.intervals {
+		for _, endpoint := range docRoot.endpoints() {
+			endpoint.Intervals = respond.CreateIntervals(endpoint.Statuses, input.end)
+			endpoint.Statuses = nil
+			for _, metric := range endpoint.Metrics {
+				metric.Intervals = respond.CreateIntervals(metric.Statuses, input.end)
+				metric.Statuses = nil
+			}
+		}
+	}
+
+	return respond.MarshalContent(docRoot, input.format, "", " ")
+}
+
+// endpoint finds the endpoint of a host under an endpoint group and a service of the document, adding
+// the endpoint group, the service and the endpoint the first time they are seen
+func (docRoot *rootOUT) endpoint(group string, service string, hostname string, groupType string) *endpointOUT {
+	var ppEndpointGroup *endpointGroupOUT
+	for _, endpointGroup := range docRoot.EndpointGroups {
+		if endpointGroup.Name == group {
+			ppEndpointGroup = endpointGroup
+			break
+		}
+	}
+	if ppEndpointGroup == nil {
+		ppEndpointGroup = &endpointGroupOUT{Name: group, GroupType: groupType}
+		docRoot.EndpointGroups = append(docRoot.EndpointGroups, ppEndpointGroup)
+	}
+
+	var ppService *serviceOUT
+	for _, groupService := range ppEndpointGroup.Services {
+		if groupService.Name == service {
+			ppService = groupService
+			break
+		}
+	}
+	if ppService == nil {
+		ppService = &serviceOUT{Name: service, GroupType: "service"}
+		ppEndpointGroup.Services = append(ppEndpointGroup.Services, ppService)
+	}
+
+	if len(ppService.Endpoints) == 0 {
+		ppService.Endpoints = append(ppService.Endpoints, &endpointOUT{Name: hostname})
+	}
+	return ppService.Endpoints[0]
+}
+
+// endpoints collects the endpoints of the host under all services of all endpoint groups
+func (docRoot *rootOUT) endpoints() []*endpointOUT {
+	endpoints := []*endpointOUT{}
+	for _, endpointGroup := range docRoot.EndpointGroups {
+		for _, service := range endpointGroup.Services {
+			endpoints = append(endpoints, service.Endpoints...)
+		}
+	}
+	return endpoints
+}
+
+func createMessageOUT(message string, format string) ([]byte, error) {
+
+	output := []byte("message placeholder")
+	err := error(nil)
+	docRoot := &messageOUT{}
+
+	docRoot.Message = message
+
+	output, err = respond.MarshalContent(docRoot, format, "", " ")
+	return output, err
+}
+
+// CSVRecords flattens the timelines into one record per status or interval of the host under every
+// service, followed by the records of its metrics
+func (docRoot *rootOUT) CSVRecords() [][]string {
+	if docRoot.intervals {
+		records := [][]string{{"group_type", "group", "service", "endpoint", "metric", "from", "to", "duration_seconds", "status", "previous_status"}}
+		for _, group := range docRoot.EndpointGroups {
+			for _, service := range group.Services {
+				for _, endpoint := range service.Endpoints {
+					for _, interval := range endpoint.Intervals {
+						records = append(records, []string{group.GroupType, group.Name, service.Name, endpoint.Name, "",
+							interval.From, interval.To, fmt.Sprint(interval.Duration), interval.Value, interval.PreviousValue})
+					}
+					for _, metric := range endpoint.Metrics {
+						for _, interval := range metric.Intervals {
+							records = append(records, []string{group.GroupType, group.Name, service.Name, endpoint.Name, metric.Name,
+								interval.From, interval.To, fmt.Sprint(interval.Duration), interval.Value, interval.PreviousValue})
+						}
+					}
+				}
+			}
+		}
+		return records
+	}
+	records := [][]string{{"group_type", "group", "service", "endpoint", "metric", "timestamp", "status"}}
+	for _, group := range docRoot.EndpointGroups {
+		for _, service := range group.Services {
+			for _, endpoint := range service.Endpoints {
+				for _, status := range endpoint.Statuses {
+					records = append(records, []string{group.GroupType, group.Name, service.Name, endpoint.Name, "", status.Timestamp, status.Value})
+				}
+				for _, metric := range endpoint.Metrics {
+					for _, status := range metric.Statuses {
+						records = append(records, []string{group.GroupType, group.Name, service.Name, endpoint.Name, metric.Name, status.Timestamp, status.Value})
+					}
+				}
+			}
+		}
+	}
+	return records
+}
@@ -0,0 +1,258 @@
+/*
+ * Copyright (c) 2015 GRNET S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the
+ * License. You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an "AS
+ * IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language
+ * governing permissions and limitations under the License.
+ *
+ * The views and conclusions contained in the software and
+ * documentation are those of the authors and should not be
+ * interpreted as representing official policies, either expressed
+ * or implied, of GRNET S.A.
+ *
+ */
+
+package statusLatest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/github.com/gorilla/mux"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2/bson"
+	"github.com/ARGOeu/argo-web-api/app/operationsProfiles"
+	"github.com/ARGOeu/argo-web-api/app/reports"
+	"github.com/ARGOeu/argo-web-api/respond"
+	"github.com/ARGOeu/argo-web-api/utils/authentication"
+	"github.com/ARGOeu/argo-web-api/utils/config"
+	"github.com/ARGOeu/argo-web-api/utils/mongo"
+)
+
+// ListLatest returns the most recent status of every endpoint group, service, endpoint or metric of a report
+func ListLatest(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
+
+	//STANDARD DECLARATIONS START
+
+	code := http.StatusOK
+	h := http.Header{}
+	output := []byte("List Latest Statuses")
+	err := error(nil)
+	contentType := "application/xml"
+	charset := "utf-8"
+
+	//STANDARD DECLARATIONS END
+
+	contentType, err = respond.ParseAcceptHeader(r, respond.TimelineContentTypes...)
+	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
+
+	if err != nil {
+		code = http.StatusNotAcceptable
+		output, _ = respond.MarshalContent(respond.NotAcceptableContentType, contentType, "", " ")
+		return code, h, output, err
+	}
+
+	// Parse the request into the input
+	urlValues := r.URL.Query()
+	vars := mux.Vars(r)
+
+	input := InputParams{
+		report:    vars["report_name"],
+		groupType: urlValues.Get("group_type"),
+		state:     urlValues.Get("state"),
+		depth:     urlValues.Get("depth"),
+		format:    contentType,
+	}
+
+	errs := []respond.ErrorResponse{}
+
+	if input.depth == "" {
+		input.depth = "groups"
+	}
+	if _, ok := depths[input.depth]; !ok {
+		errs = append(errs, respond.ErrorResponse{
+			Message: "Wrong depth",
+			Code:    "400",
+			Details: "Please use one of groups, services, endpoints or metrics as the depth of the snapshot",
+		})
+	}
+
+	// Only the statuses since start_time are considered when it is set, by default the whole history
+	if urlValues.Get("start_time") != "" {
+		start, err := time.Parse(zuluForm, urlValues.Get("start_time"))
+		if err != nil {
+			errs = append(errs, respond.ErrorResponse{
+				Message: "start_time parsing error",
+				Code:    "400",
+				Details: fmt.Sprintf("Error parsing date string %s please use zulu format like %s", urlValues.Get("start_time"), zuluForm),
+			})
+		}
+		input.startTime, _ = strconv.Atoi(start.Format(ymdForm))
+	}
+
+	// Call authenticateTenant to check the api key and retrieve
+	// the correct tenant db conf
+	tenantDbConfig, err := authentication.AuthenticateTenant(r.Header, cfg)
+	if err != nil {
+		if err.Error() == "Unauthorized" {
+			code = http.StatusUnauthorized
+			out := respond.UnauthorizedMessage
+			output = out.MarshalTo(contentType)
+			return code, h, output, err
+		}
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	session, err := mongo.OpenSession(tenantDbConfig)
+	defer mongo.CloseSession(session)
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	report := reports.MongoInterface{}
+	err = mongo.FindOne(session, tenantDbConfig.Db, "reports", bson.M{"info.name": input.report}, &report)
+	if err != nil {
+		code = http.StatusBadRequest
+		message := "The report with the name " + input.report + " does not exist"
+		output, err := createMessageOUT(message, contentType) //Render the response into XML or JSON
+		return code, h, output, err
+	}
+
+	if input.groupType == "" {
+		input.groupType = report.GetEndpointGroupType()
+	} else if input.groupType != report.GetEndpointGroupType() {
+		errs = append(errs, respond.ErrorResponse{
+			Message: "Wrong group type",
+			Code:    "400",
+			Details: fmt.Sprintf("The report %s does not define endpoint group type: %s", input.report, input.groupType),
+		})
+	}
+
+	if input.state != "" {
+		profile, err := operationsProfiles.GetReportProfile(session, tenantDbConfig.Db, input.report)
+		if err != nil || !profile.HasState(input.state) {
+			errs = append(errs, respond.ErrorResponse{
+				Message: "Unknown state",
+				Code:    "400",
+				Details: fmt.Sprintf("The state %s is not declared in the operations profile of the report %s", input.state, input.report),
+			})
+		}
+	}
+
+	if len(errs) > 0 {
+		code = http.StatusBadRequest
+		output = respond.CreateFailureResponseMessage("Bad Request", "400", errs).MarshalTo(contentType)
+		return code, h, output, nil
+	}
+
+	depth := depths[input.depth]
+	collection := session.DB(tenantDbConfig.Db).C(depth.collection)
+	states := []StateOutput{}
+	err = collection.Pipe(prepareQuery(input, report.ID, depth.keys)).All(&states)
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	results := latestStatuses(states)
+	err = lookupSince(collection, input, report.ID, depth.keys, results)
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	output, err = createView(results, input) //Render the results into JSON/XML format
+
+	return code, h, output, err
+}
+
+// prepareQuery aggregates the first and the last time every entity of the depth was seen in each state,
+// sorted by entity and by the last time
+func prepareQuery(input InputParams, reportID string, keys []string) []bson.M {
+	id := bson.M{"status": "$status"}
+	project := bson.M{"_id": 0, "status": "$_id.status", "first": 1, "last": 1}
+	sort := bson.D{}
+	for _, key := range keys {
+		id[key] = "$" + key
+		project[key] = "$_id." + key
+		sort = append(sort, bson.DocElem{Name: key, Value: 1})
+	}
+	sort = append(sort, bson.DocElem{Name: "last", Value: 1})
+
+	return []bson.M{
+		{"$match": bson.M{
+			"report":       reportID,
+			"date_integer": bson.M{"$gte": input.startTime},
+		}},
+		{"$group": bson.M{
+			"_id":   id,
+			"first": bson.M{"$min": "$timestamp"},
+			"last":  bson.M{"$max": "$timestamp"},
+		}},
+		{"$project": project},
+		{"$sort": sort},
+	}
+}
+
+// lookupSince finds the start of the current run of the entities that were in their latest state before they
+// left another state for the last time. The run starts with the first status after they left the other state
+func lookupSince(collection *mgo.Collection, input InputParams, reportID string, keys []string, results []LatestOutput) error {
+	runs := []bson.M{}
+	for _, result := range results {
+		if result.Since != "" {
+			continue
+		}
+		run := bson.M{"timestamp": bson.M{"$gt": result.left}}
+		for _, key := range keys {
+			run[key] = result.field(key)
+		}
+		runs = append(runs, run)
+	}
+	if len(runs) == 0 {
+		return nil
+	}
+
+	id := bson.M{}
+	project := bson.M{"_id": 0, "since": 1}
+	for _, key := range keys {
+		id[key] = "$" + key
+		project[key] = "$_id." + key
+	}
+	since := []LatestOutput{}
+	err := collection.Pipe([]bson.M{
+		{"$match": bson.M{
+			"report":       reportID,
+			"date_integer": bson.M{"$gte": input.startTime},
+			"$or":          runs,
+		}},
+		{"$group": bson.M{"_id": id, "since": bson.M{"$min": "$timestamp"}}},
+		{"$project": project},
+	}).All(&since)
+	if err != nil {
+		return err
+	}
+
+	runStarts := map[string]string{}
+	for _, run := range since {
+		runStarts[run.entity()] = run.Since
+	}
+	for i := range results {
+		if results[i].Since == "" {
+			results[i].Since = runStarts[results[i].entity()]
+		}
+	}
+	return nil
+}
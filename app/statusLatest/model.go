@@ -0,0 +1,179 @@
+/*
+ * Copyright (c) 2015 GRNET S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the
+ * License. You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an "AS
+ * IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language
+ * governing permissions and limitations under the License.
+ *
+ * The views and conclusions contained in the software and
+ * documentation are those of the authors and should not be
+ * interpreted as representing official policies, either expressed
+ * or implied, of GRNET S.A.
+ *
+ */
+
+package statusLatest
+
+import "encoding/xml"
+
+const zuluForm = "2006-01-02T15:04:05Z"
+const ymdForm = "20060102"
+
+// depths maps every depth of the snapshot to the status collection and the fields that identify its entities
+var depths = map[string]struct {
+	collection string
+	keys       []string
+}{
+	"groups":    {"status_endpoint_groups", []string{"endpoint_group"}},
+	"services":  {"status_services", []string{"endpoint_group", "service"}},
+	"endpoints": {"status_endpoints", []string{"endpoint_group", "service", "host"}},
+	"metrics":   {"status_metrics", []string{"endpoint_group", "service", "host", "metric"}},
+}
+
+// InputParams struct holds as input all the url params of the request
+type InputParams struct {
+	startTime int
+	report    string
+	groupType string
+	state     string
+	depth     string
+	format    string
+}
+
+// DataOutput struct holds the queried data from datastore
+type DataOutput struct {
+	Timestamp     string `bson:"timestamp"`
+	EndpointGroup string `bson:"endpoint_group"`
+	Service       string `bson:"service"`
+	Hostname      string `bson:"host"`
+	Metric        string `bson:"metric"`
+	Status        string `bson:"status"`
+}
+
+// StateOutput holds the first and the last time an entity was seen in a state, as aggregated from datastore
+type StateOutput struct {
+	EndpointGroup string `bson:"endpoint_group"`
+	Service       string `bson:"service"`
+	Hostname      string `bson:"host"`
+	Metric        string `bson:"metric"`
+	Status        string `bson:"status"`
+	First         string `bson:"first"`
+	Last          string `bson:"last"`
+}
+
+// LatestOutput holds the most recent status of an entity and the time since it has been in that state
+type LatestOutput struct {
+	DataOutput `bson:",inline"`
+	Since      string `bson:"since"`
+	left       string // the last time the entity was seen in another state
+}
+
+// json/xml response related structs
+
+type rootOUT struct {
+	XMLName        xml.Name            `xml:"root" json:"-"`
+	EndpointGroups []*endpointGroupOUT `json:"groups"`
+}
+
+type endpointGroupOUT struct {
+	XMLName   xml.Name      `xml:"group" json:"-"`
+	Name      string        `xml:"name,attr" json:"name"`
+	GroupType string        `xml:"type,attr" json:"type"`
+	Status    *statusOUT    `json:"status,omitempty"`
+	Services  []*serviceOUT `json:"services,omitempty"`
+}
+
+type serviceOUT struct {
+	XMLName   xml.Name       `xml:"group" json:"-"`
+	Name      string         `xml:"name,attr" json:"name"`
+	GroupType string         `xml:"type,attr" json:"type"`
+	Status    *statusOUT     `json:"status,omitempty"`
+	Endpoints []*endpointOUT `json:"endpoints,omitempty"`
+}
+
+type endpointOUT struct {
+	XMLName xml.Name     `xml:"endpoint" json:"-"`
+	Name    string       `xml:"name,attr" json:"name"`
+	Status  *statusOUT   `json:"status,omitempty"`
+	Metrics []*metricOUT `json:"metrics,omitempty"`
+}
+
+type metricOUT struct {
+	XMLName xml.Name   `xml:"metric" json:"-"`
+	Name    string     `xml:"name,attr" json:"name"`
+	Status  *statusOUT `json:"status"`
+}
+
+type statusOUT struct {
+	XMLName   xml.Name `xml:"status" json:"-"`
+	Timestamp string   `xml:"timestamp,attr" json:"timestamp"`
+	Value     string   `xml:"value,attr" json:"value"`
+	Since     string   `xml:"since,attr" json:"since"`
+}
+
+// Message struct to hold the json/xml response
+type messageOUT struct {
+	XMLName xml.Name `xml:"root" json:"-"`
+	Message string   `xml:"message" json:"message"`
+}
+
+// latestStatuses walks the states of the entities sorted by entity and by the last time they were seen in
+// each state, and keeps the state every entity was seen in last. Its current run starts with the first
+// status after the entity left another state for the last time. When the entity never left the state, or
+// only entered it after leaving the others, that is the first status in the state. Otherwise Since is left
+// empty and the start of the run has to be looked up
+func latestStatuses(rows []StateOutput) []LatestOutput {
+	latest := []LatestOutput{}
+	for i, row := range rows {
+		if i+1 < len(rows) && rows[i+1].entity() == row.entity() {
+			continue
+		}
+		current := LatestOutput{DataOutput: DataOutput{
+			Timestamp:     row.Last,
+			EndpointGroup: row.EndpointGroup,
+			Service:       row.Service,
+			Hostname:      row.Hostname,
+			Metric:        row.Metric,
+			Status:        row.Status,
+		}}
+		if i > 0 && rows[i-1].entity() == row.entity() {
+			current.left = rows[i-1].Last
+		}
+		if row.First > current.left {
+			current.Since = row.First
+		}
+		latest = append(latest, current)
+	}
+	return latest
+}
+
+func (row StateOutput) entity() string {
+	return row.EndpointGroup + "/" + row.Service + "/" + row.Hostname + "/" + row.Metric
+}
+
+func (row DataOutput) entity() string {
+	return row.EndpointGroup + "/" + row.Service + "/" + row.Hostname + "/" + row.Metric
+}
+
+// field returns the value of the datastore field that identifies the entity at a depth
+func (row DataOutput) field(key string) string {
+	switch key {
+	case "endpoint_group":
+		return row.EndpointGroup
+	case "service":
+		return row.Service
+	case "host":
+		return row.Hostname
+	case "metric":
+		return row.Metric
+	}
+	return ""
+}
@@ -0,0 +1,454 @@
+/*
+ * Copyright (c) 2015 GRNET S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the
+ * License. You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an "AS
+ * IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language
+ * governing permissions and limitations under the License.
+ *
+ * The views and conclusions contained in the software and
+ * documentation are those of the authors and should not be
+ * interpreted as representing official policies, either expressed
+ * or implied, of GRNET S.A.
+ *
+ */
+
+package statusLatest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/github.com/gorilla/mux"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/github.com/stretchr/testify/suite"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/gcfg.v1"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2/bson"
+	"github.com/ARGOeu/argo-web-api/respond"
+	"github.com/ARGOeu/argo-web-api/utils/authentication"
+	"github.com/ARGOeu/argo-web-api/utils/config"
+	"github.com/ARGOeu/argo-web-api/utils/mongo"
+)
+
+// This is a util. suite struct used in tests (see pkg "testify")
+type StatusLatestTestSuite struct {
+	suite.Suite
+	cfg          config.Config
+	router       *mux.Router
+	confHandler  respond.ConfHandler
+	tenantDbConf config.MongoConfig
+}
+
+// Setup the Test Environment
+// This function runs before any test and setups the environment
+// A test configuration object is instantiated using a reference
+// to testdb: argotest_latest. Also the testdb is seeded with a tenant,
+// a report, its operations profile and the status of every level
+func (suite *StatusLatestTestSuite) SetupTest() {
+
+	const testConfig = `
+    [server]
+    bindip = ""
+    port = 8080
+    maxprocs = 4
+    cache = false
+    lrucache = 700000000
+    gzip = true
+    [mongodb]
+    host = "127.0.0.1"
+    port = 27017
+    db = "argotest_latest"
+`
+
+	_ = gcfg.ReadStringInto(&suite.cfg, testConfig)
+
+	// Create router and confhandler for test
+	suite.confHandler = respond.ConfHandler{suite.cfg}
+	suite.router = mux.NewRouter().StrictSlash(true).PathPrefix("/api/v2/status").Subrouter()
+	HandleSubrouter(suite.router, &suite.confHandler)
+
+	// Connect to mongo testdb
+	session, _ := mongo.OpenSession(suite.cfg.MongoDB)
+
+	// Add authentication token to mongo testdb
+	seedAuth := bson.M{"api_key": "S3CR3T"}
+	_ = mongo.Insert(session, suite.cfg.MongoDB.Db, "authentication", seedAuth)
+
+	// seed mongo
+	session, err := mgo.Dial(suite.cfg.MongoDB.Host)
+	if err != nil {
+		panic(err)
+	}
+	defer session.Close()
+
+	// seed a tenant to use
+	c := session.DB(suite.cfg.MongoDB.Db).C("tenants")
+	c.Insert(bson.M{
+		"id": "6ac7d684-1f8e-4a02-a502-720e8f11e50c",
+		"info": bson.M{
+			"name":    "GUARDIANS",
+			"email":   "email@something2",
+			"website": "www.gotg.com",
+			"created": "2015-10-20 02:08:04",
+			"updated": "2015-10-20 02:08:04"},
+		"db_conf": []bson.M{
+			bson.M{
+				"store":    "main",
+				"server":   "localhost",
+				"port":     27017,
+				"database": "argotest_latest_egi",
+				"username": "",
+				"password": ""},
+		},
+		"users": []bson.M{
+			bson.M{
+				"name":    "egi_user",
+				"email":   "egi_user@email.com",
+				"api_key": "KEY1"},
+		}})
+
+	// get dbconfiguration based on the tenant
+	// Prepare the request object
+	request, _ := http.NewRequest("GET", "", strings.NewReader(""))
+	// add the content-type header to application/json
+	request.Header.Set("Content-Type", "application/json")
+	// add the authentication token which is seeded in testdb
+	request.Header.Set("x-api-key", "KEY1")
+	// authenticate user's api key and find corresponding tenant
+	suite.tenantDbConf, err = authentication.AuthenticateTenant(request.Header, suite.cfg)
+
+	// Now seed the report DEFINITIONS
+	c = session.DB(suite.tenantDbConf.Db).C("reports")
+	c.Insert(bson.M{
+		"id": "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"info": bson.M{
+			"name":        "Report_A",
+			"description": "report aaaaa",
+			"created":     "2015-9-10 13:43:00",
+			"updated":     "2015-10-11 13:43:00",
+		},
+		"topology_schema": bson.M{
+			"group": bson.M{
+				"type": "NGI",
+				"group": bson.M{
+					"type": "SITES",
+				},
+			},
+		},
+		"profiles": []bson.M{
+			bson.M{
+				"id":   "6ac7d684-1f8e-4a02-a502-720e8f11e50b",
+				"type": "metric",
+				"name": "profile1"},
+			bson.M{
+				"id":   "6ac7d684-1f8e-4a02-a502-720e8f11e523",
+				"type": "operations",
+				"name": "profile2"},
+			bson.M{
+				"id":   "6ac7d684-1f8e-4a02-a502-720e8f11e50q",
+				"type": "aggregation",
+				"name": "profile3"},
+		},
+		"filter_tags": []bson.M{
+			bson.M{
+				"name":  "name1",
+				"value": "value1"},
+		}})
+
+	// seed the operations profile of the report
+	c = session.DB(suite.tenantDbConf.Db).C("operations_profiles")
+	c.Insert(bson.M{
+		"id":               "6ac7d684-1f8e-4a02-a502-720e8f11e523",
+		"name":             "profile2",
+		"available_states": []string{"OK", "WARNING", "UNKNOWN", "MISSING", "CRITICAL", "DOWNTIME"},
+		"defaults": bson.M{
+			"down":    "DOWNTIME",
+			"missing": "MISSING",
+			"unknown": "UNKNOWN",
+		},
+	})
+
+	// seed the endpoint group statuses
+	c = session.DB(suite.tenantDbConf.Db).C("status_endpoint_groups")
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T00:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"status":         "OK",
+	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T01:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"status":         "CRITICAL",
+	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T02:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"status":         "CRITICAL",
+	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T00:00:00Z",
+		"endpoint_group": "HG-02-IASA",
+		"status":         "OK",
+	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T03:00:00Z",
+		"endpoint_group": "HG-02-IASA",
+		"status":         "OK",
+	})
+	// a status of the day before the requested start time
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150430,
+		"timestamp":      "2015-04-30T23:00:00Z",
+		"endpoint_group": "HG-01-OLD",
+		"status":         "CRITICAL",
+	})
+
+	// seed the service statuses
+	c = session.DB(suite.tenantDbConf.Db).C("status_services")
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T00:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"status":         "OK",
+	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T01:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"status":         "CRITICAL",
+	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T00:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "SRMv2",
+		"status":         "OK",
+	})
+
+	// seed the metric statuses
+	c = session.DB(suite.tenantDbConf.Db).C("status_metrics")
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T00:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"host":           "cream01.afroditi.gr",
+		"metric":         "emi.cream.CREAMCE-JobSubmit",
+		"status":         "OK",
+	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T01:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"host":           "cream01.afroditi.gr",
+		"metric":         "emi.cream.CREAMCE-JobSubmit",
+		"status":         "CRITICAL",
+	})
+	c.Insert(bson.M{
+		"report":         "eba61a9e-22e9-4521-9e47-ecaa4a494364",
+		"date_integer":   20150501,
+		"timestamp":      "2015-05-01T00:00:00Z",
+		"endpoint_group": "HG-03-AUTH",
+		"service":        "CREAM-CE",
+		"host":           "cream01.afroditi.gr",
+		"metric":         "eu.egi.CREAM-IGTF",
+		"status":         "OK",
+	})
+}
+
+func (suite *StatusLatestTestSuite) TestListLatestGroups() {
+
+	respXML := `<root>
+ <group name="HG-02-IASA" type="SITES">
+  <status timestamp="2015-05-01T03:00:00Z" value="OK" since="2015-05-01T00:00:00Z"></status>
+ </group>
+ <group name="HG-03-AUTH" type="SITES">
+  <status timestamp="2015-05-01T02:00:00Z" value="CRITICAL" since="2015-05-01T01:00:00Z"></status>
+ </group>
+</root>`
+
+	respJSON := `{
+ "groups": [
+  {
+   "name": "HG-02-IASA",
+   "type": "SITES",
+   "status": {
+    "timestamp": "2015-05-01T03:00:00Z",
+    "value": "OK",
+    "since": "2015-05-01T00:00:00Z"
+   }
+  },
+  {
+   "name": "HG-03-AUTH",
+   "type": "SITES",
+   "status": {
+    "timestamp": "2015-05-01T02:00:00Z",
+    "value": "CRITICAL",
+    "since": "2015-05-01T01:00:00Z"
+   }
+  }
+ ]
+}`
+
+	respCSV := `group_type,group,service,endpoint,metric,timestamp,status,since
+SITES,HG-02-IASA,,,,2015-05-01T03:00:00Z,OK,2015-05-01T00:00:00Z
+SITES,HG-03-AUTH,,,,2015-05-01T02:00:00Z,CRITICAL,2015-05-01T01:00:00Z
+`
+
+	fullurl := "/api/v2/status/Report_A/latest?start_time=2015-05-01T00:00:00Z"
+
+	for accept, expected := range map[string]string{
+		"application/xml":  respXML,
+		"application/json": respJSON,
+		"text/csv":         respCSV,
+	} {
+		response := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", fullurl, strings.NewReader(""))
+		request.Header.Set("Accept", accept)
+		request.Header.Set("x-api-key", "KEY1")
+		suite.router.ServeHTTP(response, request)
+		// Check that we must have a 200 ok code
+		suite.Equal(200, response.Code, "Incorrect HTTP response code")
+		// Compare the expected and actual response
+		suite.Equal(expected, response.Body.String(), "Response body mismatch")
+	}
+}
+
+// TestListLatestHistory tests that entities are kept however old their latest status is and that
+// the run of a state they returned to starts when they returned to it
+func (suite *StatusLatestTestSuite) TestListLatestHistory() {
+
+	session, _ := mongo.OpenSession(suite.tenantDbConf)
+	defer mongo.CloseSession(session)
+	c := session.DB(suite.tenantDbConf.Db).C("status_endpoint_groups")
+	for _, status := range []bson.M{
+		{"timestamp": "2015-05-01T00:00:00Z", "status": "OK"},
+		{"timestamp": "2015-05-01T01:00:00Z", "status": "CRITICAL"},
+		{"timestamp": "2015-05-01T02:00:00Z", "status": "OK"},
+		{"timestamp": "2015-05-01T03:00:00Z", "status": "OK"},
+	} {
+		status["report"] = "eba61a9e-22e9-4521-9e47-ecaa4a494364"
+		status["date_integer"] = 20150501
+		status["endpoint_group"] = "HG-04-CTI"
+		c.Insert(status)
+	}
+
+	respCSV := `group_type,group,service,endpoint,metric,timestamp,status,since
+SITES,HG-01-OLD,,,,2015-04-30T23:00:00Z,CRITICAL,2015-04-30T23:00:00Z
+SITES,HG-02-IASA,,,,2015-05-01T03:00:00Z,OK,2015-05-01T00:00:00Z
+SITES,HG-03-AUTH,,,,2015-05-01T02:00:00Z,CRITICAL,2015-05-01T01:00:00Z
+SITES,HG-04-CTI,,,,2015-05-01T03:00:00Z,OK,2015-05-01T02:00:00Z
+`
+
+	response := httptest.NewRecorder()
+	request, _ := http.NewRequest("GET", "/api/v2/status/Report_A/latest", strings.NewReader(""))
+	request.Header.Set("Accept", "text/csv")
+	request.Header.Set("x-api-key", "KEY1")
+	suite.router.ServeHTTP(response, request)
+	// Check that we must have a 200 ok code
+	suite.Equal(200, response.Code, "Incorrect HTTP response code")
+	// Compare the expected and actual csv response
+	suite.Equal(respCSV, response.Body.String(), "Response body mismatch")
+}
+
+func (suite *StatusLatestTestSuite) TestListLatestState() {
+
+	respServices := `<root>
+ <group name="HG-03-AUTH" type="SITES">
+  <group name="CREAM-CE" type="service">
+   <status timestamp="2015-05-01T01:00:00Z" value="CRITICAL" since="2015-05-01T01:00:00Z"></status>
+  </group>
+ </group>
+</root>`
+
+	respMetrics := `<root>
+ <group name="HG-03-AUTH" type="SITES">
+  <group name="CREAM-CE" type="service">
+   <endpoint name="cream01.afroditi.gr">
+    <metric name="emi.cream.CREAMCE-JobSubmit">
+     <status timestamp="2015-05-01T01:00:00Z" value="CRITICAL" since="2015-05-01T01:00:00Z"></status>
+    </metric>
+   </endpoint>
+  </group>
+ </group>
+</root>`
+
+	for url, expected := range map[string]string{
+		"/api/v2/status/Report_A/latest?start_time=2015-05-01T00:00:00Z&depth=services&state=CRITICAL": respServices,
+		"/api/v2/status/Report_A/latest?start_time=2015-05-01T00:00:00Z&depth=metrics&state=CRITICAL":  respMetrics,
+	} {
+		response := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", url, strings.NewReader(""))
+		request.Header.Set("Accept", "application/xml")
+		request.Header.Set("x-api-key", "KEY1")
+		suite.router.ServeHTTP(response, request)
+		// Check that we must have a 200 ok code
+		suite.Equal(200, response.Code, "Incorrect HTTP response code")
+		// Compare the expected and actual xml response
+		suite.Equal(expected, response.Body.String(), "Response body mismatch")
+	}
+}
+
+func (suite *StatusLatestTestSuite) TestListLatestErrors() {
+
+	for url, expected := range map[string]string{
+		"/api/v2/status/Report_A/latest?depth=sites":          "Please use one of groups, services, endpoints or metrics as the depth of the snapshot",
+		"/api/v2/status/Report_A/latest?state=DEGRADED":       "The state DEGRADED is not declared in the operations profile of the report Report_A",
+		"/api/v2/status/Report_A/latest?group_type=NGI":       "The report Report_A does not define endpoint group type: NGI",
+		"/api/v2/status/Report_A/latest?start_time=yesterday": "Error parsing date string yesterday please use zulu format like 2006-01-02T15:04:05Z",
+		"/api/v2/status/Report_X/latest":                      "The report with the name Report_X does not exist",
+	} {
+		response := httptest.NewRecorder()
+		request, _ := http.NewRequest("GET", url, strings.NewReader(""))
+		request.Header.Set("Accept", "application/json")
+		request.Header.Set("x-api-key", "KEY1")
+		suite.router.ServeHTTP(response, request)
+		// Check that we must have a 400 bad request code
+		suite.Equal(400, response.Code, "Incorrect HTTP response code")
+		suite.Contains(response.Body.String(), expected, "Response body mismatch")
+	}
+}
+
+// This function is actually called in the end of all tests
+// and clears the test environment.
+// Mainly it's purpose is to drop the testdb
+func (suite *StatusLatestTestSuite) TearDownTest() {
+
+	session, _ := mongo.OpenSession(suite.cfg.MongoDB)
+
+	session.DB("argotest_latest").DropDatabase()
+	session.DB("argotest_latest_egi").DropDatabase()
+}
+
+// This is the first function called when go test is issued
+func TestStatusLatestSuite(t *testing.T) {
+	suite.Run(t, new(StatusLatestTestSuite))
+}
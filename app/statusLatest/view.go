@@ -0,0 +1,130 @@
+/*
+ * Copyright (c) 2015 GRNET S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the
+ * License. You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an "AS
+ * IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language
+ * governing permissions and limitations under the License.
+ *
+ * The views and conclusions contained in the software and
+ * documentation are those of the authors and should not be
+ * interpreted as representing official policies, either expressed
+ * or implied, of GRNET S.A.
+ *
+ */
+
+package statusLatest
+
+import "github.com/ARGOeu/argo-web-api/respond"
+
+func createView(results []LatestOutput, input InputParams) ([]byte, error) {
+
+	docRoot := &rootOUT{}
+
+	var ppEndpointGroup *endpointGroupOUT
+	var ppService *serviceOUT
+	var ppEndpoint *endpointOUT
+
+	for _, row := range results {
+
+		if input.state != "" && row.Status != input.state {
+			continue
+		}
+
+		status := &statusOUT{
+			Timestamp: row.Timestamp,
+			Value:     row.Status,
+			Since:     row.Since,
+		}
+
+		if ppEndpointGroup == nil || ppEndpointGroup.Name != row.EndpointGroup {
+			ppEndpointGroup = &endpointGroupOUT{
+				Name:      row.EndpointGroup,
+				GroupType: input.groupType,
+			}
+			docRoot.EndpointGroups = append(docRoot.EndpointGroups, ppEndpointGroup)
+			ppService = nil
+		}
+		if input.depth == "groups" {
+			ppEndpointGroup.Status = status
+			continue
+		}
+
+		if ppService == nil || ppService.Name != row.Service {
+			ppService = &serviceOUT{
+				Name:      row.Service,
+				GroupType: "service",
+			}
+			ppEndpointGroup.Services = append(ppEndpointGroup.Services, ppService)
+			ppEndpoint = nil
+		}
+		if input.depth == "services" {
+			ppService.Status = status
+			continue
+		}
+
+		if ppEndpoint == nil || ppEndpoint.Name != row.Hostname {
+			ppEndpoint = &endpointOUT{Name: row.Hostname}
+			ppService.Endpoints = append(ppService.Endpoints, ppEndpoint)
+		}
+		if input.depth == "endpoints" {
+			ppEndpoint.Status = status
+			continue
+		}
+
+		ppEndpoint.Metrics = append(ppEndpoint.Metrics, &metricOUT{Name: row.Metric, Status: status})
+	}
+
+	return respond.MarshalContent(docRoot, input.format, "", " ")
+}
+
+func createMessageOUT(message string, format string) ([]byte, error) {
+
+	output := []byte("message placeholder")
+	err := error(nil)
+	docRoot := &messageOUT{}
+
+	docRoot.Message = message
+
+	output, err = respond.MarshalContent(docRoot, format, "", " ")
+	return output, err
+}
+
+// CSVRecords flattens the snapshot into one record per entity, leaving the columns below the depth empty
+func (docRoot *rootOUT) CSVRecords() [][]string {
+	records := [][]string{{"group_type", "group", "service", "endpoint", "metric", "timestamp", "status", "since"}}
+	add := func(group *endpointGroupOUT, service string, endpoint string, metric string, status *statusOUT) {
+		records = append(records, []string{group.GroupType, group.Name, service, endpoint, metric, status.Timestamp, status.Value, status.Since})
+	}
+	for _, group := range docRoot.EndpointGroups {
+		if group.Status != nil {
+			add(group, "", "", "", group.Status)
+		}
+		for _, service := range group.Services {
+			if service.Status != nil {
+				add(group, service.Name, "", "", service.Status)
+			}
+			for _, endpoint := range service.Endpoints {
+				if endpoint.Status != nil {
+					add(group, service.Name, endpoint.Name, "", endpoint.Status)
+				}
+				for _, metric := range endpoint.Metrics {
+					add(group, service.Name, endpoint.Name, metric.Name, metric.Status)
+				}
+			}
+		}
+	}
+	return records
+}
+
+// CSVRecords renders the message as a single csv record
+func (docRoot *messageOUT) CSVRecords() [][]string {
+	return [][]string{{"message"}, {docRoot.Message}}
+}
@@ -22,19 +22,33 @@
 
 package statusMetrics
 
-import "encoding/xml"
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/ARGOeu/argo-web-api/respond"
+)
+
+const zuluForm = "2006-01-02T15:04:05Z"
 
 // InputParams struct holds as input all the url params of the request
 type InputParams struct {
-	startTime int // UTC time in W3C format
-	endTime   int
-	report    string
-	groupType string
-	group     string
-	service   string
-	hostname  string
-	metric    string
-	format    string
+	startTime    int // UTC time in W3C format
+	endTime      int
+	report       string
+	groupType    string
+	group        string
+	service      string
+	hostname     string
+	metric       string
+	format       string
+	intervals    bool
+	end          time.Time
+	states       respond.StateFilter
+	bucket       time.Duration
+	start        time.Time
+	fillGaps     bool
+	gapThreshold time.Duration
 }
 
 // DataOutput struct holds the queried data from datastore
@@ -54,6 +68,9 @@ type DataOutput struct {
 type rootOUT struct {
 	XMLName        xml.Name            `xml:"root" json:"-"`
 	EndpointGroups []*endpointGroupOUT `json:"groups"`
+	intervals      bool
+	buckets        bool
+	summary        bool
 }
 
 type endpointGroupOUT struct {
@@ -77,15 +94,12 @@ type endpointOUT struct {
 }
 
 type metricOUT struct {
-	XMLName  xml.Name     `xml:"metric" json:"-"`
-	Name     string       `xml:"name,attr" json:"name"`
-	Statuses []*statusOUT `json:"statuses"`
-}
-
-type statusOUT struct {
-	XMLName   xml.Name `xml:"status" json:"-"`
-	Timestamp string   `xml:"timestamp,attr" json:"timestamp"`
-	Value     string   `xml:"value,attr" json:"value"`
+	XMLName   xml.Name                    `xml:"metric" json:"-"`
+	Name      string                      `xml:"name,attr" json:"name"`
+	Statuses  []*respond.TimelineStatus   `json:"statuses,omitempty"`
+	Intervals []*respond.TimelineInterval `json:"intervals,omitempty"`
+	Buckets   []*respond.TimelineBucket   `json:"buckets,omitempty"`
+	Summary   *respond.TimelineSummary    `json:"summary,omitempty"`
 }
 
 // Message struct to hold the json/xml response
@@ -93,3 +107,56 @@ type messageOUT struct {
 	XMLName xml.Name `xml:"root" json:"-"`
 	Message string   `xml:"message" json:"message"`
 }
+
+// timelines keys the statuses of the results by metric for the shared timeline helpers
+type timelines []DataOutput
+
+func (t timelines) Len() int { return len(t) }
+func (t timelines) Entity(i int) string {
+	return t[i].EndpointGroup + "/" + t[i].Service + "/" + t[i].Hostname + "/" + t[i].Metric
+}
+func (t timelines) Timestamp(i int) string { return t[i].Timestamp }
+func (t timelines) State(i int) string     { return t[i].Status }
+
+// previousStates keys the previous states of the statuses of the results by metric, since the previous state
+// a metric starts with is part of its timeline as well
+type previousStates []DataOutput
+
+func (t previousStates) Len() int               { return len(t) }
+func (t previousStates) Entity(i int) string    { return timelines(t).Entity(i) }
+func (t previousStates) Timestamp(i int) string { return t[i].PrevTimestamp }
+func (t previousStates) State(i int) string     { return t[i].PrevStatus }
+
+// filterStates keeps the timelines of the metrics that are in one of the requested states at some
+// point, including the previous state they start with, and drops the rest
+func filterStates(results []DataOutput, states respond.StateFilter) []DataOutput {
+	kept := map[int]bool{}
+	for _, i := range append(states.Filter(timelines(results)), states.Filter(previousStates(results))...) {
+		kept[i] = true
+	}
+
+	filtered := []DataOutput{}
+	for i, row := range results {
+		if kept[i] {
+			filtered = append(filtered, row)
+		}
+	}
+	return filtered
+}
+
+// fillMissing adds a status in the missing state to the timelines of the metrics in the results
+// wherever a status is not followed by another one for longer than the threshold
+func fillMissing(results []DataOutput, threshold time.Duration, missing string, end time.Time) []DataOutput {
+	gaps := respond.FindGaps(timelines(results), threshold, missing, end)
+	filled := []DataOutput{}
+	for i, row := range results {
+		filled = append(filled, row)
+		if len(gaps) > 0 && gaps[0].After == i {
+			row.PrevTimestamp, row.PrevStatus = row.Timestamp, row.Status
+			row.Timestamp, row.Status = gaps[0].Timestamp, missing
+			filled = append(filled, row)
+			gaps = gaps[1:]
+		}
+	}
+	return filled
+}
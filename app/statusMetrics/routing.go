@@ -39,21 +39,34 @@ import (
 // HandleSubrouter contains the different paths to follow during subrouting
 func HandleSubrouter(s *mux.Router, confhandler *respond.ConfHandler) {
 
+	// eg. timelines/critical/SITE/mysite/service/apache/endpoints/apache01.host/metrics/memory_used/summary
+	s.Path("/{report_name}/{group_type}/{group_name}/services/{service_name}/endpoints/{endpoint_name}/metrics/{metric_name}/summary").
+		Methods("GET").
+		Name("metric summary").
+		Handler(confhandler.Respond(routeCheckGroup(ListMetricSummary)))
+
 	// eg. timelines/critical/SITE/mysite/service/apache/endpoints/apache01.host/metrics/memory_used
 	s.Path("/{report_name}/{group_type}/{group_name}/services/{service_name}/endpoints/{endpoint_name}/metrics/{metric_name}").
 		Methods("GET").
 		Name("metric name").
-		Handler(confhandler.Respond(routeCheckGroup))
+		Handler(confhandler.Respond(routeCheckGroup(ListMetricTimelines)))
 
 	// eg. timelines/critical/SITE/mysite/service/apache/endpoints/apache01.host/metrics
 	s.Path("/{report_name}/{group_type}/{group_name}/services/{service_name}/endpoints/{endpoint_name}/metrics").
 		Methods("GET").
 		Name("all metrics").
-		Handler(confhandler.Respond(routeCheckGroup))
+		Handler(confhandler.Respond(routeCheckGroup(ListMetricTimelines)))
 
 }
 
-func routeCheckGroup(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
+// routeCheckGroup checks that the report defines the requested endpoint group type before handing the request over
+func routeCheckGroup(handler func(r *http.Request, cfg config.Config) (int, http.Header, []byte, error)) func(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
+	return func(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
+		return checkGroup(r, cfg, handler)
+	}
+}
+
+func checkGroup(r *http.Request, cfg config.Config, handler func(r *http.Request, cfg config.Config) (int, http.Header, []byte, error)) (int, http.Header, []byte, error) {
 
 	//STANDARD DECLARATIONS START
 	code := http.StatusOK
@@ -98,6 +111,6 @@ func routeCheckGroup(r *http.Request, cfg config.Config) (int, http.Header, []by
 		return code, h, output, err
 	}
 
-	return ListMetricTimelines(r, cfg)
+	return handler(r, cfg)
 
 }
@@ -25,27 +25,71 @@ package statusMetrics
 import (
 	"encoding/json"
 	"encoding/xml"
+	"fmt"
 	"strings"
+	"time"
 
+	"github.com/ARGOeu/argo-web-api/app/operationsProfiles"
 	"github.com/ARGOeu/argo-web-api/respond"
 )
 
-func createView(results []DataOutput, input InputParams) ([]byte, error) {
+// createView renders the timelines of the results as statuses, or as intervals or buckets when requested.
+// The states of the buckets are ranked by their severity in the operations profile
+func createView(results []DataOutput, input InputParams, order respond.StateOrder) ([]byte, error) {
 
 	output := []byte("reponse output")
 	err := error(nil)
 
-	docRoot := &rootOUT{}
+	docRoot := &rootOUT{intervals: input.intervals && input.bucket == 0, buckets: input.bucket > 0}
 
 	if len(results) == 0 {
 		if strings.EqualFold(input.format, "application/json") {
 			output, err = json.MarshalIndent(docRoot, " ", "  ")
+		} else if strings.EqualFold(input.format, "text/csv") {
+			output, err = respond.MarshalCSV(docRoot)
 		} else {
 			output, err = xml.MarshalIndent(docRoot, " ", "  ")
 		}
 		return output, err
 	}
 
+	fillTimelines(docRoot, results, input)
+
+	if input.bucket > 0 {
+		for _, timeline := range docRoot.metrics() {
+			timeline.Buckets = respond.CreateBuckets(timeline.Statuses, input.start, input.end, input.bucket, order)
+			timeline.Statuses = nil
+		}
+	} else if input.intervals {
+		for _, timeline := range docRoot.metrics() {
+			timeline.Intervals = respond.CreateIntervals(timeline.Statuses, input.end)
+			timeline.Statuses = nil
+		}
+	}
+
+	output, err = respond.MarshalContent(docRoot, input.format, "", " ")
+	return output, err
+
+}
+
+// createSummaryView renders the time every metric timeline in the results spends in each state of the
+// operations profile between start and the end of the time span
+func createSummaryView(results []DataOutput, input InputParams, start time.Time, profile operationsProfiles.OpsProfile) ([]byte, error) {
+
+	docRoot := &rootOUT{summary: true}
+	fillTimelines(docRoot, results, input)
+
+	for _, timeline := range docRoot.metrics() {
+		timeline.Summary = respond.ComputeSummary(timeline.Statuses, start, input.end, profile.AvailStates, profile.Defaults.Missing)
+		timeline.Statuses = nil
+	}
+
+	return respond.MarshalContent(docRoot, input.format, "", " ")
+}
+
+// fillTimelines adds the statuses of the results to the timelines of the document
+func fillTimelines(docRoot *rootOUT, results []DataOutput, input InputParams) {
+
 	prevHostname := ""
 	prevMetric := ""
 	prevEndpointGroup := ""
@@ -95,23 +139,32 @@ func createView(results []DataOutput, input InputParams) ([]byte, error) {
 			prevMetric = row.Metric
 			ppMetric = metric
 
-			prevStatus := &statusOUT{}
+			prevStatus := &respond.TimelineStatus{}
 			prevStatus.Timestamp = row.PrevTimestamp
 			prevStatus.Value = row.PrevStatus
 			ppMetric.Statuses = append(ppMetric.Statuses, prevStatus)
 
 		}
 
-		status := &statusOUT{}
+		status := &respond.TimelineStatus{}
 		status.Timestamp = row.Timestamp
 		status.Value = row.Status
 		ppMetric.Statuses = append(ppMetric.Statuses, status)
 
 	}
+}
 
-	output, err = respond.MarshalContent(docRoot, input.format, "", " ")
-	return output, err
-
+// metrics collects the metrics of all endpoints of all services of all endpoint groups
+func (docRoot *rootOUT) metrics() []*metricOUT {
+	metrics := []*metricOUT{}
+	for _, endpointGroup := range docRoot.EndpointGroups {
+		for _, service := range endpointGroup.Services {
+			for _, endpoint := range service.Endpoints {
+				metrics = append(metrics, endpoint.Metrics...)
+			}
+		}
+	}
+	return metrics
 }
 
 func createMessageOUT(message string, format string) ([]byte, error) {
@@ -125,3 +178,74 @@ func createMessageOUT(message string, format string) ([]byte, error) {
 	output, err = respond.MarshalContent(docRoot, format, "", " ")
 	return output, err
 }
+
+// CSVRecords flattens the timelines into one record per status, interval, bucket or state summary of every metric
+func (docRoot *rootOUT) CSVRecords() [][]string {
+	if docRoot.summary {
+		records := [][]string{{"group_type", "group", "service", "endpoint", "metric", "total_seconds", "state", "seconds", "percentage"}}
+		for _, group := range docRoot.EndpointGroups {
+			for _, service := range group.Services {
+				for _, endpoint := range service.Endpoints {
+					for _, metric := range endpoint.Metrics {
+						for _, state := range metric.Summary.States {
+							records = append(records, []string{group.GroupType, group.Name, service.Name, endpoint.Name, metric.Name,
+								fmt.Sprint(metric.Summary.TotalSeconds), state.Name, fmt.Sprint(state.Seconds), fmt.Sprint(state.Percentage)})
+						}
+					}
+				}
+			}
+		}
+		return records
+	}
+	if docRoot.buckets {
+		records := [][]string{{"group_type", "group", "service", "endpoint", "metric", "from", "to", "dominant", "worst"}}
+		for _, group := range docRoot.EndpointGroups {
+			for _, service := range group.Services {
+				for _, endpoint := range service.Endpoints {
+					for _, metric := range endpoint.Metrics {
+						for _, bucket := range metric.Buckets {
+							records = append(records, []string{group.GroupType, group.Name, service.Name, endpoint.Name, metric.Name,
+								bucket.From, bucket.To, bucket.Dominant, bucket.Worst})
+						}
+					}
+				}
+			}
+		}
+		return records
+	}
+	if docRoot.intervals {
+		records := [][]string{{"group_type", "group", "service", "endpoint", "metric", "from", "to", "duration_seconds", "status", "previous_status"}}
+		for _, group := range docRoot.EndpointGroups {
+			for _, service := range group.Services {
+				for _, endpoint := range service.Endpoints {
+					for _, metric := range endpoint.Metrics {
+						for _, interval := range metric.Intervals {
+							records = append(records, []string{group.GroupType, group.Name, service.Name, endpoint.Name,
+								metric.Name, interval.From, interval.To, fmt.Sprint(interval.Duration), interval.Value, interval.PreviousValue})
+						}
+					}
+				}
+			}
+		}
+		return records
+	}
+	records := [][]string{{"group_type", "group", "service", "endpoint", "metric", "timestamp", "status"}}
+	for _, group := range docRoot.EndpointGroups {
+		for _, service := range group.Services {
+			for _, endpoint := range service.Endpoints {
+				for _, metric := range endpoint.Metrics {
+					for _, status := range metric.Statuses {
+						records = append(records, []string{group.GroupType, group.Name, service.Name, endpoint.Name,
+							metric.Name, status.Timestamp, status.Value})
+					}
+				}
+			}
+		}
+	}
+	return records
+}
+
+// CSVRecords renders the message as a single csv record
+func (docRoot *messageOUT) CSVRecords() [][]string {
+	return [][]string{{"message"}, {docRoot.Message}}
+}
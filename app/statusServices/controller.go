@@ -25,9 +25,13 @@ package statusServices
 import (
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/github.com/gorilla/mux"
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2"
 	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/gopkg.in/mgo.v2/bson"
+	"github.com/ARGOeu/argo-web-api/app/operationsProfiles"
 	"github.com/ARGOeu/argo-web-api/respond"
 	"github.com/ARGOeu/argo-web-api/utils/authentication"
 	"github.com/ARGOeu/argo-web-api/utils/config"
@@ -48,7 +52,7 @@ func ListServiceTimelines(r *http.Request, cfg config.Config) (int, http.Header,
 
 	//STANDARD DECLARATIONS END
 
-	contentType, err = respond.ParseAcceptHeader(r)
+	contentType, err = respond.ParseAcceptHeader(r, respond.TimelineContentTypes...)
 	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
 
 	if err != nil {
@@ -62,9 +66,18 @@ func ListServiceTimelines(r *http.Request, cfg config.Config) (int, http.Header,
 	vars := mux.Vars(r)
 
 	parsedStart, parsedEnd, errs := respond.ValidateDateRange(urlValues.Get("start_time"), urlValues.Get("end_time"))
+	intervals, end, intervalErrs := respond.ValidateIntervals(urlValues.Get("intervals"), urlValues.Get("end_time"))
+	errs = append(errs, intervalErrs...)
+	bucket, start, bucketErrs := respond.ValidateBucket(urlValues.Get("bucket"), urlValues.Get("start_time"), urlValues.Get("end_time"))
+	errs = append(errs, bucketErrs...)
+	explain, explainErrs := respond.ValidateExplain(urlValues.Get("explain"), urlValues.Get("bucket"), urlValues.Get("end_time"))
+	errs = append(errs, explainErrs...)
+	fillGaps, gapThreshold, gapErrs := respond.ValidateFillGaps(urlValues.Get("fill_gaps"), urlValues.Get("gap_threshold"), urlValues.Get("end_time"))
+	errs = append(errs, gapErrs...)
 	if len(errs) > 0 {
 		code = http.StatusBadRequest
 		output = respond.CreateFailureResponseMessage("Bad Request", "400", errs).MarshalTo(contentType)
+		return code, h, output, nil
 	}
 
 	input := InputParams{
@@ -75,6 +88,14 @@ func ListServiceTimelines(r *http.Request, cfg config.Config) (int, http.Header,
 		vars["group_name"],
 		vars["service_name"],
 		contentType,
+		intervals,
+		end,
+		respond.ParseStateFilter(urlValues.Get("status"), urlValues.Get("exclude_status")),
+		bucket,
+		start,
+		explain,
+		fillGaps,
+		gapThreshold,
 	}
 
 	// Call authenticateTenant to check the api key and retrieve
@@ -104,18 +125,338 @@ func ListServiceTimelines(r *http.Request, cfg config.Config) (int, http.Header,
 		return code, h, output, err
 	}
 
-	err = metricCollection.Find(prepareQuery(input, reportID)).All(&results)
+	errs = operationsProfiles.ValidateStateFilter(session, tenantDbConfig.Db, input.report, input.states)
+
+	// Buckets and explanations order the states by their severity in the operations profile
+	order := respond.StateOrder{}
+	if input.bucket > 0 || input.explain {
+		var orderErrs []respond.ErrorResponse
+		order, orderErrs = operationsProfiles.GetStateOrder(session, tenantDbConfig.Db, input.report)
+		errs = append(errs, orderErrs...)
+	}
+
+	// Gaps are filled with the missing state of the operations profile
+	missing := ""
+	if input.fillGaps {
+		var gapErrs []respond.ErrorResponse
+		missing, input.gapThreshold, gapErrs = operationsProfiles.GetGapFilling(session, tenantDbConfig.Db, input.report, input.gapThreshold)
+		errs = append(errs, gapErrs...)
+	}
+
+	if len(errs) > 0 {
+		code = http.StatusBadRequest
+		output = respond.CreateFailureResponseMessage("Bad Request", "400", errs).MarshalTo(contentType)
+		return code, h, output, nil
+	}
+
+	err = metricCollection.Find(prepareQuery(input, reportID)).Sort("endpoint_group", "service", "timestamp").All(&results)
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	results = fillMissing(results, input.gapThreshold, missing, input.end)
+	results = filterStates(results, input.states)
+
+	contributors := []ContributorData{}
+	if input.explain {
+		contributors, err = queryContributors(session, tenantDbConfig.Db, input, reportID)
+		if err != nil {
+			code = http.StatusInternalServerError
+			return code, h, output, err
+		}
+	}
+
+	output, err = createView(results, contributors, input, order) //Render the results into XML format
+
+	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
+	return code, h, output, err
+}
+
+// ListServiceStats returns the outage statistics of a service over a time span
+func ListServiceStats(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
+
+	//STANDARD DECLARATIONS START
+
+	code := http.StatusOK
+	h := http.Header{}
+	output := []byte("List Service Stats")
+	err := error(nil)
+	contentType := "application/xml"
+	charset := "utf-8"
+
+	//STANDARD DECLARATIONS END
+
+	contentType, err = respond.ParseAcceptHeader(r, respond.TimelineContentTypes...)
+	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
+
+	if err != nil {
+		code = http.StatusNotAcceptable
+		output, _ = respond.MarshalContent(respond.NotAcceptableContentType, contentType, "", " ")
+		return code, h, output, err
+	}
+
+	// Parse the request into the input
+	urlValues := r.URL.Query()
+	vars := mux.Vars(r)
+
+	parsedStart, parsedEnd, errs := respond.ValidateDateRange(urlValues.Get("start_time"), urlValues.Get("end_time"))
+	start, errStart := time.Parse(zuluForm, urlValues.Get("start_time"))
+	end, errEnd := time.Parse(zuluForm, urlValues.Get("end_time"))
+	if len(errs) == 0 && (errStart != nil || errEnd != nil || !end.After(start)) {
+		errs = append(errs, respond.ErrorResponse{
+			Message: "Wrong time span",
+			Code:    "400",
+			Details: "Please use both start_time and end_time url parameters and an end_time after the start_time",
+		})
+	}
+
+	input := InputParams{
+		parsedStart,
+		parsedEnd,
+		vars["report_name"],
+		vars["group_type"],
+		vars["group_name"],
+		vars["service_name"],
+		contentType,
+		false,
+		end,
+		respond.ParseStateFilter(urlValues.Get("status"), urlValues.Get("exclude_status")),
+		0,
+		start,
+		false,
+		false,
+		0,
+	}
+
+	// Call authenticateTenant to check the api key and retrieve
+	// the correct tenant db conf
+	tenantDbConfig, err := authentication.AuthenticateTenant(r.Header, cfg)
+
+	if err != nil {
+		output = []byte(http.StatusText(http.StatusUnauthorized))
+		code = http.StatusUnauthorized //If wrong api key is passed we return UNAUTHORIZED http status
+		return code, h, output, err
+	}
+
+	session, err := mongo.OpenSession(tenantDbConfig)
+	defer mongo.CloseSession(session)
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	// Outages are the periods spent in the requested states of the operations profile
+	profile, errProfile := operationsProfiles.GetReportProfile(session, tenantDbConfig.Db, input.report)
+	if errProfile != nil {
+		errs = append(errs, respond.ErrorResponse{
+			Message: "Operations profile not found",
+			Code:    "400",
+			Details: fmt.Sprintf("The report %s does not define an existing operations profile", input.report),
+		})
+	}
+	if errProfile == nil {
+		errs = append(errs, input.states.Validate(profile.HasState, profile.Name)...)
+	}
+
+	outageStates := map[string]bool{}
+	for _, state := range strings.Split(urlValues.Get("outage_states"), ",") {
+		if state == "" {
+			continue
+		}
+		if errProfile == nil && !profile.HasState(state) {
+			errs = append(errs, respond.ErrorResponse{
+				Message: "Unknown state",
+				Code:    "400",
+				Details: fmt.Sprintf("The state %s is not declared in the operations profile %s", state, profile.Name),
+			})
+		}
+		outageStates[state] = true
+	}
+	if len(outageStates) == 0 && errProfile == nil {
+		outageStates, err = profile.OutageStates()
+		if err != nil {
+			errs = append(errs, respond.ErrorResponse{
+				Message: "Operations profile can not rank its states",
+				Code:    "400",
+				Details: err.Error(),
+			})
+		}
+	}
+
+	if len(errs) > 0 {
+		code = http.StatusBadRequest
+		output = respond.CreateFailureResponseMessage("Bad Request", "400", errs).MarshalTo(contentType)
+		return code, h, output, nil
+	}
+
+	reportID, err := mongo.GetReportID(session, tenantDbConfig.Db, input.report)
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	results := []DataOutput{}
+	err = session.DB(tenantDbConfig.Db).C("status_services").
+		Find(prepareQuery(input, reportID)).Sort("service", "timestamp").All(&results)
 	if err != nil {
 		code = http.StatusInternalServerError
 		return code, h, output, err
 	}
 
-	output, err = createView(results, input) //Render the results into XML format
+	results = filterStates(results, input.states)
+
+	output, err = createStatsView(results, input, start, end, outageStates) //Render the results into JSON/XML format
+
+	return code, h, output, err
+}
+
+// ListServiceSummary returns the time service timelines spend in each state of the operations profile over a time span
+func ListServiceSummary(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
 
+	//STANDARD DECLARATIONS START
+
+	code := http.StatusOK
+	h := http.Header{}
+	output := []byte("List Service Summary")
+	err := error(nil)
+	contentType := "application/xml"
+	charset := "utf-8"
+
+	//STANDARD DECLARATIONS END
+
+	contentType, err = respond.ParseAcceptHeader(r, respond.TimelineContentTypes...)
 	h.Set("Content-Type", fmt.Sprintf("%s; charset=%s", contentType, charset))
+
+	if err != nil {
+		code = http.StatusNotAcceptable
+		output, _ = respond.MarshalContent(respond.NotAcceptableContentType, contentType, "", " ")
+		return code, h, output, err
+	}
+
+	// Parse the request into the input
+	urlValues := r.URL.Query()
+	vars := mux.Vars(r)
+
+	parsedStart, parsedEnd, errs := respond.ValidateDateRange(urlValues.Get("start_time"), urlValues.Get("end_time"))
+	start, errStart := time.Parse(zuluForm, urlValues.Get("start_time"))
+	end, errEnd := time.Parse(zuluForm, urlValues.Get("end_time"))
+	if len(errs) == 0 && (errStart != nil || errEnd != nil || !end.After(start)) {
+		errs = append(errs, respond.ErrorResponse{
+			Message: "Wrong time span",
+			Code:    "400",
+			Details: "Please use both start_time and end_time url parameters and an end_time after the start_time",
+		})
+	}
+	fillGaps, gapThreshold, gapErrs := respond.ValidateFillGaps(urlValues.Get("fill_gaps"), urlValues.Get("gap_threshold"), urlValues.Get("end_time"))
+	errs = append(errs, gapErrs...)
+
+	input := InputParams{
+		parsedStart,
+		parsedEnd,
+		vars["report_name"],
+		vars["group_type"],
+		vars["group_name"],
+		vars["service_name"],
+		contentType,
+		false,
+		end,
+		respond.ParseStateFilter(urlValues.Get("status"), urlValues.Get("exclude_status")),
+		0,
+		start,
+		false,
+		fillGaps,
+		gapThreshold,
+	}
+
+	// Call authenticateTenant to check the api key and retrieve
+	// the correct tenant db conf
+	tenantDbConfig, err := authentication.AuthenticateTenant(r.Header, cfg)
+	if err != nil {
+		if err.Error() == "Unauthorized" {
+			code = http.StatusUnauthorized
+			out := respond.UnauthorizedMessage
+			output = out.MarshalTo(contentType)
+			return code, h, output, err
+		}
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	session, err := mongo.OpenSession(tenantDbConfig)
+	defer mongo.CloseSession(session)
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	profile, errProfile := operationsProfiles.GetReportProfile(session, tenantDbConfig.Db, input.report)
+	if errProfile != nil {
+		errs = append(errs, respond.ErrorResponse{
+			Message: "Operations profile not found",
+			Code:    "400",
+			Details: fmt.Sprintf("The report %s does not define an existing operations profile", input.report),
+		})
+	}
+	if errProfile == nil {
+		errs = append(errs, input.states.Validate(profile.HasState, profile.Name)...)
+	}
+	if errProfile == nil && input.fillGaps {
+		var gapErrs []respond.ErrorResponse
+		_, input.gapThreshold, gapErrs = operationsProfiles.GetGapFilling(session, tenantDbConfig.Db, input.report, input.gapThreshold)
+		errs = append(errs, gapErrs...)
+	}
+
+	if len(errs) > 0 {
+		code = http.StatusBadRequest
+		output = respond.CreateFailureResponseMessage("Bad Request", "400", errs).MarshalTo(contentType)
+		return code, h, output, nil
+	}
+
+	reportID, err := mongo.GetReportID(session, tenantDbConfig.Db, input.report)
+
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	results := []DataOutput{}
+	err = session.DB(tenantDbConfig.Db).C("status_services").
+		Find(prepareQuery(input, reportID)).Sort("endpoint_group", "service", "timestamp").All(&results)
+	if err != nil {
+		code = http.StatusInternalServerError
+		return code, h, output, err
+	}
+
+	results = fillMissing(results, input.gapThreshold, profile.Defaults.Missing, input.end)
+	results = filterStates(results, input.states)
+
+	output, err = createSummaryView(results, input, start, profile) //Render the results into JSON/XML format
+
 	return code, h, output, err
 }
 
+// queryContributors reads the statuses of the endpoints and metrics of the services of the request over
+// its time span, sorted by type, name and timestamp
+func queryContributors(session *mgo.Session, db string, input InputParams, reportID string) ([]ContributorData, error) {
+	contributors := []ContributorData{}
+	for _, source := range contributorSources {
+		statuses := []ContributorData{}
+		err := session.DB(db).C(source.collection).Find(prepareQuery(input, reportID)).Sort("service", "host", "metric", "timestamp").All(&statuses)
+		if err != nil {
+			return nil, err
+		}
+		for i := range statuses {
+			statuses[i].Type = source.kind
+		}
+		contributors = append(contributors, statuses...)
+	}
+	return contributors, nil
+}
+
 func prepareQuery(input InputParams, reportID string) bson.M {
 
 	// prepare the match filter
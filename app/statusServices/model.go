@@ -22,17 +22,32 @@
 
 package statusServices
 
-import "encoding/xml"
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/ARGOeu/argo-web-api/respond"
+)
+
+const zuluForm = "2006-01-02T15:04:05Z"
 
 // InputParams struct holds as input all the url params of the request
 type InputParams struct {
-	startTime int // UTC time in W3C format
-	endTime   int
-	report    string
-	groupType string
-	group     string
-	service   string
-	format    string
+	startTime    int // UTC time in W3C format
+	endTime      int
+	report       string
+	groupType    string
+	group        string
+	service      string
+	format       string
+	intervals    bool
+	end          time.Time
+	states       respond.StateFilter
+	bucket       time.Duration
+	start        time.Time
+	explain      bool
+	fillGaps     bool
+	gapThreshold time.Duration
 }
 
 // DataOutput struct holds the queried data from datastore
@@ -45,11 +60,35 @@ type DataOutput struct {
 	DateInteger   string `bson:"date_integer"`
 }
 
+// ContributorData struct holds a status of an endpoint or metric of a service from datastore
+type ContributorData struct {
+	Type          string `bson:"-"`
+	Timestamp     string `bson:"timestamp"`
+	EndpointGroup string `bson:"endpoint_group"`
+	Service       string `bson:"service"`
+	Hostname      string `bson:"host"`
+	Metric        string `bson:"metric"`
+	Status        string `bson:"status"`
+}
+
+// contributorSources lists the collections below the services that explain their statuses
+var contributorSources = []struct {
+	kind       string
+	collection string
+}{
+	{"endpoint", "status_endpoints"},
+	{"metric", "status_metrics"},
+}
+
 // xml/json response related structs
 
 type rootOUT struct {
 	XMLName        xml.Name            `xml:"root" json:"-"`
 	EndpointGroups []*endpointGroupOUT `json:"groups"`
+	intervals      bool
+	buckets        bool
+	summary        bool
+	explain        bool
 }
 
 type endpointGroupOUT struct {
@@ -60,16 +99,13 @@ type endpointGroupOUT struct {
 }
 
 type serviceOUT struct {
-	XMLName   xml.Name     `xml:"group" json:"-"`
-	Name      string       `xml:"name,attr" json:"name"`
-	GroupType string       `xml:"type,attr" json:"type"`
-	Statuses  []*statusOUT `json:"statuses"`
-}
-
-type statusOUT struct {
-	XMLName   xml.Name `xml:"status" json:"-"`
-	Timestamp string   `xml:"timestamp,attr" json:"timestamp"`
-	Value     string   `xml:"value,attr" json:"value"`
+	XMLName   xml.Name                    `xml:"group" json:"-"`
+	Name      string                      `xml:"name,attr" json:"name"`
+	GroupType string                      `xml:"type,attr" json:"type"`
+	Statuses  []*respond.TimelineStatus   `json:"statuses,omitempty"`
+	Intervals []*respond.TimelineInterval `json:"intervals,omitempty"`
+	Buckets   []*respond.TimelineBucket   `json:"buckets,omitempty"`
+	Summary   *respond.TimelineSummary    `json:"summary,omitempty"`
 }
 
 // Message struct to hold the xml/json response
@@ -77,3 +113,76 @@ type messageOUT struct {
 	XMLName xml.Name `xml:"root" json:"-"`
 	Message string   `xml:"message" json:"message"`
 }
+
+// statsRootOUT holds the outage statistics of the services
+type statsRootOUT struct {
+	XMLName        xml.Name                 `xml:"root" json:"-"`
+	EndpointGroups []*endpointGroupStatsOUT `json:"groups"`
+}
+
+type endpointGroupStatsOUT struct {
+	XMLName   xml.Name           `xml:"group" json:"-"`
+	Name      string             `xml:"name,attr" json:"name"`
+	GroupType string             `xml:"type,attr" json:"type"`
+	Services  []*serviceStatsOUT `json:"services"`
+}
+
+type serviceStatsOUT struct {
+	XMLName   xml.Name             `xml:"group" json:"-"`
+	Name      string               `xml:"name,attr" json:"name"`
+	GroupType string               `xml:"type,attr" json:"type"`
+	Stats     *respond.OutageStats `json:"stats"`
+}
+
+// timelines keys the statuses of the results by service for the shared timeline helpers
+type timelines []DataOutput
+
+func (t timelines) Len() int               { return len(t) }
+func (t timelines) Entity(i int) string    { return t[i].EndpointGroup + "/" + t[i].Service }
+func (t timelines) Timestamp(i int) string { return t[i].Timestamp }
+func (t timelines) State(i int) string     { return t[i].Status }
+
+// contributorTimelines keys the statuses of the endpoints and metrics of a service by contributor
+// for the shared timeline helpers
+type contributorTimelines []ContributorData
+
+func (t contributorTimelines) Len() int { return len(t) }
+func (t contributorTimelines) Entity(i int) string {
+	return t[i].Type + "/" + t[i].Hostname + "/" + t[i].Metric
+}
+func (t contributorTimelines) Timestamp(i int) string { return t[i].Timestamp }
+func (t contributorTimelines) State(i int) string     { return t[i].Status }
+
+// contributor describes the endpoint or metric whose timeline starts at a position of the statuses
+func (t contributorTimelines) contributor(i int) *respond.Contributor {
+	contributor := &respond.Contributor{Type: t[i].Type, Service: t[i].Service, Endpoint: t[i].Hostname}
+	if t[i].Type == "metric" {
+		contributor.Metric = t[i].Metric
+	}
+	return contributor
+}
+
+// filterStates keeps the timelines of the services that enter one of the requested states and drops the rest
+func filterStates(results []DataOutput, states respond.StateFilter) []DataOutput {
+	filtered := []DataOutput{}
+	for _, i := range states.Filter(timelines(results)) {
+		filtered = append(filtered, results[i])
+	}
+	return filtered
+}
+
+// fillMissing adds a status in the missing state to the timelines of the services in the results
+// wherever a status is not followed by another one for longer than the threshold
+func fillMissing(results []DataOutput, threshold time.Duration, missing string, end time.Time) []DataOutput {
+	gaps := respond.FindGaps(timelines(results), threshold, missing, end)
+	filled := []DataOutput{}
+	for i, row := range results {
+		filled = append(filled, row)
+		if len(gaps) > 0 && gaps[0].After == i {
+			row.Timestamp, row.Status = gaps[0].Timestamp, missing
+			filled = append(filled, row)
+			gaps = gaps[1:]
+		}
+	}
+	return filled
+}
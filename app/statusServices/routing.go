@@ -39,21 +39,40 @@ import (
 // HandleSubrouter contains the different paths to follow during subrouting
 func HandleSubrouter(s *mux.Router, confhandler *respond.ConfHandler) {
 
+	// eg. timelines/critical/SITE/mysite/services/apache/stats
+	s.Path("/{report_name}/{group_type}/{group_name}/services/{service_name}/stats").
+		Methods("GET").
+		Name("service stats").
+		Handler(confhandler.Respond(routeCheckGroup(ListServiceStats)))
+
+	// eg. timelines/critical/SITE/mysite/services/apache/summary
+	s.Path("/{report_name}/{group_type}/{group_name}/services/{service_name}/summary").
+		Methods("GET").
+		Name("service summary").
+		Handler(confhandler.Respond(routeCheckGroup(ListServiceSummary)))
+
 	// eg. timelines/critical/SITE/mysite/services/apache
 	s.Path("/{report_name}/{group_type}/{group_name}/services/{service_name}").
 		Methods("GET").
 		Name("service name").
-		Handler(confhandler.Respond(routeCheckGroup))
+		Handler(confhandler.Respond(routeCheckGroup(ListServiceTimelines)))
 
 	// eg. timelines/critical/SITE/mysite/services
 	s.Path("/{report_name}/{group_type}/{group_name}/services").
 		Methods("GET").
 		Name("all services").
-		Handler(confhandler.Respond(routeCheckGroup))
+		Handler(confhandler.Respond(routeCheckGroup(ListServiceTimelines)))
 
 }
 
-func routeCheckGroup(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
+// routeCheckGroup checks that the report defines the requested endpoint group type before handing the request over
+func routeCheckGroup(handler func(r *http.Request, cfg config.Config) (int, http.Header, []byte, error)) func(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
+	return func(r *http.Request, cfg config.Config) (int, http.Header, []byte, error) {
+		return checkGroup(r, cfg, handler)
+	}
+}
+
+func checkGroup(r *http.Request, cfg config.Config, handler func(r *http.Request, cfg config.Config) (int, http.Header, []byte, error)) (int, http.Header, []byte, error) {
 
 	//STANDARD DECLARATIONS START
 	code := http.StatusOK
@@ -98,6 +117,6 @@ func routeCheckGroup(r *http.Request, cfg config.Config) (int, http.Header, []by
 		return code, h, output, err
 	}
 
-	return ListServiceTimelines(r, cfg)
+	return handler(r, cfg)
 
 }
@@ -25,27 +25,86 @@ package statusServices
 import (
 	"encoding/json"
 	"encoding/xml"
+	"fmt"
 	"strings"
+	"time"
 
+	"github.com/ARGOeu/argo-web-api/app/operationsProfiles"
 	"github.com/ARGOeu/argo-web-api/respond"
 )
 
-func createView(results []DataOutput, input InputParams) ([]byte, error) {
+// createView renders the timelines of the results as statuses, or as intervals or buckets when requested.
+// Explained intervals carry the contributors found in the statuses of the endpoints and metrics.
+// The states of the buckets and the contributors are ranked by their severity in the operations profile
+func createView(results []DataOutput, contributors []ContributorData, input InputParams, order respond.StateOrder) ([]byte, error) {
 
 	output := []byte("reponse output")
 	err := error(nil)
 
-	docRoot := &rootOUT{}
+	docRoot := &rootOUT{
+		intervals: (input.intervals || input.explain) && input.bucket == 0,
+		buckets:   input.bucket > 0,
+		explain:   input.explain,
+	}
 
 	if len(results) == 0 {
 		if strings.EqualFold(input.format, "application/json") {
 			output, err = json.MarshalIndent(docRoot, " ", "  ")
+		} else if strings.EqualFold(input.format, "text/csv") {
+			output, err = respond.MarshalCSV(docRoot)
 		} else {
 			output, err = xml.MarshalIndent(docRoot, " ", "  ")
 		}
 		return output, err
 	}
 
+	fillTimelines(docRoot, results, input)
+
+	if input.bucket > 0 {
+		for _, timeline := range docRoot.services() {
+			timeline.Buckets = respond.CreateBuckets(timeline.Statuses, input.start, input.end, input.bucket, order)
+			timeline.Statuses = nil
+		}
+	} else if docRoot.intervals {
+		statuses := map[string][]ContributorData{}
+		for _, row := range contributors {
+			statuses[row.EndpointGroup+"/"+row.Service] = append(statuses[row.EndpointGroup+"/"+row.Service], row)
+		}
+		for _, group := range docRoot.EndpointGroups {
+			for _, timeline := range group.Services {
+				timeline.Intervals = respond.CreateIntervals(timeline.Statuses, input.end)
+				if input.explain {
+					lower := contributorTimelines(statuses[group.Name+"/"+timeline.Name])
+					respond.ExplainIntervals(timeline.Intervals, lower, lower.contributor, input.end, order)
+				}
+				timeline.Statuses = nil
+			}
+		}
+	}
+
+	output, err = respond.MarshalContent(docRoot, input.format, "", " ")
+	return output, err
+
+}
+
+// createSummaryView renders the time every service timeline in the results spends in each state of the
+// operations profile between start and the end of the time span
+func createSummaryView(results []DataOutput, input InputParams, start time.Time, profile operationsProfiles.OpsProfile) ([]byte, error) {
+
+	docRoot := &rootOUT{summary: true}
+	fillTimelines(docRoot, results, input)
+
+	for _, timeline := range docRoot.services() {
+		timeline.Summary = respond.ComputeSummary(timeline.Statuses, start, input.end, profile.AvailStates, profile.Defaults.Missing)
+		timeline.Statuses = nil
+	}
+
+	return respond.MarshalContent(docRoot, input.format, "", " ")
+}
+
+// fillTimelines adds the statuses of the results to the timelines of the document
+func fillTimelines(docRoot *rootOUT, results []DataOutput, input InputParams) {
+
 	prevEndpointGroup := ""
 	prevService := ""
 
@@ -73,16 +132,47 @@ func createView(results []DataOutput, input InputParams) ([]byte, error) {
 			ppService = service
 		}
 
-		status := &statusOUT{}
+		status := &respond.TimelineStatus{}
 		status.Timestamp = row.Timestamp
 		status.Value = row.Status
 		ppService.Statuses = append(ppService.Statuses, status)
 
 	}
+}
 
-	output, err = respond.MarshalContent(docRoot, input.format, "", " ")
-	return output, err
+// services collects the services of all endpoint groups
+func (docRoot *rootOUT) services() []*serviceOUT {
+	services := []*serviceOUT{}
+	for _, endpointGroup := range docRoot.EndpointGroups {
+		services = append(services, endpointGroup.Services...)
+	}
+	return services
+}
 
+// createStatsView renders the outage statistics of every service in the results
+func createStatsView(results []DataOutput, input InputParams, start time.Time, end time.Time, outageStates map[string]bool) ([]byte, error) {
+
+	docRoot := &statsRootOUT{}
+	var ppEndpointGroup *endpointGroupStatsOUT
+
+	for i := 0; i < len(results); {
+		statuses, j := respond.NextTimeline(timelines(results), i)
+		if ppEndpointGroup == nil || ppEndpointGroup.Name != results[i].EndpointGroup {
+			ppEndpointGroup = &endpointGroupStatsOUT{
+				Name:      results[i].EndpointGroup,
+				GroupType: input.groupType,
+			}
+			docRoot.EndpointGroups = append(docRoot.EndpointGroups, ppEndpointGroup)
+		}
+		ppEndpointGroup.Services = append(ppEndpointGroup.Services, &serviceStatsOUT{
+			Name:      results[i].Service,
+			GroupType: "service",
+			Stats:     respond.ComputeStats(statuses, start, end, outageStates),
+		})
+		i = j
+	}
+
+	return respond.MarshalContent(docRoot, input.format, "", " ")
 }
 
 func createMessageOUT(message string, format string) ([]byte, error) {
@@ -96,3 +186,83 @@ func createMessageOUT(message string, format string) ([]byte, error) {
 	output, err = respond.MarshalContent(docRoot, format, "", " ")
 	return output, err
 }
+
+// CSVRecords flattens the timelines into one record per status, interval, contributor of an interval, bucket or state summary of every service
+func (docRoot *rootOUT) CSVRecords() [][]string {
+	if docRoot.summary {
+		records := [][]string{{"group_type", "group", "service", "total_seconds", "state", "seconds", "percentage"}}
+		for _, group := range docRoot.EndpointGroups {
+			for _, service := range group.Services {
+				for _, state := range service.Summary.States {
+					records = append(records, []string{group.GroupType, group.Name, service.Name, fmt.Sprint(service.Summary.TotalSeconds),
+						state.Name, fmt.Sprint(state.Seconds), fmt.Sprint(state.Percentage)})
+				}
+			}
+		}
+		return records
+	}
+	if docRoot.buckets {
+		records := [][]string{{"group_type", "group", "service", "from", "to", "dominant", "worst"}}
+		for _, group := range docRoot.EndpointGroups {
+			for _, service := range group.Services {
+				for _, bucket := range service.Buckets {
+					records = append(records, []string{group.GroupType, group.Name, service.Name,
+						bucket.From, bucket.To, bucket.Dominant, bucket.Worst})
+				}
+			}
+		}
+		return records
+	}
+	if docRoot.explain {
+		records := [][]string{{"group_type", "group", "service", "from", "to", "duration_seconds", "status", "previous_status",
+			"contributor_type", "contributor_endpoint", "contributor_metric", "contributor_status"}}
+		for _, group := range docRoot.EndpointGroups {
+			for _, service := range group.Services {
+				for _, interval := range service.Intervals {
+					record := []string{group.GroupType, group.Name, service.Name, interval.From, interval.To,
+						fmt.Sprint(interval.Duration), interval.Value, interval.PreviousValue}
+					records = append(records, respond.ExplainRecords(record, interval)...)
+				}
+			}
+		}
+		return records
+	}
+	if docRoot.intervals {
+		records := [][]string{{"group_type", "group", "service", "from", "to", "duration_seconds", "status", "previous_status"}}
+		for _, group := range docRoot.EndpointGroups {
+			for _, service := range group.Services {
+				for _, interval := range service.Intervals {
+					records = append(records, []string{group.GroupType, group.Name, service.Name, interval.From, interval.To,
+						fmt.Sprint(interval.Duration), interval.Value, interval.PreviousValue})
+				}
+			}
+		}
+		return records
+	}
+	records := [][]string{{"group_type", "group", "service", "timestamp", "status"}}
+	for _, group := range docRoot.EndpointGroups {
+		for _, service := range group.Services {
+			for _, status := range service.Statuses {
+				records = append(records, []string{group.GroupType, group.Name, service.Name, status.Timestamp, status.Value})
+			}
+		}
+	}
+	return records
+}
+
+// CSVRecords renders the message as a single csv record
+func (docRoot *messageOUT) CSVRecords() [][]string {
+	return [][]string{{"message"}, {docRoot.Message}}
+}
+
+// CSVRecords flattens the statistics into one record per service
+func (docRoot *statsRootOUT) CSVRecords() [][]string {
+	records := [][]string{{"group_type", "group", "service", "outages", "mttr", "mtbf", "longest_outage"}}
+	for _, group := range docRoot.EndpointGroups {
+		for _, service := range group.Services {
+			records = append(records, []string{group.GroupType, group.Name, service.Name, fmt.Sprint(service.Stats.Outages),
+				fmt.Sprint(service.Stats.MTTR), fmt.Sprint(service.Stats.MTBF), fmt.Sprint(service.Stats.LongestOutage)})
+		}
+	}
+	return records
+}
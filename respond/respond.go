@@ -27,12 +27,15 @@
 package respond
 
 import (
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"reflect"
 	"strings"
 
 	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/github.com/gorilla/mux"
@@ -104,29 +107,47 @@ func (confhandler *ConfHandler) Respond(fn func(r *http.Request, cfg config.Conf
 
 }
 
+// acceptedContentTypes are the content types every request can be answered with, in order of preference
 var acceptedContentTypes = []string{
+	"application/json",
 	"application/xml",
+}
+
+// TimelineContentTypes are the content types of results and status timelines which, apart from
+// json and xml, can also be rendered as csv
+var TimelineContentTypes = []string{
 	"application/json",
+	"application/xml",
+	"text/csv",
 }
 
 var defaultContentType = "application/json"
 
-// ParseAcceptHeader parses the accept header to determine the content type
-func ParseAcceptHeader(r *http.Request) (string, error) {
+// ParseAcceptHeader parses the accept header to determine the content type. The content types
+// the request can be answered with are given in order of preference and default to json and xml
+func ParseAcceptHeader(r *http.Request, accepted ...string) (string, error) {
 	contentType := r.Header.Get("Accept")
 	if r.Header.Get("Accept") == "" {
 		return defaultContentType, nil
 	}
+	if len(accepted) == 0 {
+		accepted = acceptedContentTypes
+	}
 	// contentType := httputil.NegotiateContentType(r, acceptedContentTypes, "notvalid")
-	if strings.Contains(contentType, "application/json") {
-		return "application/json", nil
-	} else if strings.Contains(contentType, "application/xml") {
-		return "application/xml", nil
-	} else if strings.Contains(contentType, "*/*") {
-		return "application/json", nil
-	} else {
-		return defaultContentType, errors.New("Not Acceptable ContentType")
+	for _, acceptedType := range accepted {
+		if strings.Contains(contentType, acceptedType) {
+			return acceptedType, nil
+		}
+	}
+	if strings.Contains(contentType, "*/*") {
+		return defaultContentType, nil
 	}
+	return defaultContentType, errors.New("Not Acceptable ContentType")
+}
+
+// CSVMarshaler is implemented by documents that can be flattened into csv records
+type CSVMarshaler interface {
+	CSVRecords() [][]string
 }
 
 // MarshalContent marshals content using the marshaler that corresponds to the contentType parameter
@@ -136,6 +157,8 @@ func MarshalContent(doc interface{}, contentType string, prefix string, indent s
 
 	if contentType == "application/xml" {
 		output, err = xml.MarshalIndent(doc, prefix, indent)
+	} else if contentType == "text/csv" {
+		output, err = MarshalCSV(doc)
 	} else {
 		output, err = json.MarshalIndent(doc, prefix, indent)
 	}
@@ -143,6 +166,22 @@ func MarshalContent(doc interface{}, contentType string, prefix string, indent s
 	return output, err
 }
 
+// ErrNotCSVMarshaler is returned when a document that can not be flattened is rendered as csv
+var ErrNotCSVMarshaler = errors.New("Document can not be rendered as csv")
+
+// MarshalCSV renders the records of a document as csv. Documents that do not implement
+// CSVMarshaler are not rendered at all, so that they never reach a text/csv response
+func MarshalCSV(doc interface{}) ([]byte, error) {
+	marshaler, ok := doc.(CSVMarshaler)
+	if !ok {
+		return nil, ErrNotCSVMarshaler
+	}
+
+	buf := &bytes.Buffer{}
+	err := csv.NewWriter(buf).WriteAll(marshaler.CSVRecords())
+	return buf.Bytes(), err
+}
+
 func (confhandler *ConfHandler) walker(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
 	// route.Handler(route.GetHandler())
 	return nil
@@ -207,6 +246,29 @@ func (resp ResponseMessage) MarshalTo(contentType string) []byte {
 	return output
 }
 
+// CSVRecords flattens the response message into a row for its status and a row for each of its errors
+func (resp ResponseMessage) CSVRecords() [][]string {
+	records := [][]string{
+		{"message", "code", "details"},
+		{resp.Status.Message, resp.Status.Code, resp.Status.Details},
+	}
+
+	// Packages keep their own ErrorResponse types, so convert each error to the one of respond
+	errs := reflect.ValueOf(resp.Errors)
+	errType := reflect.TypeOf(ErrorResponse{})
+	if errs.Kind() == reflect.Slice {
+		for i := 0; i < errs.Len(); i++ {
+			item := errs.Index(i)
+			if item.Type().ConvertibleTo(errType) {
+				e := item.Convert(errType).Interface().(ErrorResponse)
+				records = append(records, []string{e.Message, e.Code, e.Details})
+			}
+		}
+	}
+
+	return records
+}
+
 // BadRequest is used to inform the user about malformed json body
 var BadRequestSimple = ResponseMessage{
 	Status: StatusResponse{
@@ -0,0 +1,394 @@
+/*
+ * Copyright (c) 2015 GRNET S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the
+ * License. You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an "AS
+ * IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language
+ * governing permissions and limitations under the License.
+ *
+ * The views and conclusions contained in the software and
+ * documentation are those of the authors and should not be
+ * interpreted as representing official policies, either expressed
+ * or implied, of either GRNET S.A., SRCE or IN2P3 CNRS Computing
+ * Centre
+ *
+ * The work represented by this source file is partially funded by
+ * the EGI-InSPIRE project through the European Commission's 7th
+ * Framework Programme (contract # INFSO-RI-261323)
+ */
+
+package respond
+
+import (
+	"encoding/xml"
+	"sort"
+	"time"
+)
+
+// TimelineStatus holds a state a timeline entered at a timestamp
+type TimelineStatus struct {
+	XMLName   xml.Name `xml:"status" json:"-"`
+	Timestamp string   `xml:"timestamp,attr" json:"timestamp"`
+	Value     string   `xml:"value,attr" json:"value"`
+}
+
+// TimelineInterval holds a status along with the time it lasted and the status it followed
+type TimelineInterval struct {
+	XMLName       xml.Name       `xml:"interval" json:"-"`
+	From          string         `xml:"from,attr" json:"from"`
+	To            string         `xml:"to,attr" json:"to"`
+	Duration      int64          `xml:"duration_seconds,attr" json:"duration_seconds"`
+	Value         string         `xml:"value,attr" json:"value"`
+	PreviousValue string         `xml:"previous_value,attr" json:"previous_value"`
+	Contributors  []*Contributor `json:"contributors,omitempty"`
+}
+
+// Contributor holds a service, endpoint or metric that was not OK during an interval along with its worst state
+type Contributor struct {
+	XMLName  xml.Name `xml:"contributor" json:"-"`
+	Type     string   `xml:"type,attr" json:"type"`
+	Service  string   `xml:"service,attr" json:"service"`
+	Endpoint string   `xml:"endpoint,attr,omitempty" json:"endpoint,omitempty"`
+	Metric   string   `xml:"metric,attr,omitempty" json:"metric,omitempty"`
+	Status   string   `xml:"status,attr" json:"status"`
+	severity int
+}
+
+// TimelineBucket holds the state a timeline spent most of a bucket in and the worst state it entered
+type TimelineBucket struct {
+	XMLName  xml.Name `xml:"bucket" json:"-"`
+	From     string   `xml:"from,attr" json:"from"`
+	To       string   `xml:"to,attr" json:"to"`
+	Dominant string   `xml:"dominant,attr" json:"dominant"`
+	Worst    string   `xml:"worst,attr" json:"worst"`
+}
+
+// TimelineSummary holds the time a timeline spent in each state over a time span
+type TimelineSummary struct {
+	XMLName      xml.Name        `xml:"summary" json:"-"`
+	TotalSeconds int64           `xml:"total_seconds,attr" json:"total_seconds"`
+	States       []*StateSummary `json:"states"`
+}
+
+// StateSummary holds the time a timeline spent in a state and its share of the time span
+type StateSummary struct {
+	XMLName    xml.Name `xml:"state" json:"-"`
+	Name       string   `xml:"name,attr" json:"name"`
+	Seconds    int64    `xml:"seconds,attr" json:"seconds"`
+	Percentage float64  `xml:"percentage,attr" json:"percentage"`
+}
+
+// OutageStats holds the number of outages, the mean time to recovery, the mean time between
+// failures and the longest outage of a timeline. All durations are in seconds
+type OutageStats struct {
+	XMLName       xml.Name `xml:"stats" json:"-"`
+	Outages       int64    `xml:"outages,attr" json:"outages"`
+	MTTR          float64  `xml:"mttr,attr" json:"mttr"`
+	MTBF          float64  `xml:"mtbf,attr" json:"mtbf"`
+	LongestOutage int64    `xml:"longest_outage,attr" json:"longest_outage"`
+}
+
+// StateOrder holds the states of an operations profile in the order they are declared, its missing
+// state and the severity of every state, where more severe states have higher severities
+type StateOrder struct {
+	States   []string
+	Missing  string
+	Severity map[string]int
+}
+
+// OK returns the least severe state
+func (order StateOrder) OK() string {
+	ok := ""
+	for _, state := range order.States {
+		if ok == "" || order.Severity[state] < order.Severity[ok] {
+			ok = state
+		}
+	}
+	return ok
+}
+
+// Timelines is implemented by the statuses of the timelines of several entities, sorted by entity and then
+// by timestamp. Entity returns the key of the entity a status belongs to, which tells the timelines apart
+type Timelines interface {
+	Len() int
+	Entity(i int) string
+	Timestamp(i int) string
+	State(i int) string
+}
+
+// NextTimeline returns the statuses of the timeline that starts at position i along with the position
+// the next timeline starts at
+func NextTimeline(timelines Timelines, i int) ([]*TimelineStatus, int) {
+	statuses := []*TimelineStatus{}
+	j := i
+	for ; j < timelines.Len() && timelines.Entity(j) == timelines.Entity(i); j++ {
+		statuses = append(statuses, &TimelineStatus{Timestamp: timelines.Timestamp(j), Value: timelines.State(j)})
+	}
+	return statuses, j
+}
+
+// Filter returns the positions of the statuses of the entities whose timelines enter one of the requested
+// states. The timelines of the rest are dropped, all of them are kept when the filter is not set. Statuses
+// without a state never match
+func (filter StateFilter) Filter(timelines Timelines) []int {
+	matching := map[string]bool{}
+	for i := 0; i < timelines.Len(); i++ {
+		if state := timelines.State(i); !filter.IsSet() || (state != "" && filter.Matches(state)) {
+			matching[timelines.Entity(i)] = true
+		}
+	}
+
+	kept := []int{}
+	for i := 0; i < timelines.Len(); i++ {
+		if matching[timelines.Entity(i)] {
+			kept = append(kept, i)
+		}
+	}
+	return kept
+}
+
+// Gap holds the position of a status that is not followed by another one for longer than the gap threshold
+// and the timestamp the missing state starts at
+type Gap struct {
+	After     int
+	Timestamp string
+}
+
+// FindGaps looks for the statuses of the timelines that are not followed by another status for longer than the
+// threshold. The missing state starts once the threshold has passed and lasts until the next status or the end
+// of the time span. Statuses already in the missing state are not followed by gaps
+func FindGaps(timelines Timelines, threshold time.Duration, missing string, end time.Time) []Gap {
+	gaps := []Gap{}
+	if threshold <= 0 || missing == "" {
+		return gaps
+	}
+
+	for i := 0; i < timelines.Len(); i++ {
+		from, err := time.Parse(zuluForm, timelines.Timestamp(i))
+		if err != nil || timelines.State(i) == missing {
+			continue
+		}
+		next := end
+		if i+1 < timelines.Len() && timelines.Entity(i+1) == timelines.Entity(i) {
+			next, _ = time.Parse(zuluForm, timelines.Timestamp(i+1))
+		}
+		if next.Sub(from) > threshold {
+			gaps = append(gaps, Gap{After: i, Timestamp: from.Add(threshold).Format(zuluForm)})
+		}
+	}
+	return gaps
+}
+
+// CreateIntervals turns the statuses of a timeline sorted by timestamp into the intervals between
+// them. Each interval lasts until the next status and the last one is clipped at the end of the time span
+func CreateIntervals(statuses []*TimelineStatus, end time.Time) []*TimelineInterval {
+	intervals := []*TimelineInterval{}
+	previous := ""
+	for i, status := range statuses {
+		from, err := time.Parse(zuluForm, status.Timestamp)
+		if err != nil || !from.Before(end) {
+			continue
+		}
+		to := end
+		if i+1 < len(statuses) {
+			next, err := time.Parse(zuluForm, statuses[i+1].Timestamp)
+			if err == nil && next.Before(end) {
+				to = next
+			}
+		}
+		intervals = append(intervals, &TimelineInterval{
+			From:          status.Timestamp,
+			To:            to.Format(zuluForm),
+			Duration:      int64(to.Sub(from).Seconds()),
+			Value:         status.Value,
+			PreviousValue: previous,
+		})
+		previous = status.Value
+	}
+	return intervals
+}
+
+// CreateBuckets collapses a timeline sorted by timestamp into consecutive buckets of the given size from
+// start to end, the last one is cut short at end. The dominant state of a bucket is the state the timeline
+// spent most time in and the worst state is the most severe state it entered. Ties between dominant states
+// go to the more severe one
+func CreateBuckets(statuses []*TimelineStatus, start time.Time, end time.Time, size time.Duration, order StateOrder) []*TimelineBucket {
+	buckets := []*TimelineBucket{}
+	for from := start; from.Before(end); from = from.Add(size) {
+		to := from.Add(size)
+		if to.After(end) {
+			to = end
+		}
+
+		bucket := &TimelineBucket{From: from.Format(zuluForm), To: to.Format(zuluForm)}
+		dominant := int64(0)
+		for _, state := range ComputeSummary(statuses, from, to, order.States, order.Missing).States {
+			if state.Seconds == 0 {
+				continue
+			}
+			if state.Seconds > dominant || (state.Seconds == dominant && order.Severity[state.Name] > order.Severity[bucket.Dominant]) {
+				dominant = state.Seconds
+				bucket.Dominant = state.Name
+			}
+			if bucket.Worst == "" || order.Severity[state.Name] > order.Severity[bucket.Worst] {
+				bucket.Worst = state.Name
+			}
+		}
+		buckets = append(buckets, bucket)
+	}
+	return buckets
+}
+
+// ComputeSummary measures the time a timeline sorted by timestamp spends in each one of the given states
+// between start and end. The time before the first status counts as missing
+func ComputeSummary(statuses []*TimelineStatus, start time.Time, end time.Time, states []string, missing string) *TimelineSummary {
+	seconds := map[string]int64{}
+	covered := start
+	for _, interval := range CreateIntervals(statuses, end) {
+		from, _ := time.Parse(zuluForm, interval.From)
+		to, _ := time.Parse(zuluForm, interval.To)
+		if !to.After(covered) {
+			continue
+		}
+		if from.After(covered) {
+			seconds[missing] += int64(from.Sub(covered).Seconds())
+			covered = from
+		}
+		seconds[interval.Value] += int64(to.Sub(covered).Seconds())
+		covered = to
+	}
+	if end.After(covered) {
+		seconds[missing] += int64(end.Sub(covered).Seconds())
+	}
+
+	summary := &TimelineSummary{TotalSeconds: int64(end.Sub(start).Seconds())}
+	for _, state := range states {
+		stateSummary := &StateSummary{Name: state, Seconds: seconds[state]}
+		if summary.TotalSeconds > 0 {
+			stateSummary.Percentage = 100 * float64(seconds[state]) / float64(summary.TotalSeconds)
+		}
+		summary.States = append(summary.States, stateSummary)
+	}
+	return summary
+}
+
+// ComputeStats scans the state transitions of a timeline sorted by timestamp and measures the
+// outages that overlap the time span between start and end. An outage lasts for as long as the
+// timeline stays in outage states and an outage still open at the end of the time span is clipped
+func ComputeStats(statuses []*TimelineStatus, start time.Time, end time.Time, outageStates map[string]bool) *OutageStats {
+	stats := &OutageStats{}
+	var downtime int64
+	var from time.Time
+	inOutage := false
+
+	closeOutage := func(to time.Time) {
+		inOutage = false
+		duration := int64(to.Sub(from).Seconds())
+		if duration <= 0 {
+			return
+		}
+		stats.Outages++
+		downtime += duration
+		if duration > stats.LongestOutage {
+			stats.LongestOutage = duration
+		}
+	}
+
+	for _, status := range statuses {
+		ts, err := time.Parse(zuluForm, status.Timestamp)
+		if err != nil {
+			continue
+		}
+		if ts.After(end) {
+			break
+		}
+		if ts.Before(start) {
+			ts = start
+		}
+		if outageStates[status.Value] && !inOutage {
+			inOutage = true
+			from = ts
+		} else if !outageStates[status.Value] && inOutage {
+			closeOutage(ts)
+		}
+	}
+	if inOutage {
+		closeOutage(end)
+	}
+
+	if stats.Outages > 0 {
+		stats.MTTR = float64(downtime) / float64(stats.Outages)
+		stats.MTBF = (end.Sub(start).Seconds() - float64(downtime)) / float64(stats.Outages)
+	}
+	return stats
+}
+
+// ExplainIntervals attaches to every interval of a timeline that is not in the OK state the contributors whose
+// timelines were not OK while it lasted, each one with the worst state it entered. The contributor function
+// describes the contributor whose timeline starts at a position of the contributor timelines. Contributors are
+// ordered by severity and then in the order of their timelines
+func ExplainIntervals(intervals []*TimelineInterval, timelines Timelines, contributor func(i int) *Contributor, end time.Time, order StateOrder) {
+	ok := order.OK()
+
+	// split the statuses into the timelines of the contributors
+	contributors := []*Contributor{}
+	lowerIntervals := [][]*TimelineInterval{}
+	for i := 0; i < timelines.Len(); {
+		statuses, j := NextTimeline(timelines, i)
+		contributors = append(contributors, contributor(i))
+		lowerIntervals = append(lowerIntervals, CreateIntervals(statuses, end))
+		i = j
+	}
+
+	for _, interval := range intervals {
+		if interval.Value == ok {
+			continue
+		}
+		for i, lowerTimeline := range lowerIntervals {
+			worst := ""
+			for _, lower := range lowerTimeline {
+				// zulu timestamps order the same way as the times they stand for
+				if lower.Value == ok || lower.From >= interval.To || lower.To <= interval.From {
+					continue
+				}
+				if worst == "" || order.Severity[lower.Value] > order.Severity[worst] {
+					worst = lower.Value
+				}
+			}
+			if worst != "" {
+				explained := *contributors[i]
+				explained.Status = worst
+				explained.severity = order.Severity[worst]
+				interval.Contributors = append(interval.Contributors, &explained)
+			}
+		}
+		sort.Stable(bySeverity(interval.Contributors))
+	}
+}
+
+type bySeverity []*Contributor
+
+func (c bySeverity) Len() int           { return len(c) }
+func (c bySeverity) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+func (c bySeverity) Less(i, j int) bool { return c[i].severity > c[j].severity }
+
+// ExplainRecords extends the csv record of an interval with each one of its contributors, or with empty
+// fields when the interval has no contributors
+func ExplainRecords(record []string, interval *TimelineInterval) [][]string {
+	if len(interval.Contributors) == 0 {
+		return [][]string{append(record, "", "", "", "", "")}
+	}
+	records := [][]string{}
+	for _, contributor := range interval.Contributors {
+		records = append(records, append(append([]string{}, record...),
+			contributor.Type, contributor.Service, contributor.Endpoint, contributor.Metric, contributor.Status))
+	}
+	return records
+}
@@ -0,0 +1,223 @@
+/*
+ * Copyright (c) 2015 GRNET S.A.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the
+ * License. You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an "AS
+ * IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language
+ * governing permissions and limitations under the License.
+ *
+ * The views and conclusions contained in the software and
+ * documentation are those of the authors and should not be
+ * interpreted as representing official policies, either expressed
+ * or implied, of GRNET S.A.
+ *
+ */
+
+package respond
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ARGOeu/argo-web-api/Godeps/_workspace/src/github.com/stretchr/testify/suite"
+)
+
+// testStatus is a status of the timeline of an entity as the status packages query it from datastore
+type testStatus struct {
+	entity    string
+	timestamp string
+	state     string
+}
+
+type testTimelines []testStatus
+
+func (t testTimelines) Len() int               { return len(t) }
+func (t testTimelines) Entity(i int) string    { return t[i].entity }
+func (t testTimelines) Timestamp(i int) string { return t[i].timestamp }
+func (t testTimelines) State(i int) string     { return t[i].state }
+
+// This is a util. suite struct used in tests (see pkg "testify")
+type TimelineTestSuite struct {
+	suite.Suite
+	timelines testTimelines
+	order     StateOrder
+	start     time.Time
+	end       time.Time
+}
+
+// SetupTest prepares the timelines of two endpoints over a day along with the states of an
+// operations profile, ranked the way its AND operation ranks them
+func (suite *TimelineTestSuite) SetupTest() {
+	suite.timelines = testTimelines{
+		{"cream01", "2015-05-01T00:00:00Z", "OK"},
+		{"cream01", "2015-05-01T01:00:00Z", "CRITICAL"},
+		{"cream01", "2015-05-01T05:00:00Z", "OK"},
+		{"cream01", "2015-05-01T06:00:00Z", "WARNING"},
+		{"cream02", "2015-05-01T00:00:00Z", "OK"},
+		{"cream02", "2015-05-01T12:00:00Z", "UNKNOWN"},
+	}
+	suite.order = StateOrder{
+		States:   []string{"OK", "WARNING", "UNKNOWN", "MISSING", "CRITICAL", "DOWNTIME"},
+		Missing:  "MISSING",
+		Severity: map[string]int{"OK": 0, "WARNING": 1, "UNKNOWN": 2, "MISSING": 3, "DOWNTIME": 4, "CRITICAL": 5},
+	}
+	suite.start, _ = time.Parse(zuluForm, "2015-05-01T00:00:00Z")
+	suite.end, _ = time.Parse(zuluForm, "2015-05-02T00:00:00Z")
+}
+
+// TestNextTimeline tests that the statuses are split into the timelines of the entities
+func (suite *TimelineTestSuite) TestNextTimeline() {
+	statuses, next := NextTimeline(suite.timelines, 0)
+	suite.Equal(4, next)
+	suite.Equal(4, len(statuses))
+	suite.Equal("WARNING", statuses[3].Value)
+
+	statuses, next = NextTimeline(suite.timelines, next)
+	suite.Equal(6, next)
+	suite.Equal([]*TimelineStatus{
+		{Timestamp: "2015-05-01T00:00:00Z", Value: "OK"},
+		{Timestamp: "2015-05-01T12:00:00Z", Value: "UNKNOWN"},
+	}, statuses)
+}
+
+// TestFilter tests that whole timelines are kept or dropped by the states they enter
+func (suite *TimelineTestSuite) TestFilter() {
+	suite.Equal([]int{0, 1, 2, 3, 4, 5}, StateFilter{}.Filter(suite.timelines))
+	suite.Equal([]int{0, 1, 2, 3}, StateFilter{Include: []string{"CRITICAL"}}.Filter(suite.timelines))
+	suite.Equal([]int{4, 5}, StateFilter{Exclude: []string{"OK", "CRITICAL", "WARNING"}}.Filter(suite.timelines))
+	suite.Equal([]int{}, StateFilter{Include: []string{"DOWNTIME"}}.Filter(suite.timelines))
+
+	// statuses without a state never match
+	suite.Equal([]int{}, StateFilter{Exclude: []string{"OK"}}.Filter(testTimelines{{"cream01", "2015-05-01T00:00:00Z", ""}}))
+}
+
+// TestFindGaps tests that gaps are found per timeline and that the last status of a timeline is
+// measured against the end of the time span
+func (suite *TimelineTestSuite) TestFindGaps() {
+	suite.Equal([]Gap{
+		{After: 1, Timestamp: "2015-05-01T04:00:00Z"},
+		{After: 3, Timestamp: "2015-05-01T09:00:00Z"},
+		{After: 4, Timestamp: "2015-05-01T03:00:00Z"},
+		{After: 5, Timestamp: "2015-05-01T15:00:00Z"},
+	}, FindGaps(suite.timelines, 3*time.Hour, "MISSING", suite.end))
+
+	suite.Equal([]Gap{}, FindGaps(suite.timelines, 0, "MISSING", suite.end))
+	suite.Equal([]Gap{}, FindGaps(testTimelines{{"cream01", "2015-05-01T00:00:00Z", "MISSING"}}, time.Hour, "MISSING", suite.end))
+}
+
+// TestCreateIntervals tests that intervals last until the next status and the last one is clipped at the end
+func (suite *TimelineTestSuite) TestCreateIntervals() {
+	statuses, _ := NextTimeline(suite.timelines, 0)
+	intervals := CreateIntervals(statuses, suite.end)
+
+	suite.Equal(4, len(intervals))
+	suite.Equal(&TimelineInterval{From: "2015-05-01T01:00:00Z", To: "2015-05-01T05:00:00Z", Duration: 14400, Value: "CRITICAL", PreviousValue: "OK"}, intervals[1])
+	suite.Equal(&TimelineInterval{From: "2015-05-01T06:00:00Z", To: "2015-05-02T00:00:00Z", Duration: 64800, Value: "WARNING", PreviousValue: "OK"}, intervals[3])
+
+	// statuses at or after the end are dropped
+	early, _ := time.Parse(zuluForm, "2015-05-01T03:00:00Z")
+	intervals = CreateIntervals(statuses, early)
+	suite.Equal(2, len(intervals))
+	suite.Equal("2015-05-01T03:00:00Z", intervals[1].To)
+}
+
+// TestCreateBuckets tests the dominant and worst states of the buckets of a timeline
+func (suite *TimelineTestSuite) TestCreateBuckets() {
+	statuses, _ := NextTimeline(suite.timelines, 0)
+	buckets := CreateBuckets(statuses, suite.start, suite.end, 8*time.Hour, suite.order)
+
+	suite.Equal([]*TimelineBucket{
+		{From: "2015-05-01T00:00:00Z", To: "2015-05-01T08:00:00Z", Dominant: "CRITICAL", Worst: "CRITICAL"},
+		{From: "2015-05-01T08:00:00Z", To: "2015-05-01T16:00:00Z", Dominant: "WARNING", Worst: "WARNING"},
+		{From: "2015-05-01T16:00:00Z", To: "2015-05-02T00:00:00Z", Dominant: "WARNING", Worst: "WARNING"},
+	}, buckets)
+
+	// ties between dominant states go to the more severe one and the last bucket is cut short
+	buckets = CreateBuckets(statuses, suite.start, suite.start.Add(2*time.Hour), 90*time.Minute, suite.order)
+	suite.Equal([]*TimelineBucket{
+		{From: "2015-05-01T00:00:00Z", To: "2015-05-01T01:30:00Z", Dominant: "OK", Worst: "CRITICAL"},
+		{From: "2015-05-01T01:30:00Z", To: "2015-05-01T02:00:00Z", Dominant: "CRITICAL", Worst: "CRITICAL"},
+	}, buckets)
+
+	tied := []*TimelineStatus{
+		{Timestamp: "2015-05-01T00:00:00Z", Value: "CRITICAL"},
+		{Timestamp: "2015-05-01T01:00:00Z", Value: "DOWNTIME"},
+	}
+	buckets = CreateBuckets(tied, suite.start, suite.start.Add(2*time.Hour), 2*time.Hour, suite.order)
+	suite.Equal("CRITICAL", buckets[0].Dominant)
+	suite.Equal("CRITICAL", buckets[0].Worst)
+}
+
+// TestComputeSummary tests that the time before the first status counts as missing
+func (suite *TimelineTestSuite) TestComputeSummary() {
+	statuses := []*TimelineStatus{
+		{Timestamp: "2015-05-01T06:00:00Z", Value: "OK"},
+		{Timestamp: "2015-05-01T18:00:00Z", Value: "CRITICAL"},
+	}
+	summary := ComputeSummary(statuses, suite.start, suite.end, suite.order.States, suite.order.Missing)
+
+	suite.Equal(int64(86400), summary.TotalSeconds)
+	suite.Equal(len(suite.order.States), len(summary.States))
+	suite.Equal(&StateSummary{Name: "OK", Seconds: 43200, Percentage: 50}, summary.States[0])
+	suite.Equal(&StateSummary{Name: "MISSING", Seconds: 21600, Percentage: 25}, summary.States[3])
+	suite.Equal(&StateSummary{Name: "CRITICAL", Seconds: 21600, Percentage: 25}, summary.States[4])
+}
+
+// TestComputeStats tests the outages of a timeline, including one still open at the end
+func (suite *TimelineTestSuite) TestComputeStats() {
+	statuses := []*TimelineStatus{
+		{Timestamp: "2015-04-30T22:00:00Z", Value: "CRITICAL"},
+		{Timestamp: "2015-05-01T02:00:00Z", Value: "OK"},
+		{Timestamp: "2015-05-01T10:00:00Z", Value: "DOWNTIME"},
+		{Timestamp: "2015-05-01T12:00:00Z", Value: "CRITICAL"},
+		{Timestamp: "2015-05-01T14:00:00Z", Value: "OK"},
+		{Timestamp: "2015-05-01T20:00:00Z", Value: "CRITICAL"},
+	}
+	stats := ComputeStats(statuses, suite.start, suite.end, map[string]bool{"CRITICAL": true, "DOWNTIME": true})
+
+	suite.Equal(int64(3), stats.Outages)
+	suite.Equal(int64(4*3600), stats.LongestOutage)
+	suite.Equal(float64(10*3600/3), stats.MTTR)
+	suite.Equal(float64(14*3600/3), stats.MTBF)
+
+	suite.Equal(&OutageStats{}, ComputeStats(statuses[1:2], suite.start, suite.end, map[string]bool{"CRITICAL": true}))
+}
+
+// TestExplainIntervals tests that the intervals that are not OK are explained by the worst states
+// their contributors entered while they lasted, most severe first
+func (suite *TimelineTestSuite) TestExplainIntervals() {
+	upper := []*TimelineStatus{
+		{Timestamp: "2015-05-01T00:00:00Z", Value: "OK"},
+		{Timestamp: "2015-05-01T01:00:00Z", Value: "CRITICAL"},
+		{Timestamp: "2015-05-01T14:00:00Z", Value: "OK"},
+	}
+	intervals := CreateIntervals(upper, suite.end)
+	contributor := func(i int) *Contributor {
+		return &Contributor{Type: "endpoint", Service: "CREAM-CE", Endpoint: suite.timelines[i].entity}
+	}
+	ExplainIntervals(intervals, suite.timelines, contributor, suite.end, suite.order)
+
+	suite.Equal(0, len(intervals[0].Contributors))
+	suite.Equal(0, len(intervals[2].Contributors))
+	suite.Equal([]*Contributor{
+		{Type: "endpoint", Service: "CREAM-CE", Endpoint: "cream01", Status: "CRITICAL", severity: 5},
+		{Type: "endpoint", Service: "CREAM-CE", Endpoint: "cream02", Status: "UNKNOWN", severity: 2},
+	}, intervals[1].Contributors)
+
+	suite.Equal([][]string{
+		{"2015-05-01T01:00:00Z", "endpoint", "CREAM-CE", "cream01", "", "CRITICAL"},
+		{"2015-05-01T01:00:00Z", "endpoint", "CREAM-CE", "cream02", "", "UNKNOWN"},
+	}, ExplainRecords([]string{"2015-05-01T01:00:00Z"}, intervals[1]))
+	suite.Equal([][]string{{"2015-05-01T00:00:00Z", "", "", "", "", ""}}, ExplainRecords([]string{"2015-05-01T00:00:00Z"}, intervals[0]))
+}
+
+func TestTimelineSuite(t *testing.T) {
+	suite.Run(t, new(TimelineTestSuite))
+}
@@ -29,6 +29,7 @@ package respond
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -78,3 +79,218 @@ func ValidateDateRange(dateStart string, dateEnd string) (int, int, []ErrorRespo
 
 	return parsedStart, parsedEnd, errs
 }
+
+// ValidateIntervals checks whether timelines are requested as intervals. Intervals need the
+// end_time of the time span since the last interval of a timeline is clipped at it. The parsed
+// end_time is returned whenever it is set
+func ValidateIntervals(intervals string, dateEnd string) (bool, time.Time, []ErrorResponse) {
+	errs := []ErrorResponse{}
+
+	// a malformed end_time is already reported by ValidateDateRange
+	parsedEnd, _ := time.Parse(zuluForm, dateEnd)
+	if intervals == "" {
+		return false, parsedEnd, errs
+	}
+
+	parsedIntervals, err := strconv.ParseBool(intervals)
+	if err != nil {
+		errs = append(errs, ErrorResponse{
+			Message: "Wrong intervals",
+			Code:    "400",
+			Details: fmt.Sprintf("%s is not accepted as intervals parameter, please provide either true or false", intervals),
+		})
+		return false, parsedEnd, errs
+	}
+
+	if parsedIntervals && dateEnd == "" {
+		errs = append(errs, ErrorResponse{
+			Message: "No end_time set",
+			Code:    "400",
+			Details: "Please use the end_time url parameter to set the end of the last interval",
+		})
+	}
+
+	return parsedIntervals, parsedEnd, errs
+}
+
+// bucketSizes lists the sizes of the buckets timelines can be collapsed into
+var bucketSizes = map[string]time.Duration{
+	"1h": time.Hour,
+	"6h": 6 * time.Hour,
+	"1d": 24 * time.Hour,
+}
+
+// ValidateBucket checks the size of the buckets timelines are collapsed into. Buckets are laid out
+// from the start_time to the end_time of the time span so both of them are needed
+func ValidateBucket(bucket string, dateStart string, dateEnd string) (time.Duration, time.Time, []ErrorResponse) {
+	errs := []ErrorResponse{}
+
+	// a malformed start_time is already reported by ValidateDateRange
+	parsedStart, errStart := time.Parse(zuluForm, dateStart)
+	if bucket == "" {
+		return 0, parsedStart, errs
+	}
+
+	size, ok := bucketSizes[bucket]
+	if !ok {
+		errs = append(errs, ErrorResponse{
+			Message: "Wrong bucket",
+			Code:    "400",
+			Details: fmt.Sprintf("%s is not accepted as bucket, please use one of 1h, 6h or 1d", bucket),
+		})
+		return 0, parsedStart, errs
+	}
+
+	parsedEnd, errEnd := time.Parse(zuluForm, dateEnd)
+	if dateStart == "" || dateEnd == "" || (errStart == nil && errEnd == nil && !parsedEnd.After(parsedStart)) {
+		errs = append(errs, ErrorResponse{
+			Message: "Wrong time span",
+			Code:    "400",
+			Details: "Please use both start_time and end_time url parameters and an end_time after the start_time to lay out the buckets",
+		})
+	}
+
+	return size, parsedStart, errs
+}
+
+// ValidateExplain checks whether the non OK intervals of timelines are explained by the timelines below
+// them. Explanations are attached to intervals so they need the end_time and can not be used with buckets
+func ValidateExplain(explain string, bucket string, dateEnd string) (bool, []ErrorResponse) {
+	errs := []ErrorResponse{}
+	if explain == "" {
+		return false, errs
+	}
+
+	parsedExplain, err := strconv.ParseBool(explain)
+	if err != nil {
+		errs = append(errs, ErrorResponse{
+			Message: "Wrong explain",
+			Code:    "400",
+			Details: fmt.Sprintf("%s is not accepted as explain parameter, please provide either true or false", explain),
+		})
+		return false, errs
+	}
+
+	if parsedExplain && dateEnd == "" {
+		errs = append(errs, ErrorResponse{
+			Message: "No end_time set",
+			Code:    "400",
+			Details: "Please use the end_time url parameter to set the end of the last interval",
+		})
+	}
+
+	if parsedExplain && bucket != "" {
+		errs = append(errs, ErrorResponse{
+			Message: "Wrong explain",
+			Code:    "400",
+			Details: "Explanations are attached to intervals and can not be combined with buckets",
+		})
+	}
+
+	return parsedExplain, errs
+}
+
+// ValidateFillGaps checks whether the gaps of timelines are filled with the missing state and the
+// threshold above which a gap is filled. Without a threshold the one of the report is used. The last
+// status of a timeline is followed by a gap when it is older than the threshold at the end_time
+func ValidateFillGaps(fillGaps string, threshold string, dateEnd string) (bool, time.Duration, []ErrorResponse) {
+	errs := []ErrorResponse{}
+	if fillGaps == "" {
+		return false, 0, errs
+	}
+
+	parsedFillGaps, err := strconv.ParseBool(fillGaps)
+	if err != nil {
+		errs = append(errs, ErrorResponse{
+			Message: "Wrong fill_gaps",
+			Code:    "400",
+			Details: fmt.Sprintf("%s is not accepted as fill_gaps parameter, please provide either true or false", fillGaps),
+		})
+		return false, 0, errs
+	}
+
+	if parsedFillGaps && dateEnd == "" {
+		errs = append(errs, ErrorResponse{
+			Message: "No end_time set",
+			Code:    "400",
+			Details: "Please use the end_time url parameter to set the end of the last gap",
+		})
+	}
+
+	if !parsedFillGaps || threshold == "" {
+		return parsedFillGaps, 0, errs
+	}
+
+	parsedThreshold, err := time.ParseDuration(threshold)
+	if err != nil || parsedThreshold <= 0 {
+		errs = append(errs, ErrorResponse{
+			Message: "Wrong gap threshold",
+			Code:    "400",
+			Details: fmt.Sprintf("%s is not accepted as gap_threshold, please provide a positive duration such as 30m or 2h", threshold),
+		})
+	}
+
+	return parsedFillGaps, parsedThreshold, errs
+}
+
+// StateFilter holds the states requested with the status and exclude_status url parameters
+type StateFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// ParseStateFilter splits the comma separated states of the status and exclude_status url parameters
+func ParseStateFilter(status string, excludeStatus string) StateFilter {
+	filter := StateFilter{}
+	for _, state := range strings.Split(status, ",") {
+		if state != "" {
+			filter.Include = append(filter.Include, state)
+		}
+	}
+	for _, state := range strings.Split(excludeStatus, ",") {
+		if state != "" {
+			filter.Exclude = append(filter.Exclude, state)
+		}
+	}
+	return filter
+}
+
+// IsSet tells whether any state has been requested or excluded
+func (filter StateFilter) IsSet() bool {
+	return len(filter.Include) > 0 || len(filter.Exclude) > 0
+}
+
+// Matches tells whether a state is one of the requested states, when there are any, and
+// is not excluded
+func (filter StateFilter) Matches(state string) bool {
+	for _, excluded := range filter.Exclude {
+		if state == excluded {
+			return false
+		}
+	}
+	if len(filter.Include) == 0 {
+		return true
+	}
+	for _, included := range filter.Include {
+		if state == included {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that the requested and excluded states are declared in the operations profile
+// through the hasState function of the profile
+func (filter StateFilter) Validate(hasState func(string) bool, profile string) []ErrorResponse {
+	errs := []ErrorResponse{}
+	for _, state := range append(append([]string{}, filter.Include...), filter.Exclude...) {
+		if !hasState(state) {
+			errs = append(errs, ErrorResponse{
+				Message: "Unknown state",
+				Code:    "400",
+				Details: fmt.Sprintf("The state %s is not declared in the operations profile %s", state, profile),
+			})
+		}
+	}
+	return errs
+}
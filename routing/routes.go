@@ -33,6 +33,10 @@ import (
 	"github.com/ARGOeu/argo-web-api/app/results"
 	"github.com/ARGOeu/argo-web-api/app/statusEndpointGroups"
 	"github.com/ARGOeu/argo-web-api/app/statusEndpoints"
+	"github.com/ARGOeu/argo-web-api/app/statusEvents"
+	"github.com/ARGOeu/argo-web-api/app/statusFlapping"
+	"github.com/ARGOeu/argo-web-api/app/statusHosts"
+	"github.com/ARGOeu/argo-web-api/app/statusLatest"
 	"github.com/ARGOeu/argo-web-api/app/statusMetrics"
 	"github.com/ARGOeu/argo-web-api/app/statusServices"
 	"github.com/ARGOeu/argo-web-api/app/tenants"
@@ -41,6 +45,10 @@ import (
 var routesV2 = []RouteV2{
 	{"Results", "/results", results.HandleSubrouter},
 	{"Metric Result", "/metric_result", metricResult.HandleSubrouter},
+	{"Status latest snapshot", "/status", statusLatest.HandleSubrouter},
+	{"Status flapping", "/status", statusFlapping.HandleSubrouter},
+	{"Status events", "/status", statusEvents.HandleSubrouter},
+	{"Status host timelines", "/status", statusHosts.HandleSubrouter},
 	{"Status metric timelines", "/status", statusMetrics.HandleSubrouter},
 	{"Status service timelines", "/status", statusServices.HandleSubrouter},
 	{"Status endpoint group timelines", "/status", statusEndpointGroups.HandleSubrouter},